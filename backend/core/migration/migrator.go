@@ -87,6 +87,18 @@ func (m *migratorImpl) Info(stage string) {
 
 // Execute all registered migration script in order and mark them as executed in migration_history table
 func (m *migratorImpl) Execute() errors.Error {
+	// other instances sharing the database might have executed some of the scripts in the meantime
+	err := m.loadExecuted()
+	if err != nil {
+		return err
+	}
+	pending := make([]*scriptWithComment, 0, len(m.pending))
+	for _, swc := range m.pending {
+		if !m.executed[getScriptId(swc.script.Name(), swc.script.Version())] {
+			pending = append(pending, swc)
+		}
+	}
+	m.pending = pending
 	// sort the scripts by version
 	sort.Slice(m.pending, func(i, j int) bool {
 		return m.pending[i].script.Version() < m.pending[j].script.Version()
@@ -36,7 +36,8 @@ import (
 func TestHasPendingScripts(t *testing.T) {
 	// simulate db reaction
 	mockDal := new(mockdal.Dal)
-	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
+	// the history is loaded on initialization and reloaded before execution
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Twice()
 	mockDal.On("All", mock.Anything, mock.Anything).Return(func(i interface{}, _ ...dal.Clause) errors.Error {
 		precords := i.(*[]MigrationHistory)
 		*precords = []MigrationHistory{
@@ -45,7 +46,7 @@ func TestHasPendingScripts(t *testing.T) {
 			{ScriptName: "C", ScriptVersion: 3, Comment: "UniTest", CreatedAt: time.Now()},
 		}
 		return nil
-	}).Once()
+	}).Twice()
 	mockDal.On("Create", &MigrationHistory{
 		ScriptName:    "E",
 		ScriptVersion: 4,
@@ -85,3 +86,41 @@ func TestHasPendingScripts(t *testing.T) {
 	// make sure all method got called
 	mockDal.AssertExpectations(t)
 }
+
+func TestExecuteSkipsScriptsExecutedByOthers(t *testing.T) {
+	history := []MigrationHistory{
+		{ScriptName: "A", ScriptVersion: 1, Comment: "UniTest", CreatedAt: time.Now()},
+	}
+	mockDal := new(mockdal.Dal)
+	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil)
+	mockDal.On("All", mock.Anything, mock.Anything).Return(func(i interface{}, _ ...dal.Clause) errors.Error {
+		*i.(*[]MigrationHistory) = history
+		return nil
+	})
+	mockDal.On("Create", &MigrationHistory{
+		ScriptName:    "C",
+		ScriptVersion: 3,
+		Comment:       "UnitTest",
+	}, mock.Anything).Return(nil).Once()
+
+	basicRes := context.NewDefaultBasicRes(viper.New(), unithelper.DummyLogger(), mockDal)
+	migrator, err := NewMigrator(basicRes)
+	assert.Nil(t, err)
+	scriptB := new(mockplugin.MigrationScript)
+	scriptB.On("Version").Return(uint64(2))
+	scriptB.On("Name").Return("B")
+	scriptC := new(mockplugin.MigrationScript)
+	scriptC.On("Up", mock.Anything).Return(nil).Once()
+	scriptC.On("Version").Return(uint64(3))
+	scriptC.On("Name").Return("C")
+	migrator.Register([]plugin.MigrationScript{scriptB, scriptC}, "UnitTest")
+	assert.True(t, migrator.HasPendingScripts())
+
+	// another instance sharing the database executed B in the meantime
+	history = append(history, MigrationHistory{ScriptName: "B", ScriptVersion: 2, Comment: "UnitTest", CreatedAt: time.Now()})
+	assert.Nil(t, migrator.Execute())
+	assert.False(t, migrator.HasPendingScripts())
+	scriptB.AssertNotCalled(t, "Up", mock.Anything)
+	mockDal.AssertExpectations(t)
+	scriptC.AssertExpectations(t)
+}
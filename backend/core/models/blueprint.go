@@ -28,6 +28,11 @@ const (
 	BLUEPRINT_MODE_ADVANCED = "ADVANCED"
 )
 
+const (
+	BLUEPRINT_TRIGGER_BLUEPRINT    = "BLUEPRINT"
+	BLUEPRINT_TRIGGER_PLUGIN_EVENT = "PLUGIN_EVENT"
+)
+
 // @Description CronConfig
 type Blueprint struct {
 	Name         string                 `json:"name" validate:"required"`
@@ -42,6 +47,8 @@ type Blueprint struct {
 	Labels       []string               `json:"labels" gorm:"-"`
 	Connections  []*BlueprintConnection `json:"connections" gorm:"-"`
 	Priority     int                    `json:"priority"` // greater is higher
+	Triggers     []*BlueprintTrigger    `json:"triggers" gorm:"-"`
+	Debounce     int                    `json:"debounce"` // seconds to wait for more triggering events before running
 	SyncPolicy   `gorm:"embedded"`
 	common.Model `swaggerignore:"true"`
 }
@@ -83,13 +90,45 @@ func (BlueprintScope) TableName() string {
 	return "_devlake_blueprint_scopes"
 }
 
+// BlueprintTrigger runs the blueprint when all the upstream blueprints of its BLUEPRINT triggers succeeded,
+// or when a plugin published a matching PLUGIN_EVENT, e.g. a new deployment was posted to the webhook plugin
+type BlueprintTrigger struct {
+	ID                  uint64 `json:"-" gorm:"primaryKey"`
+	BlueprintId         uint64 `json:"-" gorm:"index"`
+	Type                string `json:"type" gorm:"type:varchar(20)" validate:"required,oneof=BLUEPRINT PLUGIN_EVENT"`
+	UpstreamBlueprintId uint64 `json:"upstreamBlueprintId,omitempty" gorm:"index"`
+	PluginName          string `json:"pluginName,omitempty" gorm:"type:varchar(255)"`
+	EventName           string `json:"eventName,omitempty" gorm:"type:varchar(255)"`
+	ConnectionId        uint64 `json:"connectionId,omitempty"` // 0 matches all connections
+}
+
+func (BlueprintTrigger) TableName() string {
+	return "_devlake_blueprint_triggers"
+}
+
+// BlueprintDebounce is a triggered blueprint waiting for its debounce window to pass, it is persisted so the
+// run survives restarts and is carried out once by the leader in the cluster mode
+type BlueprintDebounce struct {
+	BlueprintId uint64    `json:"blueprintId" gorm:"primaryKey;autoIncrement:false"`
+	RunAt       time.Time `json:"runAt" gorm:"index"`
+	Reason      string    `json:"reason"`
+}
+
+func (BlueprintDebounce) TableName() string {
+	return "_devlake_blueprint_debounces"
+}
+
 type TriggerSyncPolicy struct {
 	SkipCollectors bool `json:"skipCollectors"`
 	FullSync       bool `json:"fullSync"`
 }
 
 type SyncPolicy struct {
-	SkipOnFail bool       `json:"skipOnFail"`
-	TimeAfter  *time.Time `json:"timeAfter"`
+	SkipOnFail  bool       `json:"skipOnFail"`
+	TimeAfter   *time.Time `json:"timeAfter"`
+	Timeout     int        `json:"timeout"`     // seconds the whole pipeline may run, 0 means no limit
+	TaskTimeout int        `json:"taskTimeout"` // seconds each task may run, 0 means no limit
+	// Retry overrides the retry policies of all subtasks if specified
+	Retry *RetryPolicy `json:"retry" gorm:"type:json;serializer:json"`
 	TriggerSyncPolicy
 }
@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossdomain
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+)
+
+const (
+	// REWORK_TYPE_ROLLBACK means the deployment rolled back to a commit deployed before the failed deployment
+	REWORK_TYPE_ROLLBACK = "ROLLBACK"
+	// REWORK_TYPE_HOTFIX means the deployment fixed forward the failed deployment
+	REWORK_TYPE_HOTFIX = "HOTFIX"
+)
+
+// ProjectDeploymentMetric holds the rework and recovery of the production deployments of a project, the id is
+// the id of the deployment
+type ProjectDeploymentMetric struct {
+	domainlayer.DomainEntity
+	ProjectName  string `gorm:"primaryKey;type:varchar(100)"`
+	CicdScopeId  string `gorm:"type:varchar(255)"`
+	Name         string `gorm:"type:varchar(255)"`
+	FinishedDate *time.Time
+	// IsFailed is true if the deployment caused incidents
+	IsFailed bool
+	// IsRework is true if the deployment was unplanned to roll back or fix forward a failed deployment
+	IsRework             bool
+	ReworkType           string `gorm:"type:varchar(100)"`
+	ReworkOfDeploymentId string `gorm:"type:varchar(255)"`
+	// RecoveredDate is when the failed deployment was recovered by a rework deployment, or the resolution
+	// of its incidents if there was no rework deployment
+	RecoveredDate           *time.Time
+	RecoveredByDeploymentId string `gorm:"type:varchar(255)"`
+	// RecoveryTime is the failed deployment recovery time in minutes
+	RecoveryTime *int64
+}
+
+func (ProjectDeploymentMetric) TableName() string {
+	return "project_deployment_metrics"
+}
@@ -21,10 +21,24 @@ import (
 	"github.com/apache/incubator-devlake/core/models/domainlayer"
 )
 
+const (
+	// ATTRIBUTION_STRATEGY_REFERENCE attributes the incident to the first deployment of the pull requests or commits
+	// referenced by the incident
+	ATTRIBUTION_STRATEGY_REFERENCE = "REFERENCE"
+	// ATTRIBUTION_STRATEGY_SERVICE attributes the incident to the latest deployment of the service of its component
+	ATTRIBUTION_STRATEGY_SERVICE = "SERVICE"
+	// ATTRIBUTION_STRATEGY_TIME_WINDOW attributes the incident to the latest deployment within a time window before
+	// the incident, of the service of its component if any
+	ATTRIBUTION_STRATEGY_TIME_WINDOW = "TIME_WINDOW"
+	// ATTRIBUTION_STRATEGY_LATEST attributes the incident to the latest deployment of the project
+	ATTRIBUTION_STRATEGY_LATEST = "LATEST"
+)
+
 type ProjectIncidentDeploymentRelationship struct {
 	domainlayer.DomainEntity
-	ProjectName  string `gorm:"primaryKey;type:varchar(100)"`
-	DeploymentId string
+	ProjectName         string `gorm:"primaryKey;type:varchar(100)"`
+	DeploymentId        string
+	AttributionStrategy string `gorm:"type:varchar(100)"`
 }
 
 func (ProjectIncidentDeploymentRelationship) TableName() string {
@@ -35,11 +35,20 @@ type ProjectPrMetric struct {
 	PrDeployTime       *int64
 	PrCycleTime        *int64
 
+	// the first deployments to the TESTING and STAGING environments, the production deployment is the
+	// DeploymentCommitId above
+	TestingDeploymentCommitId string `gorm:"type:varchar(255)"`
+	PrTestingDeployTime       *int64
+	StagingDeploymentCommitId string `gorm:"type:varchar(255)"`
+	PrStagingDeployTime       *int64
+
 	FirstCommitAuthoredDate *time.Time
 	FirstCommentDate        *time.Time
 	PrCreatedDate           *time.Time
 	PrMergedDate            *time.Time
 	PrDeployedDate          *time.Time
+	PrTestingDeployedDate   *time.Time
+	PrStagingDeployedDate   *time.Time
 }
 
 func (ProjectPrMetric) TableName() string {
@@ -0,0 +1,69 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crossdomain
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+)
+
+// ProjectServiceDeployment is a production deployment of a service of a project, the id is the id of the deployment.
+// A deployment could deploy more than one service of a monorepo project
+type ProjectServiceDeployment struct {
+	domainlayer.DomainEntity
+	ProjectName  string `gorm:"primaryKey;type:varchar(100)"`
+	ServiceName  string `gorm:"primaryKey;type:varchar(100)"`
+	CicdScopeId  string `gorm:"type:varchar(255)"`
+	Name         string `gorm:"type:varchar(255)"`
+	FinishedDate *time.Time
+	// IsFailed is true if the deployment caused incidents of the service
+	IsFailed bool
+}
+
+func (ProjectServiceDeployment) TableName() string {
+	return "project_service_deployments"
+}
+
+// ProjectServicePrMetric is the change lead time of a pull request of a service of a project, the id is the id of
+// the pull request
+type ProjectServicePrMetric struct {
+	domainlayer.DomainEntity
+	ProjectName        string `gorm:"primaryKey;type:varchar(100)"`
+	ServiceName        string `gorm:"primaryKey;type:varchar(100)"`
+	DeploymentCommitId string `gorm:"type:varchar(255)"`
+	PrCycleTime        *int64
+	PrDeployedDate     *time.Time
+}
+
+func (ProjectServicePrMetric) TableName() string {
+	return "project_service_pr_metrics"
+}
+
+// ProjectServiceIncident is an incident of a service of a project, the id is the id of the incident
+type ProjectServiceIncident struct {
+	domainlayer.DomainEntity
+	ProjectName string `gorm:"primaryKey;type:varchar(100)"`
+	ServiceName string `gorm:"primaryKey;type:varchar(100)"`
+	// DeploymentId is the deployment of the service causing the incident
+	DeploymentId string `gorm:"type:varchar(255)"`
+}
+
+func (ProjectServiceIncident) TableName() string {
+	return "project_service_incidents"
+}
@@ -62,6 +62,10 @@ func GetDomainTablesInfo() []dal.Tabler {
 		&crossdomain.IssueCommit{},
 		&crossdomain.IssueRepoCommit{},
 		&crossdomain.ProjectMapping{},
+		&crossdomain.ProjectDeploymentMetric{},
+		&crossdomain.ProjectServiceDeployment{},
+		&crossdomain.ProjectServicePrMetric{},
+		&crossdomain.ProjectServiceIncident{},
 		&crossdomain.ProjectIncidentDeploymentRelationship{},
 		&crossdomain.ProjectPrMetric{},
 		&crossdomain.PullRequestIssue{},
@@ -27,7 +27,8 @@ import "time"
 // 3. Update the record with `Succeeded=true` if it had obtained the lock successfully
 //
 // NOTE: it works IFF all devlake instances obey the principle described above, in other words, this mechanism can
-// not prevent older versions from sharing the same database
+// not prevent older versions from sharing the same database. It is skipped when CLUSTER_MODE is enabled, check the
+// Worker for the detail.
 type LockingHistory struct {
 	ID        uint64 `gorm:"primaryKey" json:"id"`
 	HostName  string
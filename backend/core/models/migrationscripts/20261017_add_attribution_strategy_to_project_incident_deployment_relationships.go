@@ -0,0 +1,48 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+var _ plugin.MigrationScript = (*addAttributionStrategyToProjectIncidentDeploymentRelationships)(nil)
+
+type addAttributionStrategyToProjectIncidentDeploymentRelationships struct{}
+
+type projectIncidentDeploymentRelationship20261017 struct {
+	AttributionStrategy string `gorm:"type:varchar(100)"`
+}
+
+func (projectIncidentDeploymentRelationship20261017) TableName() string {
+	return "project_incident_deployment_relationships"
+}
+
+func (script *addAttributionStrategyToProjectIncidentDeploymentRelationships) Up(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&projectIncidentDeploymentRelationship20261017{})
+}
+
+func (*addAttributionStrategyToProjectIncidentDeploymentRelationships) Version() uint64 {
+	return 20261017200000
+}
+
+func (*addAttributionStrategyToProjectIncidentDeploymentRelationships) Name() string {
+	return "add attribution_strategy to project_incident_deployment_relationships"
+}
@@ -0,0 +1,53 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addBlueprintDebounces)(nil)
+
+type addBlueprintDebounces struct{}
+
+type blueprintDebounce20261017 struct {
+	BlueprintId uint64    `gorm:"primaryKey;autoIncrement:false"`
+	RunAt       time.Time `gorm:"index"`
+	Reason      string
+}
+
+func (blueprintDebounce20261017) TableName() string {
+	return "_devlake_blueprint_debounces"
+}
+
+func (script *addBlueprintDebounces) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, new(blueprintDebounce20261017))
+}
+
+func (*addBlueprintDebounces) Version() uint64 {
+	return 20261017230000
+}
+
+func (*addBlueprintDebounces) Name() string {
+	return "add blueprint debounces for the triggered blueprints"
+}
@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addBlueprintTriggers)(nil)
+
+type addBlueprintTriggers struct{}
+
+type blueprint20261017 struct {
+	Debounce int
+}
+
+func (blueprint20261017) TableName() string {
+	return "_devlake_blueprints"
+}
+
+type blueprintTrigger20261017 struct {
+	ID                  uint64 `gorm:"primaryKey"`
+	BlueprintId         uint64 `gorm:"index"`
+	Type                string `gorm:"type:varchar(20)"`
+	UpstreamBlueprintId uint64 `gorm:"index"`
+	PluginName          string `gorm:"type:varchar(255)"`
+	EventName           string `gorm:"type:varchar(255)"`
+	ConnectionId        uint64
+}
+
+func (blueprintTrigger20261017) TableName() string {
+	return "_devlake_blueprint_triggers"
+}
+
+func (script *addBlueprintTriggers) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, new(blueprint20261017), new(blueprintTrigger20261017))
+}
+
+func (*addBlueprintTriggers) Version() uint64 {
+	return 20261017120000
+}
+
+func (*addBlueprintTriggers) Name() string {
+	return "add blueprint triggers"
+}
@@ -0,0 +1,53 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addClusterLocks)(nil)
+
+type addClusterLocks struct{}
+
+type clusterLock20261017 struct {
+	Name           string `gorm:"primaryKey;type:varchar(255)"`
+	WorkerId       string `gorm:"type:varchar(255);index"`
+	LeaseExpiresAt *time.Time
+}
+
+func (clusterLock20261017) TableName() string {
+	return "_devlake_cluster_locks"
+}
+
+func (script *addClusterLocks) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, new(clusterLock20261017))
+}
+
+func (*addClusterLocks) Version() uint64 {
+	return 20261017220000
+}
+
+func (*addClusterLocks) Name() string {
+	return "add cluster locks for the cluster mode"
+}
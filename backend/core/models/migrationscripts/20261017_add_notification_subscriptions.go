@@ -0,0 +1,78 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addNotificationSubscriptions)(nil)
+
+type addNotificationSubscriptions struct{}
+
+type notification20261017 struct {
+	SubscriptionId uint64 `gorm:"index"`
+	Channel        string `gorm:"type:varchar(20)"`
+	Status         string `gorm:"type:varchar(20);index"`
+	Attempts       int
+	NextRetryAt    *time.Time `gorm:"index"`
+}
+
+func (notification20261017) TableName() string {
+	return "_devlake_notifications"
+}
+
+type notificationSubscription20261017 struct {
+	archived.Model
+	Name        string `gorm:"type:varchar(255)"`
+	ProjectName string `gorm:"type:varchar(255);index"`
+	BlueprintId uint64 `gorm:"index"`
+	Channel     string `gorm:"type:varchar(20)"`
+	Endpoint    string
+	Secret      string
+	Events      string `gorm:"type:json"`
+	Enable      bool
+}
+
+func (notificationSubscription20261017) TableName() string {
+	return "_devlake_notification_subscriptions"
+}
+
+func (script *addNotificationSubscriptions) Up(basicRes context.BasicRes) errors.Error {
+	err := migrationhelper.AutoMigrateTables(basicRes, new(notification20261017), new(notificationSubscription20261017))
+	if err != nil {
+		return err
+	}
+	// notifications sent before were delivered once and never retried
+	return basicRes.GetDal().UpdateColumn("_devlake_notifications", "status", "SENT", dal.Where("status IS NULL OR status = ''"))
+}
+
+func (*addNotificationSubscriptions) Version() uint64 {
+	return 20261017100000
+}
+
+func (*addNotificationSubscriptions) Name() string {
+	return "add notification subscriptions and delivery status"
+}
@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addProjectDeploymentMetrics)(nil)
+
+type projectDeploymentMetric20261017 struct {
+	archived.DomainEntity
+	ProjectName             string `gorm:"primaryKey;type:varchar(100)"`
+	CicdScopeId             string `gorm:"type:varchar(255)"`
+	Name                    string `gorm:"type:varchar(255)"`
+	FinishedDate            *time.Time
+	IsFailed                bool
+	IsRework                bool
+	ReworkType              string `gorm:"type:varchar(100)"`
+	ReworkOfDeploymentId    string `gorm:"type:varchar(255)"`
+	RecoveredDate           *time.Time
+	RecoveredByDeploymentId string `gorm:"type:varchar(255)"`
+	RecoveryTime            *int64
+}
+
+func (projectDeploymentMetric20261017) TableName() string {
+	return "project_deployment_metrics"
+}
+
+type addProjectDeploymentMetrics struct{}
+
+func (*addProjectDeploymentMetrics) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &projectDeploymentMetric20261017{})
+}
+
+func (*addProjectDeploymentMetrics) Version() uint64 {
+	return 20261017170000
+}
+
+func (*addProjectDeploymentMetrics) Name() string {
+	return "add project_deployment_metrics"
+}
@@ -0,0 +1,87 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addProjectServiceMetrics)(nil)
+
+type projectServiceDeployment20261017 struct {
+	archived.DomainEntity
+	ProjectName  string `gorm:"primaryKey;type:varchar(100)"`
+	ServiceName  string `gorm:"primaryKey;type:varchar(100)"`
+	CicdScopeId  string `gorm:"type:varchar(255)"`
+	Name         string `gorm:"type:varchar(255)"`
+	FinishedDate *time.Time
+	IsFailed     bool
+}
+
+func (projectServiceDeployment20261017) TableName() string {
+	return "project_service_deployments"
+}
+
+type projectServicePrMetric20261017 struct {
+	archived.DomainEntity
+	ProjectName        string `gorm:"primaryKey;type:varchar(100)"`
+	ServiceName        string `gorm:"primaryKey;type:varchar(100)"`
+	DeploymentCommitId string `gorm:"type:varchar(255)"`
+	PrCycleTime        *int64
+	PrDeployedDate     *time.Time
+}
+
+func (projectServicePrMetric20261017) TableName() string {
+	return "project_service_pr_metrics"
+}
+
+type projectServiceIncident20261017 struct {
+	archived.DomainEntity
+	ProjectName  string `gorm:"primaryKey;type:varchar(100)"`
+	ServiceName  string `gorm:"primaryKey;type:varchar(100)"`
+	DeploymentId string `gorm:"type:varchar(255)"`
+}
+
+func (projectServiceIncident20261017) TableName() string {
+	return "project_service_incidents"
+}
+
+type addProjectServiceMetrics struct{}
+
+func (*addProjectServiceMetrics) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&projectServiceDeployment20261017{},
+		&projectServicePrMetric20261017{},
+		&projectServiceIncident20261017{},
+	)
+}
+
+func (*addProjectServiceMetrics) Version() uint64 {
+	return 20261017180000
+}
+
+func (*addProjectServiceMetrics) Name() string {
+	return "add project_service_deployments, project_service_pr_metrics and project_service_incidents"
+}
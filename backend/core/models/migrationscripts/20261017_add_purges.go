@@ -0,0 +1,65 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addPurges)(nil)
+
+type purge20261017 struct {
+	archived.Model
+	ProjectName  string `gorm:"type:varchar(255)"`
+	Plugin       string `gorm:"type:varchar(255)"`
+	ConnectionId uint64
+	ScopeId      string `gorm:"type:varchar(255)"`
+	Status       string `gorm:"type:varchar(100)"`
+	Message      string
+	Progress     float32
+	TotalRows    int64
+	DeletedRows  int64
+	Tables       string `gorm:"type:json"`
+	BeganAt      *time.Time
+	FinishedAt   *time.Time
+	SpentSeconds int
+}
+
+func (purge20261017) TableName() string {
+	return "_devlake_purges"
+}
+
+type addPurges struct{}
+
+func (*addPurges) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &purge20261017{})
+}
+
+func (*addPurges) Version() uint64 {
+	return 20261017160000
+}
+
+func (*addPurges) Name() string {
+	return "add _devlake_purges"
+}
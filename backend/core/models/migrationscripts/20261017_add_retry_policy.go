@@ -0,0 +1,76 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addRetryPolicy)(nil)
+
+type retryPolicy20261017 struct {
+	MaxAttempts     int   `json:"maxAttempts"`
+	Backoff         int   `json:"backoff"`
+	RetriableErrors []int `json:"retriableErrors"`
+}
+
+type addRetryPolicyToBlueprint struct {
+	Retry *retryPolicy20261017 `gorm:"type:json;serializer:json"`
+}
+
+func (*addRetryPolicyToBlueprint) TableName() string {
+	return "_devlake_blueprints"
+}
+
+type addRetryPolicyToPipeline struct {
+	Retry *retryPolicy20261017 `gorm:"type:json;serializer:json"`
+}
+
+func (*addRetryPolicyToPipeline) TableName() string {
+	return "_devlake_pipelines"
+}
+
+type addAttemptsToSubtask struct {
+	Attempts int
+}
+
+func (*addAttemptsToSubtask) TableName() string {
+	return "_devlake_subtasks"
+}
+
+type addRetryPolicy struct{}
+
+func (*addRetryPolicy) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&addRetryPolicyToBlueprint{},
+		&addRetryPolicyToPipeline{},
+		&addAttemptsToSubtask{},
+	)
+}
+
+func (*addRetryPolicy) Version() uint64 {
+	return 20261017140000
+}
+
+func (*addRetryPolicy) Name() string {
+	return "add retry policy to _devlake_blueprints and _devlake_pipelines, attempts to _devlake_subtasks"
+}
@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addTimeouts)(nil)
+
+type addTimeoutsToBlueprint struct {
+	Timeout     int
+	TaskTimeout int
+}
+
+func (*addTimeoutsToBlueprint) TableName() string {
+	return "_devlake_blueprints"
+}
+
+type addTimeoutsToPipeline struct {
+	Timeout     int
+	TaskTimeout int
+}
+
+func (*addTimeoutsToPipeline) TableName() string {
+	return "_devlake_pipelines"
+}
+
+type addTimeoutToTask struct {
+	Timeout int
+}
+
+func (*addTimeoutToTask) TableName() string {
+	return "_devlake_tasks"
+}
+
+type addTimeouts struct{}
+
+func (*addTimeouts) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(
+		basicRes,
+		&addTimeoutsToBlueprint{},
+		&addTimeoutsToPipeline{},
+		&addTimeoutToTask{},
+	)
+}
+
+func (*addTimeouts) Version() uint64 {
+	return 20261017130000
+}
+
+func (*addTimeouts) Name() string {
+	return "add timeouts to _devlake_blueprints, _devlake_pipelines and _devlake_tasks"
+}
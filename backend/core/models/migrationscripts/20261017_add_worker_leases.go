@@ -0,0 +1,74 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+var _ plugin.MigrationScript = (*addWorkerLeases)(nil)
+
+type addWorkerLeases struct{}
+
+type worker20261017 struct {
+	ID          string `gorm:"primaryKey;type:varchar(255)"`
+	HostName    string `gorm:"type:varchar(255)"`
+	Version     string `gorm:"type:varchar(255)"`
+	StartedAt   time.Time
+	HeartbeatAt time.Time `gorm:"index"`
+}
+
+func (worker20261017) TableName() string {
+	return "_devlake_workers"
+}
+
+type pipeline20261017 struct {
+	WorkerId       string `gorm:"type:varchar(255);index"`
+	LeaseExpiresAt *time.Time
+}
+
+func (pipeline20261017) TableName() string {
+	return "_devlake_pipelines"
+}
+
+type task20261017 struct {
+	QueuedAt       *time.Time
+	WorkerId       string `gorm:"type:varchar(255);index"`
+	LeaseExpiresAt *time.Time
+}
+
+func (task20261017) TableName() string {
+	return "_devlake_tasks"
+}
+
+func (script *addWorkerLeases) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, new(worker20261017), new(pipeline20261017), new(task20261017))
+}
+
+func (*addWorkerLeases) Version() uint64 {
+	return 20261017110000
+}
+
+func (*addWorkerLeases) Name() string {
+	return "add workers and leases for the cluster mode"
+}
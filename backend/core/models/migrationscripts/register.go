@@ -141,5 +141,21 @@ func All() []plugin.MigrationScript {
 		new(addIssueFixVerion),
 		new(addPipelinePriority),
 		new(fixNullPriority),
+		new(addNotificationSubscriptions),
+		new(addWorkerLeases),
+		new(addBlueprintTriggers),
+		new(addTimeouts),
+		new(addRetryPolicy),
+		new(addRawDataSyncs),
+		new(addPurges),
+		new(addProjectDeploymentMetrics),
+		new(addProjectServiceMetrics),
+		new(addEnvironmentsToProjectPrMetrics),
+		new(addAttributionStrategyToProjectIncidentDeploymentRelationships),
+		new(addClaimedByToNotifications),
+		new(addClusterLocks),
+		new(addBlueprintDebounces),
+		new(addRunBeganAtToPipelines),
+		new(addIncrementalToRawDataSyncs),
 	}
 }
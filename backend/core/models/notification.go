@@ -18,6 +18,8 @@ limitations under the License.
 package models
 
 import (
+	"time"
+
 	"github.com/apache/incubator-devlake/core/models/common"
 )
 
@@ -25,19 +27,81 @@ type NotificationType string
 
 const (
 	NotificationPipelineStatusChanged NotificationType = "PipelineStatusChanged"
+	NotificationPipelineFailed        NotificationType = "PipelineFailed"
+	NotificationPipelinePartial       NotificationType = "PipelinePartial"
+	NotificationPipelineRecovered     NotificationType = "PipelineRecovered"
+	NotificationTaskFailed            NotificationType = "TaskFailed"
+)
+
+const (
+	NOTIFICATION_CHANNEL_WEBHOOK = "WEBHOOK"
+	NOTIFICATION_CHANNEL_SLACK   = "SLACK"
+	NOTIFICATION_CHANNEL_EMAIL   = "EMAIL"
+)
+
+const (
+	NOTIFICATION_PENDING = "PENDING"
+	NOTIFICATION_SENT    = "SENT"
+	NOTIFICATION_FAILED  = "FAILED"
 )
 
 // Notification records notifications sent by lake
 type Notification struct {
 	common.Model
-	Type         NotificationType
-	Endpoint     string
-	Nonce        string
-	ResponseCode int
-	Response     string
-	Data         string
+	Type           NotificationType
+	Endpoint       string
+	Nonce          string
+	ResponseCode   int
+	Response       string
+	Data           string
+	SubscriptionId uint64     `gorm:"index"`
+	Channel        string     `gorm:"type:varchar(20)"`
+	Status         string     `gorm:"type:varchar(20);index"`
+	Attempts       int        `json:"attempts"`
+	NextRetryAt    *time.Time `gorm:"index"`
+	ClaimedBy      string     `gorm:"type:varchar(255)"`
 }
 
 func (Notification) TableName() string {
 	return "_devlake_notifications"
 }
+
+// NotificationSubscription routes the notifications of a project or a blueprint to a channel,
+// empty ProjectName, zero BlueprintId and empty Events match everything
+type NotificationSubscription struct {
+	common.Model
+	Name        string             `json:"name" gorm:"type:varchar(255)" validate:"required"`
+	ProjectName string             `json:"projectName" gorm:"type:varchar(255);index"`
+	BlueprintId uint64             `json:"blueprintId" gorm:"index"`
+	Channel     string             `json:"channel" gorm:"type:varchar(20)" validate:"required,oneof=WEBHOOK SLACK EMAIL"`
+	Endpoint    string             `json:"endpoint" validate:"required"` // webhook url or comma separated email recipients
+	Secret      string             `json:"secret,omitempty" gorm:"serializer:encdec"`
+	Events      []NotificationType `json:"events" gorm:"type:json;serializer:json"`
+	Enable      bool               `json:"enable"`
+}
+
+func (NotificationSubscription) TableName() string {
+	return "_devlake_notification_subscriptions"
+}
+
+// Matches tells whether the subscription is interested in the given notification
+func (s *NotificationSubscription) Matches(notificationType NotificationType, projectName string, blueprintId uint64) bool {
+	if !s.Enable {
+		return false
+	}
+	if s.ProjectName != "" && s.ProjectName != projectName {
+		return false
+	}
+	if s.BlueprintId != 0 && s.BlueprintId != blueprintId {
+		return false
+	}
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, event := range s.Events {
+		if event == notificationType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationSubscription_Matches(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription NotificationSubscription
+		want         bool
+	}{
+		{
+			name:         "disabled",
+			subscription: NotificationSubscription{},
+			want:         false,
+		},
+		{
+			name:         "match all",
+			subscription: NotificationSubscription{Enable: true},
+			want:         true,
+		},
+		{
+			name:         "other project",
+			subscription: NotificationSubscription{Enable: true, ProjectName: "other"},
+			want:         false,
+		},
+		{
+			name:         "other blueprint",
+			subscription: NotificationSubscription{Enable: true, ProjectName: "devlake", BlueprintId: 2},
+			want:         false,
+		},
+		{
+			name:         "filtered event",
+			subscription: NotificationSubscription{Enable: true, Events: []NotificationType{NotificationTaskFailed}},
+			want:         false,
+		},
+		{
+			name:         "subscribed event",
+			subscription: NotificationSubscription{Enable: true, BlueprintId: 1, Events: []NotificationType{NotificationTaskFailed, NotificationPipelineFailed}},
+			want:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.subscription.Matches(NotificationPipelineFailed, "devlake", 1))
+		})
+	}
+}
@@ -27,6 +27,7 @@ type GenericPipelineTask[T any] struct {
 	Plugin   string   `json:"plugin" binding:"required"`
 	Subtasks []string `json:"subtasks"`
 	Options  T        `json:"options"`
+	Timeout  int      `json:"timeout,omitempty"` // seconds, 0 means the taskTimeout of the pipeline
 }
 
 // PipelineTask represents a smallest unit of execution inside a PipelinePlan
@@ -35,7 +36,8 @@ type PipelineTask GenericPipelineTask[map[string]interface{}]
 // PipelineStage consist of multiple PipelineTasks, they will be executed in parallel
 type PipelineStage []*PipelineTask
 
-// PipelinePlan consist of multiple PipelineStages, they will be executed in sequential order
+// PipelinePlan consist of multiple PipelineStages, a task would start as soon as the tasks of
+// the previous stages producing the tables it depends on are finished
 type PipelinePlan []PipelineStage
 
 // IsEmpty checks if a PipelinePlan is empty
@@ -59,6 +61,7 @@ type Pipeline struct {
 	TotalTasks    int          `json:"totalTasks"`
 	FinishedTasks int          `json:"finishedTasks"`
 	BeganAt       *time.Time   `json:"beganAt"`
+	RunBeganAt    *time.Time   `json:"runBeganAt"` // when the current run began, reruns and resumes included
 	FinishedAt    *time.Time   `json:"finishedAt" gorm:"index"`
 	Status        string       `json:"status"`
 	Message       string       `json:"message"`
@@ -68,6 +71,7 @@ type Pipeline struct {
 	Labels        []string     `json:"labels" gorm:"-"`
 	Priority      int          `json:"priority"` // greater is higher
 	SyncPolicy    `gorm:"embedded"`
+	Lease         `gorm:"embedded"`
 }
 
 // We use a 2D array because the request body must be an array of a set of tasks
@@ -85,6 +89,16 @@ func (Pipeline) TableName() string {
 	return "_devlake_pipelines"
 }
 
+// IsTimedOut tells whether the current run of the pipeline has run longer than its timeout by the given time,
+// every rerun or resume of the pipeline gets a fresh time budget
+func (p *Pipeline) IsTimedOut(now time.Time) bool {
+	beganAt := p.RunBeganAt
+	if beganAt == nil {
+		beganAt = p.BeganAt
+	}
+	return p.Timeout > 0 && beganAt != nil && now.Sub(*beganAt) >= time.Duration(p.Timeout)*time.Second
+}
+
 type DbPipelineLabel struct {
 	CreatedAt  time.Time `json:"createdAt"`
 	UpdatedAt  time.Time `json:"updatedAt"`
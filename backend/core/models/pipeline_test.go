@@ -19,6 +19,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -52,3 +53,56 @@ func TestPipelinePlan_IsEmpty(t *testing.T) {
 		})
 	}
 }
+
+func TestPipeline_IsTimedOut(t *testing.T) {
+	beganAt := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	rerunBeganAt := beganAt.Add(24 * time.Hour)
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+		now      time.Time
+		want     bool
+	}{
+		{
+			name:     "no timeout",
+			pipeline: Pipeline{BeganAt: &beganAt},
+			now:      beganAt.Add(24 * time.Hour),
+			want:     false,
+		},
+		{
+			name:     "not started",
+			pipeline: Pipeline{SyncPolicy: SyncPolicy{Timeout: 60}},
+			now:      beganAt.Add(time.Hour),
+			want:     false,
+		},
+		{
+			name:     "within budget",
+			pipeline: Pipeline{BeganAt: &beganAt, SyncPolicy: SyncPolicy{Timeout: 60}},
+			now:      beganAt.Add(59 * time.Second),
+			want:     false,
+		},
+		{
+			name:     "out of budget",
+			pipeline: Pipeline{BeganAt: &beganAt, SyncPolicy: SyncPolicy{Timeout: 60}},
+			now:      beganAt.Add(time.Minute),
+			want:     true,
+		},
+		{
+			name:     "rerun within budget",
+			pipeline: Pipeline{BeganAt: &beganAt, RunBeganAt: &rerunBeganAt, SyncPolicy: SyncPolicy{Timeout: 60}},
+			now:      rerunBeganAt.Add(59 * time.Second),
+			want:     false,
+		},
+		{
+			name:     "rerun out of budget",
+			pipeline: Pipeline{BeganAt: &beganAt, RunBeganAt: &rerunBeganAt, SyncPolicy: SyncPolicy{Timeout: 60}},
+			now:      rerunBeganAt.Add(time.Minute),
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.pipeline.IsTimedOut(tt.now))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+const (
+	PURGE_LAYER_RAW       = "raw"
+	PURGE_LAYER_TOOL      = "tool"
+	PURGE_LAYER_DOMAIN    = "domain"
+	PURGE_LAYER_FRAMEWORK = "framework"
+)
+
+// PurgeTable is the number of rows of a table affected by a purge
+type PurgeTable struct {
+	Table   string `json:"table"`
+	Layer   string `json:"layer"`
+	Rows    int64  `json:"rows"`
+	Deleted int64  `json:"deleted"`
+}
+
+// Purge deletes the collected data of a project, a connection or a scope across the raw, tool and domain
+// layers, rows are deleted in batches and the progress is tracked like a task
+type Purge struct {
+	common.Model
+	ProjectName  string        `json:"projectName" gorm:"type:varchar(255)"`
+	Plugin       string        `json:"plugin" gorm:"type:varchar(255)"`
+	ConnectionId uint64        `json:"connectionId"`
+	ScopeId      string        `json:"scopeId" gorm:"type:varchar(255)"`
+	Status       string        `json:"status" gorm:"type:varchar(100)"`
+	Message      string        `json:"message"`
+	Progress     float32       `json:"progress"`
+	TotalRows    int64         `json:"totalRows"`
+	DeletedRows  int64         `json:"deletedRows"`
+	Tables       []*PurgeTable `json:"tables" gorm:"type:json;serializer:json"`
+	BeganAt      *time.Time    `json:"beganAt"`
+	FinishedAt   *time.Time    `json:"finishedAt"`
+	SpentSeconds int           `json:"spentSeconds"`
+}
+
+func (Purge) TableName() string {
+	return "_devlake_purges"
+}
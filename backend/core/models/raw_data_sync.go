@@ -0,0 +1,37 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+)
+
+// RawDataSync records each collection of a raw table for a specific set of params, it is used
+// to tell the rows of different syncs apart when enforcing the raw data retention policy
+type RawDataSync struct {
+	ID            uint64    `gorm:"primaryKey" json:"id"`
+	RawDataTable  string    `gorm:"type:varchar(255);index:idx_raw_data_syncs_table_params" json:"rawDataTable"`
+	RawDataParams string    `gorm:"type:varchar(255);index:idx_raw_data_syncs_table_params" json:"rawDataParams"`
+	StartedAt     time.Time `json:"startedAt"`
+	// Incremental tells whether the rows collected by earlier syncs were kept
+	Incremental bool `json:"incremental"`
+}
+
+func (RawDataSync) TableName() string {
+	return "_devlake_raw_data_syncs"
+}
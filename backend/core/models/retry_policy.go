@@ -0,0 +1,70 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// defaultRetriableErrors are retried when the RetryPolicy doesn't specify any
+var defaultRetriableErrors = []*errors.Type{
+	errors.Timeout,
+	errors.Unavailable,
+	errors.HttpStatus(502),
+}
+
+// RetryPolicy tells how a failed subtask should be retried in-place before failing the task
+type RetryPolicy struct {
+	// MaxAttempts including the first run, 0 or 1 means no retry
+	MaxAttempts int `json:"maxAttempts"`
+	// Backoff is the seconds to wait before the first retry, doubled for each of the following ones
+	Backoff int `json:"backoff"`
+	// RetriableErrors are http codes identifying the retriable errors.Type, i.e. 504 for errors.Timeout,
+	// defaults to errors.Timeout, errors.Unavailable and 502
+	RetriableErrors []int `json:"retriableErrors"`
+}
+
+// ShouldRetry tells whether the failed attempt is to be followed by another one
+func (p *RetryPolicy) ShouldRetry(attempt int, err errors.Error) bool {
+	if p == nil || err == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	retriable := defaultRetriableErrors
+	if len(p.RetriableErrors) > 0 {
+		retriable = make([]*errors.Type, len(p.RetriableErrors))
+		for i, code := range p.RetriableErrors {
+			retriable[i] = errors.HttpStatus(code)
+		}
+	}
+	for _, t := range retriable {
+		if err.As(t) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBackoff returns how long to wait after the failed attempt
+func (p *RetryPolicy) GetBackoff(attempt int) time.Duration {
+	if p == nil || p.Backoff <= 0 || attempt < 1 {
+		return 0
+	}
+	return time.Duration(p.Backoff) * time.Second << (attempt - 1)
+}
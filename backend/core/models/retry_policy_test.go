@@ -0,0 +1,50 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	var nilPolicy *RetryPolicy
+	assert.False(t, nilPolicy.ShouldRetry(1, errors.Timeout.New("timeout")))
+
+	policy := &RetryPolicy{MaxAttempts: 3}
+	assert.True(t, policy.ShouldRetry(1, errors.Timeout.New("timeout")))
+	assert.True(t, policy.ShouldRetry(2, errors.Default.Wrap(errors.HttpStatus(502).New("bad gateway"), "collect failed")))
+	assert.False(t, policy.ShouldRetry(3, errors.Timeout.New("timeout")))
+	assert.False(t, policy.ShouldRetry(1, errors.BadInput.New("bad input")))
+	assert.False(t, policy.ShouldRetry(1, nil))
+
+	policy = &RetryPolicy{MaxAttempts: 3, RetriableErrors: []int{409}}
+	assert.True(t, policy.ShouldRetry(1, errors.Conflict.New("conflict")))
+	assert.False(t, policy.ShouldRetry(1, errors.Timeout.New("timeout")))
+}
+
+func TestRetryPolicy_GetBackoff(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 4, Backoff: 5}
+	assert.Equal(t, 5*time.Second, policy.GetBackoff(1))
+	assert.Equal(t, 10*time.Second, policy.GetBackoff(2))
+	assert.Equal(t, 20*time.Second, policy.GetBackoff(3))
+	assert.Equal(t, time.Duration(0), (&RetryPolicy{MaxAttempts: 2}).GetBackoff(1))
+}
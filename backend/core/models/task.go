@@ -32,11 +32,12 @@ const (
 	TASK_FAILED    = "TASK_FAILED"
 	TASK_CANCELLED = "TASK_CANCELLED"
 	TASK_PARTIAL   = "TASK_PARTIAL"
+	TASK_TIMEOUT   = "TASK_TIMEOUT"
 )
 
 var (
 	PendingTaskStatus  = []string{TASK_CREATED, TASK_RERUN, TASK_RUNNING}
-	FinishedTaskStatus = []string{TASK_PARTIAL, TASK_CANCELLED, TASK_FAILED, TASK_COMPLETED}
+	FinishedTaskStatus = []string{TASK_PARTIAL, TASK_CANCELLED, TASK_FAILED, TASK_TIMEOUT, TASK_COMPLETED}
 )
 
 type TaskProgressDetail struct {
@@ -64,6 +65,7 @@ type Task struct {
 	Plugin         string                 `json:"plugin" gorm:"index"`
 	Subtasks       []string               `json:"subtasks" gorm:"type:json;serializer:json"`
 	Options        map[string]interface{} `json:"options" gorm:"serializer:encdec"`
+	Timeout        int                    `json:"timeout"` // seconds, overrides the taskTimeout of the pipeline
 	Status         string                 `json:"status"`
 	Message        string                 `json:"message"`
 	ErrorName      string                 `json:"errorName"`
@@ -77,6 +79,8 @@ type Task struct {
 	BeganAt       *time.Time `json:"beganAt"`
 	FinishedAt    *time.Time `json:"finishedAt" gorm:"index"`
 	SpentSeconds  int        `json:"spentSeconds"`
+	QueuedAt      *time.Time `json:"-"` // set when the task is ready to be claimed by workers in the cluster mode
+	Lease         `gorm:"embedded"`
 }
 
 func (Task) TableName() string {
@@ -96,6 +100,7 @@ type Subtask struct {
 	IsCollector     bool       `json:"isCollector"`
 	IsFailed        bool       `json:"isFailed"`
 	Message         string     `json:"message"`
+	Attempts        int        `json:"attempts"`
 }
 
 func (Subtask) TableName() string {
@@ -117,6 +122,7 @@ type SubtaskDetails struct {
 	IsCollector     bool       `json:"isCollector"`
 	IsFailed        bool       `json:"isFailed"`
 	Message         string     `json:"message"`
+	Attempts        int        `json:"attempts"`
 }
 
 type SubtasksInfo struct {
@@ -0,0 +1,60 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import "time"
+
+// Worker is a devlake instance sharing the database with other instances when running in the cluster mode.
+// Unlike the LockingHistory, workers coordinate with each other by claiming pipelines and tasks with leases:
+//
+// 1. A worker claims a row by setting its `WorkerId` and `LeaseExpiresAt` iff the lease is not held by others
+// 2. The worker keeps renewing the lease (heartbeat) while working on it
+// 3. Rows with an expired lease are considered abandoned by a dead worker and may be claimed by others
+type Worker struct {
+	ID          string    `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	HostName    string    `gorm:"type:varchar(255)" json:"hostName"`
+	Version     string    `gorm:"type:varchar(255)" json:"version"`
+	StartedAt   time.Time `json:"startedAt"`
+	HeartbeatAt time.Time `json:"heartbeatAt" gorm:"index"`
+}
+
+func (Worker) TableName() string {
+	return "_devlake_workers"
+}
+
+// Lease records which worker is working on a pipeline or a task and until when
+type Lease struct {
+	WorkerId       string     `json:"workerId" gorm:"type:varchar(255);index"`
+	LeaseExpiresAt *time.Time `json:"leaseExpiresAt"`
+}
+
+// GetLease returns the lease embedded in the pipeline or the task
+func (l *Lease) GetLease() *Lease {
+	return l
+}
+
+// ClusterLock is a named lease letting only one worker do a job at a time in the cluster mode, e.g. the leader
+// scheduling the blueprints and resending the notifications
+type ClusterLock struct {
+	Name string `gorm:"primaryKey;type:varchar(255)" json:"name"`
+	Lease
+}
+
+func (ClusterLock) TableName() string {
+	return "_devlake_cluster_locks"
+}
@@ -0,0 +1,51 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// PluginEventHandler handles the event published by a plugin, e.g. new deployments posted to the webhook plugin
+type PluginEventHandler func(pluginName string, eventName string, connectionId uint64) errors.Error
+
+var (
+	pluginEventHandler      PluginEventHandler
+	pluginEventHandlerMutex sync.RWMutex
+)
+
+// RegisterPluginEventHandler sets the handler of the plugin events, it is called by the server at init so plugins
+// could publish events without depending on it
+func RegisterPluginEventHandler(handler PluginEventHandler) {
+	pluginEventHandlerMutex.Lock()
+	defer pluginEventHandlerMutex.Unlock()
+	pluginEventHandler = handler
+}
+
+// PublishPluginEvent passes the event to the registered handler, the data of the event must have been committed
+func PublishPluginEvent(pluginName string, eventName string, connectionId uint64) errors.Error {
+	pluginEventHandlerMutex.RLock()
+	handler := pluginEventHandler
+	pluginEventHandlerMutex.RUnlock()
+	if handler == nil {
+		return nil
+	}
+	return handler(pluginName, eventName, connectionId)
+}
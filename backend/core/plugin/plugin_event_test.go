@@ -0,0 +1,42 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishPluginEvent(t *testing.T) {
+	defer RegisterPluginEventHandler(nil)
+	assert.Nil(t, PublishPluginEvent("webhook", "deployment", 1))
+
+	var published []string
+	RegisterPluginEventHandler(func(pluginName string, eventName string, connectionId uint64) errors.Error {
+		published = append(published, pluginName+" "+eventName)
+		if connectionId == 0 {
+			return errors.Default.New("no connection")
+		}
+		return nil
+	})
+	assert.Nil(t, PublishPluginEvent("webhook", "deployment", 1))
+	assert.NotNil(t, PublishPluginEvent("webhook", "deployment", 0))
+	assert.Equal(t, []string{"webhook deployment", "webhook deployment"}, published)
+}
@@ -19,6 +19,7 @@ package plugin
 
 import (
 	"context"
+	"time"
 
 	corecontext "github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/errors"
@@ -107,6 +108,10 @@ type SubTaskMeta struct {
 	DependencyTables []string
 	ProductTables    []string
 	ForceRunOnResume bool // Should a subtask be ran dispite it was finished before
+	// Timeout cancels the task if the subtask runs longer than it, 0 means no limit
+	Timeout time.Duration
+	// Retry policy of the subtask, could be overridden by the SyncPolicy of the blueprint
+	Retry *models.RetryPolicy
 }
 
 // PluginTask Implement this interface to let framework run tasks for you
@@ -24,6 +24,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/utils"
@@ -31,6 +32,37 @@ import (
 
 const EncodeKeyEnvStr = "ENCRYPTION_SECRET"
 
+// PreviousEncodeKeysEnvStr holds the comma separated secrets used before the ENCRYPTION_SECRET, they are
+// accepted for decryption during the rotation of the ENCRYPTION_SECRET
+const PreviousEncodeKeysEnvStr = "ENCRYPTION_SECRET_PREVIOUS"
+
+// SplitEncryptionSecrets splits the comma separated secrets, empty ones are dropped
+func SplitEncryptionSecrets(secrets string) []string {
+	var result []string
+	for _, secret := range strings.Split(secrets, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			result = append(result, secret)
+		}
+	}
+	return result
+}
+
+// DecryptWithAny decrypts the text with the first working secret and returns the index of the secret
+func DecryptWithAny(encryptionSecrets []string, encryptedText string) (string, int, errors.Error) {
+	var err errors.Error
+	for i, encryptionSecret := range encryptionSecrets {
+		var plainText string
+		plainText, err = Decrypt(encryptionSecret, encryptedText)
+		if err == nil {
+			return plainText, i, nil
+		}
+	}
+	if err == nil {
+		err = errors.Default.New("encryptionSecret is required")
+	}
+	return "", -1, err
+}
+
 // TODO: maybe move encryption/decryption into helper?
 // AES + Base64 encryption using ENCRYPTION_SECRET in .env as key
 func Encrypt(encryptionSecret, plainText string) (string, errors.Error) {
@@ -70,3 +70,27 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitEncryptionSecrets(t *testing.T) {
+	assert.Empty(t, SplitEncryptionSecrets(""))
+	assert.Equal(t, []string{"a", "b"}, SplitEncryptionSecrets(" a, ,b ,"))
+}
+
+func TestDecryptWithAny(t *testing.T) {
+	oldSecret, _ := RandomEncryptionSecret()
+	newSecret, _ := RandomEncryptionSecret()
+	encrypted, err := Encrypt(oldSecret, "token")
+	assert.Nil(t, err)
+
+	plainText, index, err := DecryptWithAny([]string{newSecret, oldSecret}, encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", plainText)
+	assert.Equal(t, 1, index)
+
+	_, index, err = DecryptWithAny([]string{newSecret}, encrypted)
+	assert.NotNil(t, err)
+	assert.Equal(t, -1, index)
+
+	_, _, err = DecryptWithAny(nil, encrypted)
+	assert.NotNil(t, err)
+}
@@ -49,7 +49,10 @@ func CreateAppBasicRes() context.BasicRes {
 	if err != nil {
 		panic(err)
 	}
-	dalgorm.Init(cfg.GetString(plugin.EncodeKeyEnvStr))
+	dalgorm.Init(
+		cfg.GetString(plugin.EncodeKeyEnvStr),
+		plugin.SplitEncryptionSecrets(cfg.GetString(plugin.PreviousEncodeKeysEnvStr))...,
+	)
 	return CreateBasicRes(cfg, logger, db)
 }
 
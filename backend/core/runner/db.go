@@ -121,6 +121,10 @@ func sanitizeQuery(query url.Values) string {
 }
 
 func MakeDbConnection(dbUrl string, conf *gorm.Config) (*gorm.DB, error) {
+	// the path of a sqlite database might not be a valid url, e.g. `sqlite://:memory:`
+	if dsn, ok := sqliteDsn(dbUrl); ok {
+		return gorm.Open(newSqliteDialector(dsn), conf)
+	}
 	u, err := url.Parse(dbUrl)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,354 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	sqlite3 "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// sqliteDsn converts `sqlite://path/to/file.db?params` to the dsn of the sqlite driver, the database is shared
+// by all connections of the pool, so it is put into the WAL mode with a busy timeout to allow concurrent access,
+// and transactions acquire the write lock from the beginning to avoid deadlocks among writers.
+func sqliteDsn(dbUrl string) (string, bool) {
+	var dsn string
+	for _, prefix := range []string{"sqlite://", "sqlite3://"} {
+		if len(dbUrl) >= len(prefix) && strings.EqualFold(dbUrl[:len(prefix)], prefix) {
+			dsn = dbUrl[len(prefix):]
+			break
+		}
+	}
+	if dsn == "" {
+		return "", false
+	}
+	path, query, _ := strings.Cut(dsn, "?")
+	if path == ":memory:" {
+		// each connection would get its own database otherwise
+		path = "file::memory:"
+		query = "cache=shared&" + query
+	}
+	params, _ := url.ParseQuery(query)
+	pragmas := strings.Join(params["_pragma"], ",")
+	if !strings.Contains(pragmas, "busy_timeout") {
+		params.Add("_pragma", "busy_timeout(10000)")
+	}
+	if !strings.Contains(pragmas, "journal_mode") && !strings.Contains(path, ":memory:") {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+	if params.Get("_txlock") == "" {
+		params.Set("_txlock", "immediate")
+	}
+	return fmt.Sprintf("%s?%s", path, params.Encode()), true
+}
+
+// sqliteDialector creates indexes only if they don't exist like the postgres dialector does, index names are unique
+// across the whole database for both of them, and some of the existing models share the same index names.
+type sqliteDialector struct {
+	sqlite.Dialector
+}
+
+func newSqliteDialector(dsn string) gorm.Dialector {
+	return &sqliteDialector{Dialector: sqlite.Dialector{DriverName: sqliteDriverName, DSN: dsn}}
+}
+
+// Migrator returns the sqliteMigrator
+func (dialector sqliteDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	m := dialector.Dialector.Migrator(db).(sqlite.Migrator)
+	m.Dialector = dialector
+	return sqliteMigrator{Migrator: m}
+}
+
+// DataTypeOf keeps composite primary keys intact. Models embedding `common.Scope` come with an auto-increment
+// `connection_id`, which the sqlite dialector would turn into an inline `PRIMARY KEY AUTOINCREMENT` and drop
+// the rest of the primary key
+func (dialector sqliteDialector) DataTypeOf(field *schema.Field) string {
+	if field.AutoIncrement && field.Schema != nil && len(field.Schema.PrimaryFields) > 1 {
+		return "integer"
+	}
+	return dialector.Dialector.DataTypeOf(field)
+}
+
+type sqliteMigrator struct {
+	sqlite.Migrator
+}
+
+// CreateIndex creates the index if not exists
+func (m sqliteMigrator) CreateIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema != nil {
+			if idx := stmt.Schema.LookIndex(name); idx != nil {
+				opts := m.BuildIndexOptions(idx.Fields, stmt)
+				values := []interface{}{clause.Column{Name: idx.Name}, clause.Table{Name: stmt.Table}, opts}
+				createIndexSQL := "CREATE "
+				if idx.Class != "" {
+					createIndexSQL += idx.Class + " "
+				}
+				createIndexSQL += "INDEX IF NOT EXISTS ? ON ??"
+				if idx.Where != "" {
+					createIndexSQL += " WHERE " + idx.Where
+				}
+				return m.DB.Exec(createIndexSQL, values...).Error
+			}
+		}
+		return fmt.Errorf("failed to create index with name %v", name)
+	})
+}
+
+const sqliteDriverName = "devlake-sqlite"
+
+func init() {
+	db, err := sql.Open(sqlite.DriverName, "")
+	if err != nil {
+		panic(err)
+	}
+	sql.Register(sqliteDriverName, &sqliteDriver{Driver: db.Driver()})
+	_ = db.Close()
+	// functions used by the raw sql of plugins which sqlite doesn't provide
+	sqlite3.MustRegisterScalarFunction("now", 0, func(_ *sqlite3.FunctionContext, _ []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format(sqliteTimeFormat), nil
+	})
+	sqlite3.MustRegisterDeterministicScalarFunction("concat", -1, func(_ *sqlite3.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var b strings.Builder
+		for _, arg := range args {
+			if arg == nil {
+				return nil, nil
+			}
+			if bytes, ok := arg.([]byte); ok {
+				arg = string(bytes)
+			}
+			b.WriteString(fmt.Sprint(arg))
+		}
+		return b.String(), nil
+	})
+}
+
+// sqliteTimeFormat is the format the driver writes time.Time values with
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// sqliteKeywordColumn matches the qualified columns named after a keyword, i.e. `pm.table`, which mysql and
+// postgres accept but sqlite doesn't
+var sqliteKeywordColumn = regexp.MustCompile(`\b(\w+)\.(table)\b`)
+
+// sqliteQuery rewrites the query for sqlite, the trailing semicolon is removed as well, or the driver would try to
+// run the blank statement after it and fail with the `bad parameter or other API misuse`
+func sqliteQuery(query string) string {
+	query = strings.TrimRight(query, "; \t\r\n")
+	return sqliteKeywordColumn.ReplaceAllString(query, "$1.\"$2\"")
+}
+
+// sqliteDriver smooths out the differences between sqlite and mysql/pg for the existing queries and models,
+// i.e. json columns being scanned into the json.RawMessage fields like the `RawData.Input`
+type sqliteDriver struct {
+	driver.Driver
+}
+
+func (d *sqliteDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{Conn: conn}, nil
+}
+
+type sqliteConn struct {
+	driver.Conn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, sqliteQuery(query))
+	} else {
+		stmt, err = c.Conn.Prepare(sqliteQuery(query))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteStmt{Stmt: stmt}, nil
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() // nolint
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, sqliteQuery(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, sqliteQuery(query), args)
+	if err != nil {
+		return nil, err
+	}
+	return newSqliteRows(rows), nil
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+type sqliteStmt struct {
+	driver.Stmt
+}
+
+func (s *sqliteStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (s *sqliteStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return newSqliteRows(rows), nil
+}
+
+// sqliteRows converts the TEXT values of json columns to []byte, and the datetime TEXT values of expressions,
+// i.e. `max(created_date)` or `now()`, to time.Time since sqlite doesn't keep the declared type for them. So do
+// the columns declared with a precision like `DATETIME(3)` which the driver doesn't recognize.
+type sqliteRows struct {
+	driver.Rows
+	jsonColumns []bool
+	timeColumns []bool
+}
+
+func newSqliteRows(rows driver.Rows) *sqliteRows {
+	r := &sqliteRows{Rows: rows}
+	if typed, ok := rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		for i := range rows.Columns() {
+			declType := typed.ColumnTypeDatabaseTypeName(i)
+			typeName, _, hasPrecision := strings.Cut(declType, "(")
+			r.jsonColumns = append(r.jsonColumns, typeName == "JSON" || typeName == "JSONB")
+			r.timeColumns = append(r.timeColumns, declType == "" ||
+				hasPrecision && (typeName == "DATE" || typeName == "DATETIME" || typeName == "TIMESTAMP"))
+		}
+	}
+	return r
+}
+
+func (r *sqliteRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err != nil {
+		return err
+	}
+	for i, isJson := range r.jsonColumns {
+		text, ok := dest[i].(string)
+		if !ok {
+			continue
+		}
+		if isJson {
+			dest[i] = []byte(text)
+		} else if r.timeColumns[i] && len(text) >= len("2006-01-02 15:04:05") {
+			for _, layout := range []string{sqliteTimeFormat, "2006-01-02 15:04:05.999999999"} {
+				if t, err := time.Parse(layout, text); err == nil {
+					dest[i] = t
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *sqliteRows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeScanType returns the scan type by the declared type where possible, the driver derives it from the value
+// of the current row, which doesn't work for NULL values and the datetime columns declared with a precision
+func (r *sqliteRows) ColumnTypeScanType(index int) reflect.Type {
+	typeName, _, _ := strings.Cut(r.ColumnTypeDatabaseTypeName(index), "(")
+	switch typeName {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return reflect.TypeOf(time.Time{})
+	}
+	var scanType reflect.Type
+	if typed, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		scanType = typed.ColumnTypeScanType(index)
+	}
+	switch scanType {
+	case nil:
+		return reflect.TypeOf(new(interface{})).Elem()
+	case reflect.TypeOf([][]byte{}):
+		// the driver reports [][]byte for the blob columns
+		return reflect.TypeOf([]byte{})
+	}
+	return scanType
+}
+
+func (r *sqliteRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return typed.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *sqliteRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return typed.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *sqliteRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if typed, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return typed.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
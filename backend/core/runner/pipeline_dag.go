@@ -0,0 +1,192 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+// taskTables holds the tables a task reads from and writes to, collected from the
+// `DependencyTables` and `ProductTables` of its enabled subtasks
+type taskTables struct {
+	// declared is false when none of the subtasks declares its tables, in which case the
+	// task has to be treated as reading and writing anything
+	declared     bool
+	dependencies map[string]bool
+	products     map[string]bool
+}
+
+// dependsOn tells whether the task owning `t` has to wait for the task owning `upstream`, which is the case when
+// they share any table written by at least one of them: read-after-write, write-after-write or write-after-read
+func (t *taskTables) dependsOn(upstream *taskTables) bool {
+	if !t.declared || !upstream.declared {
+		return true
+	}
+	for table := range upstream.products {
+		if t.dependencies[table] || t.products[table] {
+			return true
+		}
+	}
+	for table := range t.products {
+		if upstream.dependencies[table] {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTaskTables collects tables from the plugin subtask metas, honoring the subtasks specified by the task
+func loadTaskTables(task *models.Task) *taskTables {
+	tables := &taskTables{
+		dependencies: make(map[string]bool),
+		products:     make(map[string]bool),
+	}
+	pluginMeta, err := plugin.GetPlugin(task.Plugin)
+	if err != nil {
+		return tables
+	}
+	pluginTask, ok := pluginMeta.(plugin.PluginTask)
+	if !ok {
+		return tables
+	}
+	specified := make(map[string]bool)
+	for _, name := range task.Subtasks {
+		specified[name] = true
+	}
+	for _, meta := range pluginTask.SubTaskMetas() {
+		if len(specified) > 0 && !specified[meta.Name] && !meta.Required {
+			continue
+		}
+		if len(meta.DependencyTables) == 0 && len(meta.ProductTables) == 0 {
+			// one undeclared subtask is enough to make the whole task unpredictable
+			tables.declared = false
+			return tables
+		}
+		tables.declared = true
+		for _, table := range meta.DependencyTables {
+			tables.dependencies[table] = true
+		}
+		for _, table := range meta.ProductTables {
+			tables.products[table] = true
+		}
+	}
+	return tables
+}
+
+// taskNode is a vertex of the taskGraph
+type taskNode struct {
+	task        *models.Task
+	upstreams   int
+	downstreams []*taskNode
+}
+
+// taskGraph is the DAG of the tasks of a pipeline. A task only depends on tasks of the
+// previous pipeline rows sharing a table with it written by either side, tasks without declared tables
+// depend on all tasks of the previous rows to keep the original row-by-row semantic
+type taskGraph struct {
+	nodes []*taskNode
+}
+
+func buildTaskGraph(tasks []models.Task, loader func(task *models.Task) *taskTables) *taskGraph {
+	graph := &taskGraph{}
+	tables := make([]*taskTables, len(tasks))
+	for i := range tasks {
+		tables[i] = loader(&tasks[i])
+		graph.nodes = append(graph.nodes, &taskNode{task: &tasks[i]})
+	}
+	for i, node := range graph.nodes {
+		for j, upstream := range graph.nodes {
+			if upstream.task.PipelineRow >= node.task.PipelineRow {
+				continue
+			}
+			if tables[i].dependsOn(tables[j]) {
+				node.upstreams++
+				upstream.downstreams = append(upstream.downstreams, node)
+			}
+		}
+	}
+	return graph
+}
+
+type taskResult struct {
+	node *taskNode
+	err  errors.Error
+}
+
+// run starts each task as soon as all its upstream tasks are finished. On failure, no more
+// tasks would be started unless skipOnFail is true, and cancellation always stops the graph.
+func (g *taskGraph) run(
+	skipOnFail bool,
+	onStart func(task *models.Task) errors.Error,
+	runTasks func([]uint64) errors.Error,
+) errors.Error {
+	results := make(chan taskResult)
+	running := 0
+	start := func(node *taskNode) errors.Error {
+		if err := onStart(node.task); err != nil {
+			return err
+		}
+		running++
+		go func() {
+			results <- taskResult{node: node, err: runTasks([]uint64{node.task.ID})}
+		}()
+		return nil
+	}
+	var err errors.Error
+	stopped := false
+	for _, node := range g.nodes {
+		if node.upstreams == 0 {
+			if err = start(node); err != nil {
+				stopped = true
+				break
+			}
+		}
+	}
+	for running > 0 {
+		result := <-results
+		running--
+		if result.err != nil {
+			// keep the cancellation error so the caller could tell the pipeline was cancelled
+			if err == nil || !errors.Is(err, gocontext.Canceled) {
+				err = result.err
+			}
+			if errors.Is(result.err, gocontext.Canceled) || !skipOnFail {
+				stopped = true
+			}
+		}
+		if stopped {
+			continue
+		}
+		for _, downstream := range result.node.downstreams {
+			downstream.upstreams--
+			if downstream.upstreams > 0 {
+				continue
+			}
+			if e := start(downstream); e != nil {
+				err = e
+				stopped = true
+				break
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	"sync"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTables(declared bool, dependencies []string, products []string) *taskTables {
+	tables := &taskTables{
+		declared:     declared,
+		dependencies: make(map[string]bool),
+		products:     make(map[string]bool),
+	}
+	for _, table := range dependencies {
+		tables.dependencies[table] = true
+	}
+	for _, table := range products {
+		tables.products[table] = true
+	}
+	return tables
+}
+
+func newGraphTasks() ([]models.Task, func(task *models.Task) *taskTables) {
+	tasks := []models.Task{
+		{Model: common.Model{ID: 1}, Plugin: "jira", PipelineRow: 1},
+		{Model: common.Model{ID: 2}, Plugin: "github", PipelineRow: 1},
+		{Model: common.Model{ID: 3}, Plugin: "github_post", PipelineRow: 2},
+		{Model: common.Model{ID: 4}, Plugin: "dora", PipelineRow: 3},
+	}
+	tables := map[string]*taskTables{
+		"jira":        newTables(true, nil, []string{"issues"}),
+		"github":      newTables(true, nil, []string{"pull_requests"}),
+		"github_post": newTables(true, []string{"pull_requests"}, []string{"pull_request_commits"}),
+		"dora":        newTables(false, nil, nil),
+	}
+	return tasks, func(task *models.Task) *taskTables {
+		return tables[task.Plugin]
+	}
+}
+
+func TestBuildTaskGraph(t *testing.T) {
+	tasks, loader := newGraphTasks()
+	graph := buildTaskGraph(tasks, loader)
+	upstreams := make(map[uint64]int)
+	for _, node := range graph.nodes {
+		upstreams[node.task.ID] = node.upstreams
+	}
+	// github_post only waits for github, dora waits for everything since it declares nothing
+	assert.Equal(t, map[uint64]int{1: 0, 2: 0, 3: 1, 4: 3}, upstreams)
+}
+
+func TestTaskTablesDependsOn(t *testing.T) {
+	reader := newTables(true, []string{"commits"}, []string{"commit_files"})
+	writer := newTables(true, nil, []string{"commits", "repos"})
+	other := newTables(true, []string{"issues"}, []string{"boards"})
+	// read-after-write
+	assert.True(t, reader.dependsOn(writer))
+	// write-after-read
+	assert.True(t, writer.dependsOn(reader))
+	// write-after-write
+	assert.True(t, writer.dependsOn(newTables(true, nil, []string{"repos"})))
+	// read-after-read and disjoint tables
+	assert.False(t, reader.dependsOn(newTables(true, []string{"commits"}, nil)))
+	assert.False(t, other.dependsOn(writer))
+	assert.False(t, writer.dependsOn(other))
+	// undeclared tables
+	assert.True(t, other.dependsOn(newTables(false, nil, nil)))
+}
+
+func TestTaskGraphRun(t *testing.T) {
+	tasks, loader := newGraphTasks()
+	graph := buildTaskGraph(tasks, loader)
+	jiraDone := make(chan struct{})
+	var mu sync.Mutex
+	finished := make([]uint64, 0)
+	err := graph.run(false, func(task *models.Task) errors.Error {
+		return nil
+	}, func(taskIds []uint64) errors.Error {
+		// the slow jira task must not block github_post
+		if taskIds[0] == 1 {
+			<-jiraDone
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		finished = append(finished, taskIds[0])
+		if taskIds[0] == 3 {
+			close(jiraDone)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []uint64{2, 3, 1, 4}, finished)
+}
+
+func TestTaskGraphRunFailure(t *testing.T) {
+	for _, skipOnFail := range []bool{false, true} {
+		tasks, loader := newGraphTasks()
+		graph := buildTaskGraph(tasks, loader)
+		var mu sync.Mutex
+		started := make(map[uint64]bool)
+		err := graph.run(skipOnFail, func(task *models.Task) errors.Error {
+			return nil
+		}, func(taskIds []uint64) errors.Error {
+			mu.Lock()
+			started[taskIds[0]] = true
+			mu.Unlock()
+			if taskIds[0] == 2 {
+				return errors.Default.New("github failed")
+			}
+			return nil
+		})
+		assert.NotNil(t, err)
+		assert.Equal(t, skipOnFail, started[3])
+		assert.Equal(t, skipOnFail, started[4])
+	}
+}
+
+func TestTaskGraphRunCancelled(t *testing.T) {
+	tasks, loader := newGraphTasks()
+	graph := buildTaskGraph(tasks, loader)
+	var mu sync.Mutex
+	started := make(map[uint64]bool)
+	err := graph.run(true, func(task *models.Task) errors.Error {
+		return nil
+	}, func(taskIds []uint64) errors.Error {
+		mu.Lock()
+		started[taskIds[0]] = true
+		mu.Unlock()
+		if taskIds[0] == 2 {
+			return errors.Convert(gocontext.Canceled)
+		}
+		return nil
+	})
+	assert.True(t, errors.Is(err, gocontext.Canceled))
+	assert.False(t, started[3])
+	assert.False(t, started[4])
+}
@@ -19,6 +19,7 @@ package runner
 
 import (
 	gocontext "context"
+	"fmt"
 	"time"
 
 	"github.com/apache/incubator-devlake/core/context"
@@ -38,26 +39,21 @@ func RunPipeline(
 	var tasks []models.Task
 	err := db.All(
 		&tasks,
-		dal.Where("pipeline_id = ? AND status in ?", pipelineId, []string{models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME}),
+		// running tasks only exist when taking over a pipeline from a dead worker in the cluster mode
+		dal.Where("pipeline_id = ? AND status in ?", pipelineId, []string{models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME, models.TASK_RUNNING}),
 		dal.Orderby("pipeline_row, pipeline_col"),
 	)
 	if err != nil {
 		return err
 	}
-	taskIds := make([][]uint64, 0)
-	for _, task := range tasks {
-		for len(taskIds) < task.PipelineRow {
-			taskIds = append(taskIds, make([]uint64, 0))
-		}
-		taskIds[task.PipelineRow-1] = append(taskIds[task.PipelineRow-1], task.ID)
-	}
-	return runPipelineTasks(basicRes, pipelineId, taskIds, runTasks)
+	graph := buildTaskGraph(tasks, loadTaskTables)
+	return runPipelineTasks(basicRes, pipelineId, graph, runTasks)
 }
 
 func runPipelineTasks(
 	basicRes context.BasicRes,
 	pipelineId uint64,
-	taskIds [][]uint64,
+	graph *taskGraph,
 	runTasks func([]uint64) errors.Error,
 ) errors.Error {
 	db := basicRes.GetDal()
@@ -74,26 +70,32 @@ func runPipelineTasks(
 		return nil
 	}
 
-	// This double for loop executes each set of tasks sequentially while
-	// executing the set of tasks concurrently.
-	for i, row := range taskIds {
-		// update stage
-		err = db.UpdateColumns(dbPipeline, []dal.DalSet{
+	// Each task starts as soon as the tasks producing the tables it depends on are
+	// finished, the stage reflects the furthest pipeline row that has been started.
+	stage := 0
+	err = graph.run(dbPipeline.SkipOnFail, func(task *models.Task) errors.Error {
+		// no more tasks once the pipeline ran out of its time budget
+		if dbPipeline.IsTimedOut(time.Now()) {
+			return errors.Timeout.New(fmt.Sprintf("pipeline #%d timed out after %ds", dbPipeline.ID, dbPipeline.Timeout))
+		}
+		if task.PipelineRow <= stage {
+			return nil
+		}
+		stage = task.PipelineRow
+		e := db.UpdateColumns(dbPipeline, []dal.DalSet{
 			{ColumnName: "status", Value: models.TASK_RUNNING},
-			{ColumnName: "stage", Value: i + 1},
+			{ColumnName: "stage", Value: stage},
 		})
-		if err != nil {
-			log.Error(err, "update pipeline state failed")
-			break
+		if e != nil {
+			log.Error(e, "update pipeline state failed")
 		}
-		// run tasks in parallel
-		err = runTasks(row)
-		if err != nil {
-			log.Error(err, "run tasks failed")
-			if errors.Is(err, gocontext.Canceled) || !dbPipeline.SkipOnFail {
-				log.Info("return error")
-				return err
-			}
+		return e
+	}, runTasks)
+	if err != nil {
+		log.Error(err, "run tasks failed")
+		if errors.Is(err, gocontext.Canceled) || !dbPipeline.SkipOnFail {
+			log.Info("return error")
+			return err
 		}
 	}
 	if dbPipeline.BeganAt != nil {
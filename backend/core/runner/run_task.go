@@ -32,9 +32,12 @@ import (
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/utils"
+	"github.com/apache/incubator-devlake/helpers/metricshelper"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/helpers/tracinghelper"
 	contextimpl "github.com/apache/incubator-devlake/impls/context"
 	"github.com/apache/incubator-devlake/impls/logruslog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // RunTask FIXME ...
@@ -62,6 +65,14 @@ func RunTask(
 	if task.BeganAt != nil {
 		beganAt = *task.BeganAt
 	}
+	ctx, span := tracinghelper.Start(ctx, "task", append(
+		tracinghelper.ScopeAttributes(task.Options),
+		attribute.Int64("task.id", int64(task.ID)),
+		attribute.Int64("pipeline.id", int64(task.PipelineId)),
+		attribute.String("plugin", task.Plugin),
+	)...)
+	// the subtask being run is the parent of the spans reported by clients created with the task context
+	ctx = tracinghelper.WithCurrentSpanHolder(ctx)
 	// make sure task status always correct even if it panicked
 	defer func() {
 		if r := recover(); r != nil {
@@ -87,8 +98,12 @@ func RunTask(
 			} else {
 				lakeErr = errors.Convert(err)
 			}
+			status := models.TASK_FAILED
+			if lakeErr.As(errors.Timeout) != nil {
+				status = models.TASK_TIMEOUT
+			}
 			dbe := db.UpdateColumns(task, []dal.DalSet{
-				{ColumnName: "status", Value: models.TASK_FAILED},
+				{ColumnName: "status", Value: status},
 				{ColumnName: "message", Value: lakeErr.Error()},
 				{ColumnName: "error_name", Value: lakeErr.Messages().Format()},
 				{ColumnName: "finished_at", Value: finishedAt},
@@ -98,6 +113,7 @@ func RunTask(
 			if dbe != nil {
 				logger.Error(dbe, "failed to finalize task status into db (task failed)")
 			}
+			metricshelper.TaskFinished(task.Plugin, status, finishedAt.Sub(beganAt))
 		} else {
 			dbe := db.UpdateColumns(task, []dal.DalSet{
 				{ColumnName: "status", Value: models.TASK_COMPLETED},
@@ -108,6 +124,7 @@ func RunTask(
 			if dbe != nil {
 				logger.Error(dbe, "failed to finalize task status into db (task succeeded)")
 			}
+			metricshelper.TaskFinished(task.Plugin, models.TASK_COMPLETED, finishedAt.Sub(beganAt))
 		}
 		// update finishedTasks
 		errors.Must(db.UpdateColumn(
@@ -115,6 +132,7 @@ func RunTask(
 			"finished_tasks", dal.Expr("finished_tasks + 1"),
 			dal.Where("id=?", task.PipelineId),
 		))
+		tracinghelper.End(span, err)
 		// not return err if the `SkipOnFail` is true and the error is not canceled
 		if dbPipeline.SkipOnFail && !errors.Is(err, gocontext.Canceled) {
 			err = nil
@@ -136,6 +154,21 @@ func RunTask(
 		return dbe
 	}
 
+	// the time budget is enforced by cancelling the task context, the same way as cancelling the pipeline
+	timeout := task.Timeout
+	if timeout == 0 {
+		timeout = dbPipeline.TaskTimeout
+	}
+	ctx, cancel := gocontext.WithCancelCause(ctx)
+	defer cancel(nil)
+	if timeout > 0 {
+		budget := time.Duration(timeout) * time.Second
+		timer := time.AfterFunc(budget, func() {
+			cancel(errors.Timeout.New(fmt.Sprintf("task #%d timed out after %v", task.ID, budget)))
+		})
+		defer timer.Stop()
+	}
+
 	err = RunPluginTask(
 		ctx,
 		basicRes.ReplaceLogger(logger),
@@ -143,9 +176,33 @@ func RunTask(
 		progress,
 		&dbPipeline.SyncPolicy,
 	)
+	if err != nil && err.As(errors.Timeout) == nil {
+		if timeoutErr := timeoutCause(ctx); timeoutErr != nil {
+			err = timeoutErr
+		}
+	}
 	return err
 }
 
+// subtaskStatus maps the result of a subtask run to the status of tasks
+func subtaskStatus(err errors.Error) string {
+	if err == nil {
+		return models.TASK_COMPLETED
+	}
+	if err.As(errors.Timeout) != nil {
+		return models.TASK_TIMEOUT
+	}
+	return models.TASK_FAILED
+}
+
+// timeoutCause returns the error the context was cancelled with if it ran out of its time budget
+func timeoutCause(ctx gocontext.Context) errors.Error {
+	if lakeErr := errors.AsLakeErrorType(gocontext.Cause(ctx)); lakeErr != nil && lakeErr.GetType() == errors.Timeout {
+		return lakeErr
+	}
+	return nil
+}
+
 // RunPluginTask FIXME ...
 func RunPluginTask(
 	ctx gocontext.Context,
@@ -183,53 +240,23 @@ func RunPluginSubTasks(
 ) errors.Error {
 	logger := basicRes.GetLogger()
 	logger.Info("start plugin")
+	// subtasks running out of their time budget cancel the whole task
+	ctx, cancel := gocontext.WithCancelCause(ctx)
+	defer cancel(nil)
 	// find out all possible subtasks this plugin can offer
 	subtaskMetas := pluginTask.SubTaskMetas()
-	subtasksFlag := make(map[string]bool)
-	for _, subtaskMeta := range subtaskMetas {
-		subtasksFlag[subtaskMeta.Name] = subtaskMeta.EnabledByDefault
-	}
-	/* subtasksFlag example
-	subtasksFlag := map[string]bool{
-		"collectProject": true,
-		"convertCommits": true,
-		...
-	}
-	*/
-
 	// user specifies what subtasks to run
+	var specifiedTasks []string
 	if len(task.Subtasks) != 0 {
 		// decode user specified subtasks
-		var specifiedTasks []string
 		err := api.Decode(task.Subtasks, &specifiedTasks, nil)
 		if err != nil {
 			return errors.Default.Wrap(err, "subtasks could not be decoded")
 		}
-		if len(specifiedTasks) > 0 {
-			// first, disable all subtasks
-			for task := range subtasksFlag {
-				subtasksFlag[task] = false
-			}
-			// second, check specified subtasks is valid and enable them if so
-			for _, task := range specifiedTasks {
-				if _, ok := subtasksFlag[task]; ok {
-					subtasksFlag[task] = true
-				} else {
-					return errors.Default.New(fmt.Sprintf("subtask %s does not exist", task))
-				}
-			}
-		}
 	}
-
-	// 1. make sure `Collect` subtasks skip if `SkipCollectors` is true
-	// 2. make sure `Required` subtasks are always enabled
-	for _, subtaskMeta := range subtaskMetas {
-		if syncPolicy != nil && syncPolicy.SkipCollectors && strings.Contains(strings.ToLower(subtaskMeta.Name), "collect") {
-			subtasksFlag[subtaskMeta.Name] = false
-		}
-		if subtaskMeta.Required {
-			subtasksFlag[subtaskMeta.Name] = true
-		}
+	subtasksFlag, err := EnabledSubtasks(subtaskMetas, specifiedTasks, syncPolicy)
+	if err != nil {
+		return err
 	}
 
 	// calculate total step(number of task to run)
@@ -240,6 +267,22 @@ func RunPluginSubTasks(
 		}
 	}
 
+	// api requests of the task could be recorded to or replayed from a cassette
+	cassette, err := openTaskCassette(basicRes, task)
+	if err != nil {
+		return err
+	}
+	if cassette != nil {
+		logger.Info("%s api requests with cassette %s", cassette.Mode(), cassette.Path())
+		ctx = api.WithCassette(ctx, cassette)
+		defer func() {
+			if err := cassette.Save(); err != nil {
+				logger.Error(err, "failed to save cassette %s", cassette.Path())
+			}
+		}()
+	}
+
+	basicRes = tracinghelper.WrapBasicRes(ctx, basicRes)
 	taskCtx := contextimpl.NewDefaultTaskContext(ctx, basicRes, task.Plugin, subtasksFlag, progress)
 	if closeablePlugin, ok := pluginTask.(plugin.CloseablePluginTask); ok {
 		defer closeablePlugin.Close(taskCtx)
@@ -325,17 +368,54 @@ func RunPluginSubTasks(
 		if subtaskFinished {
 			logger.Info("subtask %s already finished previously", subtaskMeta.Name)
 		} else {
-			logger.Info("executing subtask %s", subtaskMeta.Name)
-			start := time.Now()
-			err = runSubtask(basicRes, subtaskCtx, task.ID, subtaskNumber, subtaskMeta.EntryPoint)
-			logger.Info("subtask %s finished in %d ms", subtaskMeta.Name, time.Since(start).Milliseconds())
+			retryPolicy := subtaskMeta.Retry
+			if syncPolicy != nil && syncPolicy.Retry != nil {
+				retryPolicy = syncPolicy.Retry
+			}
+			for attempt := 1; ; attempt++ {
+				logger.Info("executing subtask %s (attempt %d)", subtaskMeta.Name, attempt)
+				start := time.Now()
+				var timer *time.Timer
+				if subtaskMeta.Timeout > 0 {
+					timeoutErr := errors.Timeout.New(fmt.Sprintf("subtask %s timed out after %v", subtaskMeta.Name, subtaskMeta.Timeout))
+					timer = time.AfterFunc(subtaskMeta.Timeout, func() { cancel(timeoutErr) })
+				}
+				spanCtx, span := tracinghelper.Start(ctx, "subtask",
+					attribute.String("subtask", subtaskMeta.Name),
+					attribute.Int("subtask.attempt", attempt),
+				)
+				tracinghelper.SetCurrentSpan(ctx, spanCtx)
+				err = runSubtask(basicRes, subtaskCtx, task.ID, subtaskNumber, attempt, subtaskMeta.EntryPoint)
+				tracinghelper.SetCurrentSpan(ctx, nil)
+				tracinghelper.End(span, err)
+				if timer != nil {
+					timer.Stop()
+				}
+				logger.Info("subtask %s finished in %d ms", subtaskMeta.Name, time.Since(start).Milliseconds())
+				metricshelper.SubtaskFinished(task.Plugin, subtaskMeta.Name, subtaskStatus(err), time.Since(start))
+				// nothing could be retried once the task context is done, e.g. cancelled or timed out
+				if err == nil || ctx.Err() != nil || !retryPolicy.ShouldRetry(attempt, err) {
+					break
+				}
+				backoff := retryPolicy.GetBackoff(attempt)
+				logger.Warn(err, "subtask %s failed on attempt %d, retry in %v", subtaskMeta.Name, attempt, backoff)
+				recordSubtaskAttemptFailure(basicRes, task.ID, subtaskMeta.Name, attempt, err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(backoff):
+				}
+			}
 			if err != nil {
+				if timeoutErr := timeoutCause(ctx); timeoutErr != nil {
+					// the subtask was interrupted by either its own or the task timeout
+					err = timeoutErr
+				}
 				err = errors.SubtaskErr.Wrap(err, fmt.Sprintf("subtask %s ended unexpectedly", subtaskMeta.Name), errors.WithData(&subtaskMeta))
 				logger.Error(err, "")
 				where := dal.Where("task_id = ? and name = ?", task.ID, subtaskCtx.GetName())
 				if err := basicRes.GetDal().UpdateColumns(subtask, []dal.DalSet{
 					{ColumnName: "is_failed", Value: true},
-					{ColumnName: "message", Value: err.Error()},
+					{ColumnName: "message", Value: appendSubtaskMessage(basicRes, task.ID, subtaskMeta.Name, err.Error())},
 				}, where); err != nil {
 					basicRes.GetLogger().Error(err, "error writing subtask %v status to DB", subtaskCtx.GetName())
 				}
@@ -348,6 +428,48 @@ func RunPluginSubTasks(
 	return nil
 }
 
+// EnabledSubtasks tells which subtasks would be run for the user specified subtasks and the sync policy
+func EnabledSubtasks(subtaskMetas []plugin.SubTaskMeta, specifiedTasks []string, syncPolicy *models.SyncPolicy) (map[string]bool, errors.Error) {
+	subtasksFlag := make(map[string]bool)
+	for _, subtaskMeta := range subtaskMetas {
+		subtasksFlag[subtaskMeta.Name] = subtaskMeta.EnabledByDefault
+	}
+	/* subtasksFlag example
+	subtasksFlag := map[string]bool{
+		"collectProject": true,
+		"convertCommits": true,
+		...
+	}
+	*/
+
+	if len(specifiedTasks) > 0 {
+		// first, disable all subtasks
+		for task := range subtasksFlag {
+			subtasksFlag[task] = false
+		}
+		// second, check specified subtasks is valid and enable them if so
+		for _, task := range specifiedTasks {
+			if _, ok := subtasksFlag[task]; ok {
+				subtasksFlag[task] = true
+			} else {
+				return nil, errors.Default.New(fmt.Sprintf("subtask %s does not exist", task))
+			}
+		}
+	}
+
+	// 1. make sure `Collect` subtasks skip if `SkipCollectors` is true
+	// 2. make sure `Required` subtasks are always enabled
+	for _, subtaskMeta := range subtaskMetas {
+		if syncPolicy != nil && syncPolicy.SkipCollectors && strings.Contains(strings.ToLower(subtaskMeta.Name), "collect") {
+			subtasksFlag[subtaskMeta.Name] = false
+		}
+		if subtaskMeta.Required {
+			subtasksFlag[subtaskMeta.Name] = true
+		}
+	}
+	return subtasksFlag, nil
+}
+
 // UpdateProgressDetail FIXME ...
 func UpdateProgressDetail(basicRes context.BasicRes, taskId uint64, progressDetail *models.TaskProgressDetail, p *plugin.RunningProgress) {
 	cfg := basicRes.GetConfigReader()
@@ -404,14 +526,16 @@ func runSubtask(
 	ctx plugin.SubTaskContext,
 	parentID uint64,
 	subtaskNumber int,
+	attempt int,
 	entryPoint plugin.SubTaskEntryPoint,
 ) errors.Error {
 	beginAt := time.Now()
 	subtask := &models.Subtask{
-		Name:    ctx.GetName(),
-		TaskID:  parentID,
-		Number:  subtaskNumber,
-		BeganAt: &beginAt,
+		Name:     ctx.GetName(),
+		TaskID:   parentID,
+		Number:   subtaskNumber,
+		BeganAt:  &beginAt,
+		Attempts: attempt,
 	}
 	recordSubtask(basicRes, subtask)
 	// defer to record subtask status
@@ -433,11 +557,32 @@ func recordSubtask(basicRes context.BasicRes, subtask *models.Subtask) {
 		{ColumnName: "spent_seconds", Value: subtask.SpentSeconds},
 		//{ColumnName: "finished_records", Value: subtask.FinishedRecords}, // FinishedRecords is zero always.
 		{ColumnName: "number", Value: subtask.Number},
+		{ColumnName: "attempts", Value: subtask.Attempts},
 	}, where); err != nil {
 		basicRes.GetLogger().Error(err, "error writing subtask %d status to DB: %v", subtask.ID)
 	}
 }
 
+// recordSubtaskAttemptFailure appends the error of the failed attempt to the message of the subtask before it
+// gets retried, so the errors of all attempts are kept
+func recordSubtaskAttemptFailure(basicRes context.BasicRes, taskId uint64, name string, attempt int, err errors.Error) {
+	where := dal.Where("task_id = ? and name = ?", taskId, name)
+	message := appendSubtaskMessage(basicRes, taskId, name, fmt.Sprintf("attempt %d failed: %s", attempt, err.Error()))
+	if e := basicRes.GetDal().UpdateColumn(&models.Subtask{}, "message", message, where); e != nil {
+		basicRes.GetLogger().Error(e, "error writing subtask %s attempt to DB", name)
+	}
+}
+
+// appendSubtaskMessage returns the message of the subtask followed by the given one
+func appendSubtaskMessage(basicRes context.BasicRes, taskId uint64, name string, message string) string {
+	subtask := &models.Subtask{}
+	err := basicRes.GetDal().First(subtask, dal.Where("task_id = ? and name = ?", taskId, name))
+	if err != nil || subtask.Message == "" {
+		return message
+	}
+	return subtask.Message + "\n" + message
+}
+
 func getTaskLogger(parentLogger log.Logger, task *models.Task) (log.Logger, errors.Error) {
 	logger := parentLogger.Nested(fmt.Sprintf("task #%d", task.ID))
 	loggingPath := logruslog.GetTaskLoggerPath(logger.GetConfig(), task)
@@ -0,0 +1,134 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/plugin"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type retryTestPluginTask struct {
+	subtaskMetas []plugin.SubTaskMeta
+}
+
+func (p *retryTestPluginTask) SubTaskMetas() []plugin.SubTaskMeta {
+	return p.subtaskMetas
+}
+
+func (p *retryTestPluginTask) PrepareTaskData(_ plugin.TaskContext, _ map[string]interface{}) (interface{}, errors.Error) {
+	return nil, nil
+}
+
+// failingEntryPoint fails with a retriable error for the given number of times before succeeding
+func failingEntryPoint(failures int, calls *[]time.Time) plugin.SubTaskEntryPoint {
+	return func(plugin.SubTaskContext) errors.Error {
+		*calls = append(*calls, time.Now())
+		if len(*calls) <= failures {
+			return errors.Unavailable.New("service unavailable")
+		}
+		return nil
+	}
+}
+
+func TestRunPluginSubTasksRetry(t *testing.T) {
+	gormDb := openSqliteTestDb(t)
+	require.Nil(t, gormDb.AutoMigrate(&models.Subtask{}))
+	basicRes := contextimpl.NewDefaultBasicRes(viper.New(), logruslog.Global, dalgorm.NewDalgorm(gormDb))
+	db := basicRes.GetDal()
+	getSubtask := func(taskId uint64) *models.Subtask {
+		subtask := &models.Subtask{}
+		require.Nil(t, db.First(subtask, dal.Where("task_id = ? AND name = ?", taskId, "collectSomething")))
+		return subtask
+	}
+
+	t.Run("succeeded after retries", func(t *testing.T) {
+		var calls []time.Time
+		pluginTask := &retryTestPluginTask{subtaskMetas: []plugin.SubTaskMeta{{
+			Name:             "collectSomething",
+			EntryPoint:       failingEntryPoint(2, &calls),
+			EnabledByDefault: true,
+			Retry:            &models.RetryPolicy{MaxAttempts: 3, Backoff: 1},
+		}}}
+		task := &models.Task{Model: common.Model{ID: 1}, Plugin: "test"}
+		err := RunPluginSubTasks(gocontext.Background(), basicRes, task, pluginTask, nil, nil)
+		assert.Nil(t, err)
+		require.Len(t, calls, 3)
+		// the backoff doubles after each failed attempt
+		assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), time.Second)
+		assert.GreaterOrEqual(t, calls[2].Sub(calls[1]), 2*time.Second)
+
+		subtask := getSubtask(task.ID)
+		assert.Equal(t, 3, subtask.Attempts)
+		assert.False(t, subtask.IsFailed)
+		assert.NotNil(t, subtask.FinishedAt)
+		// errors of all the failed attempts are kept
+		assert.Contains(t, subtask.Message, "attempt 1 failed: service unavailable")
+		assert.Contains(t, subtask.Message, "attempt 2 failed: service unavailable")
+	})
+
+	t.Run("failed after all attempts", func(t *testing.T) {
+		var calls []time.Time
+		pluginTask := &retryTestPluginTask{subtaskMetas: []plugin.SubTaskMeta{{
+			Name:             "collectSomething",
+			EntryPoint:       failingEntryPoint(2, &calls),
+			EnabledByDefault: true,
+			Retry:            &models.RetryPolicy{MaxAttempts: 2, Backoff: 1},
+		}}}
+		task := &models.Task{Model: common.Model{ID: 2}, Plugin: "test"}
+		err := RunPluginSubTasks(gocontext.Background(), basicRes, task, pluginTask, nil, nil)
+		assert.NotNil(t, err)
+		assert.Len(t, calls, 2)
+
+		subtask := getSubtask(task.ID)
+		assert.Equal(t, 2, subtask.Attempts)
+		assert.True(t, subtask.IsFailed)
+		assert.Contains(t, subtask.Message, "attempt 1 failed: service unavailable")
+		assert.Contains(t, subtask.Message, "subtask collectSomething ended unexpectedly")
+	})
+
+	t.Run("not retriable", func(t *testing.T) {
+		var calls []time.Time
+		pluginTask := &retryTestPluginTask{subtaskMetas: []plugin.SubTaskMeta{{
+			Name: "collectSomething",
+			EntryPoint: func(c plugin.SubTaskContext) errors.Error {
+				calls = append(calls, time.Now())
+				return errors.BadInput.New("invalid options")
+			},
+			EnabledByDefault: true,
+			Retry:            &models.RetryPolicy{MaxAttempts: 3, Backoff: 1},
+		}}}
+		task := &models.Task{Model: common.Model{ID: 3}, Plugin: "test"}
+		err := RunPluginSubTasks(gocontext.Background(), basicRes, task, pluginTask, nil, nil)
+		assert.NotNil(t, err)
+		assert.Len(t, calls, 1)
+		assert.Equal(t, 1, getSubtask(task.ID).Attempts)
+	})
+}
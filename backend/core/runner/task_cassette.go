@@ -0,0 +1,52 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// openTaskCassette returns the cassette of the task according to API_CASSETTE_MODE, or nil if it is not set.
+// Cassettes are named after the plugin and the options of the task, so a task could be replayed by
+// another pipeline with the same options.
+func openTaskCassette(basicRes context.BasicRes, task *models.Task) (*api.Cassette, errors.Error) {
+	mode := api.CassetteMode(basicRes.GetConfig("API_CASSETTE_MODE"))
+	if mode == "" {
+		return nil, nil
+	}
+	dir := basicRes.GetConfig("API_CASSETTE_DIR")
+	if dir == "" {
+		dir = "cassettes"
+	}
+	options, err := json.Marshal(task.Options)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to serialize task options")
+	}
+	hash := sha256.Sum256(options)
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", task.Plugin, hex.EncodeToString(hash[:8])))
+	return api.NewCassette(path, mode)
+}
@@ -0,0 +1,121 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainlayerhelper
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+)
+
+// ProjectFilter limits rows of the domain layer tables to the scopes of a project, the scopes are resolved
+// through `project_mapping` along with the repos linked to the boards of the project by `board_repos`
+type ProjectFilter struct {
+	Project  string
+	scopeIds map[string][]string
+	params   []string
+}
+
+// NewProjectFilter resolves the scopes of the project
+func NewProjectFilter(db dal.Dal, project string) (*ProjectFilter, errors.Error) {
+	var mappings []crossdomain.ProjectMapping
+	err := db.All(&mappings, dal.Where("project_name = ?", project))
+	if err != nil {
+		return nil, err
+	}
+	filter := &ProjectFilter{Project: project, scopeIds: make(map[string][]string)}
+	seen := make(map[string]bool)
+	addScope := func(table, id string) {
+		key := table + ":" + id
+		if !seen[key] {
+			seen[key] = true
+			filter.scopeIds[table] = append(filter.scopeIds[table], id)
+		}
+	}
+	for _, mapping := range mappings {
+		addScope(mapping.Table, mapping.RowId)
+	}
+	if boardIds := filter.scopeIds["boards"]; len(boardIds) > 0 {
+		var boardRepos []crossdomain.BoardRepo
+		err = db.All(&boardRepos, dal.Where("board_id IN ?", boardIds))
+		if err != nil {
+			return nil, err
+		}
+		for _, boardRepo := range boardRepos {
+			addScope("repos", boardRepo.RepoId)
+		}
+	}
+	// rows converted from the tool layer carry the `_raw_data_params` of their scopes, while the gitextractor
+	// takes the repo id as the params
+	params := make(map[string]bool)
+	for table, ids := range filter.scopeIds {
+		for _, id := range ids {
+			params[id] = true
+		}
+		if !isDomainTable(table) {
+			continue
+		}
+		var scopeParams []string
+		err = db.Pluck("_raw_data_params", &scopeParams, dal.From(table), dal.Where("id IN ?", ids))
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range scopeParams {
+			if p != "" {
+				params[p] = true
+			}
+		}
+	}
+	for p := range params {
+		filter.params = append(filter.params, p)
+	}
+	sort.Strings(filter.params)
+	return filter, nil
+}
+
+// Clause returns the where clause limiting the rows of the table to the project, by the `project_name` column
+// if there is one, by the ids for the scope tables, or by the `_raw_data_params`. ok is false if the table
+// could not be related to the project
+func (f *ProjectFilter) Clause(table string, columns map[string]bool) (clause dal.Clause, ok bool) {
+	if columns["project_name"] {
+		return dal.Where(fmt.Sprintf("%s.project_name = ?", table), f.Project), true
+	}
+	if ids, isScope := f.scopeIds[table]; isScope && columns["id"] {
+		return dal.Where(fmt.Sprintf("%s.id IN ?", table), ids), true
+	}
+	if !columns["_raw_data_params"] {
+		return dal.Clause{}, false
+	}
+	if len(f.params) == 0 {
+		return dal.Where("1 = 0"), true
+	}
+	return dal.Where(fmt.Sprintf("%s._raw_data_params IN ?", table), f.params), true
+}
+
+func isDomainTable(table string) bool {
+	for _, tabler := range domaininfo.GetDomainTablesInfo() {
+		if tabler.TableName() == table {
+			return true
+		}
+	}
+	return false
+}
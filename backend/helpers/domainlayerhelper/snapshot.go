@@ -0,0 +1,495 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainlayerhelper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"github.com/apache/incubator-devlake/core/utils"
+	"github.com/apache/incubator-devlake/core/version"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper"
+	"github.com/xitongsys/parquet-go/writer"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	SNAPSHOT_FORMAT_PARQUET = "parquet"
+	SNAPSHOT_FORMAT_CSV     = "csv"
+	SNAPSHOT_VERSION        = 1
+	SNAPSHOT_MANIFEST_FILE  = "manifest.json"
+)
+
+// logical column types of the snapshot, they are the same whatever the database is
+const (
+	SNAPSHOT_TYPE_STRING    = "string"
+	SNAPSHOT_TYPE_INT64     = "int64"
+	SNAPSHOT_TYPE_DOUBLE    = "double"
+	SNAPSHOT_TYPE_BOOLEAN   = "boolean"
+	SNAPSHOT_TYPE_TIMESTAMP = "timestamp"
+)
+
+type SnapshotColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type SnapshotTable struct {
+	Name    string           `json:"name"`
+	File    string           `json:"file"`
+	Columns []SnapshotColumn `json:"columns"`
+	Rows    int64            `json:"rows"`
+}
+
+// SnapshotManifest describes the files of a snapshot, the tables are listed in the order of
+// `domaininfo.GetDomainTablesInfo` and their columns in the order of the model fields
+type SnapshotManifest struct {
+	Version        int             `json:"version"`
+	DevlakeVersion string          `json:"devlakeVersion"`
+	Project        string          `json:"project"`
+	Format         string          `json:"format"`
+	ExportedAt     time.Time       `json:"exportedAt"`
+	Tables         []SnapshotTable `json:"tables"`
+	// SkippedTables could not be related to the project thus were not exported
+	SkippedTables []string `json:"skippedTables"`
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// SnapshotFileName turns the project name into a string safe to be used in the name of the snapshot archive
+func SnapshotFileName(project string) string {
+	return unsafeFileNameChars.ReplaceAllString(project, "_")
+}
+
+// ValidateSnapshotFormat returns the format, defaults to parquet
+func ValidateSnapshotFormat(format string) (string, errors.Error) {
+	switch strings.ToLower(format) {
+	case "", SNAPSHOT_FORMAT_PARQUET:
+		return SNAPSHOT_FORMAT_PARQUET, nil
+	case SNAPSHOT_FORMAT_CSV:
+		return SNAPSHOT_FORMAT_CSV, nil
+	}
+	return "", errors.BadInput.New(fmt.Sprintf("unsupported snapshot format %s, must be parquet or csv", format))
+}
+
+// SnapshotColumns derives the columns of the table from its model
+func SnapshotColumns(tabler dal.Tabler) ([]SnapshotColumn, []string, errors.Error) {
+	s, err := schema.Parse(tabler, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return nil, nil, errors.Default.Wrap(err, fmt.Sprintf("failed to parse the model of %s", tabler.TableName()))
+	}
+	var columns []SnapshotColumn
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		columns = append(columns, SnapshotColumn{Name: field.DBName, Type: snapshotType(field.GORMDataType)})
+	}
+	var primaryKeys []string
+	for _, field := range s.PrimaryFields {
+		primaryKeys = append(primaryKeys, field.DBName)
+	}
+	return columns, primaryKeys, nil
+}
+
+func snapshotType(dataType schema.DataType) string {
+	switch dataType {
+	case schema.Int, schema.Uint:
+		return SNAPSHOT_TYPE_INT64
+	case schema.Float:
+		return SNAPSHOT_TYPE_DOUBLE
+	case schema.Bool:
+		return SNAPSHOT_TYPE_BOOLEAN
+	case schema.Time:
+		return SNAPSHOT_TYPE_TIMESTAMP
+	}
+	return SNAPSHOT_TYPE_STRING
+}
+
+// ExportProjectSnapshot exports rows of the domain layer tables belonging to the project into the
+// archive, which is a tar.gz file containing a file per table along with the manifest
+func ExportProjectSnapshot(db dal.Dal, logger log.Logger, project string, format string, archivePath string) (*SnapshotManifest, errors.Error) {
+	format, err := ValidateSnapshotFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	count, err := db.Count(dal.From(&models.Project{}), dal.Where("name = ?", project))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.NotFound.New(fmt.Sprintf("project %s not found", project))
+	}
+	filter, err := NewProjectFilter(db, project)
+	if err != nil {
+		return nil, err
+	}
+	dir, e := os.MkdirTemp("", "devlake-snapshot-")
+	if e != nil {
+		return nil, errors.Convert(e)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest := &SnapshotManifest{
+		Version:        SNAPSHOT_VERSION,
+		DevlakeVersion: version.Version,
+		Project:        project,
+		Format:         format,
+		ExportedAt:     time.Now().UTC(),
+		Tables:         []SnapshotTable{},
+		SkippedTables:  []string{},
+	}
+	for _, tabler := range domaininfo.GetDomainTablesInfo() {
+		table := tabler.TableName()
+		columns, primaryKeys, err := SnapshotColumns(tabler)
+		if err != nil {
+			return nil, err
+		}
+		existing := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			existing[column.Name] = true
+		}
+		clause, ok := filter.Clause(table, existing)
+		if !ok {
+			manifest.SkippedTables = append(manifest.SkippedTables, table)
+			continue
+		}
+		snapshotTable := SnapshotTable{
+			Name:    table,
+			File:    fmt.Sprintf("%s.%s", table, format),
+			Columns: columns,
+		}
+		snapshotTable.Rows, err = exportSnapshotTable(db, clause, primaryKeys, &snapshotTable, filepath.Join(dir, snapshotTable.File))
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to export %s", table))
+		}
+		logger.Debug("exported %d rows of %s", snapshotTable.Rows, table)
+		manifest.Tables = append(manifest.Tables, snapshotTable)
+	}
+	content, e := json.MarshalIndent(manifest, "", "  ")
+	if e != nil {
+		return nil, errors.Convert(e)
+	}
+	if e = os.WriteFile(filepath.Join(dir, SNAPSHOT_MANIFEST_FILE), content, 0600); e != nil {
+		return nil, errors.Convert(e)
+	}
+	if err = utils.CreateGZipArchive(archivePath, dir+"/*"); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func exportSnapshotTable(db dal.Dal, clause dal.Clause, primaryKeys []string, table *SnapshotTable, path string) (int64, errors.Error) {
+	var w snapshotWriter
+	var err errors.Error
+	if strings.HasSuffix(path, SNAPSHOT_FORMAT_CSV) {
+		w, err = newCsvSnapshotWriter(path, table.Columns)
+	} else {
+		w, err = newParquetSnapshotWriter(path, table.Columns)
+	}
+	if err != nil {
+		return 0, err
+	}
+	selects := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		selects[i] = fmt.Sprintf("%s.%s", table.Name, column.Name)
+	}
+	orderBy := make([]string, len(primaryKeys))
+	for i, pk := range primaryKeys {
+		orderBy[i] = fmt.Sprintf("%s.%s", table.Name, pk)
+	}
+	clauses := []dal.Clause{dal.Select(strings.Join(selects, ", ")), dal.From(table.Name), clause}
+	if len(orderBy) > 0 {
+		clauses = append(clauses, dal.Orderby(strings.Join(orderBy, ", ")))
+	}
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		_ = w.Close()
+		return 0, err
+	}
+	defer cursor.Close()
+
+	var rows int64
+	raw := make([]interface{}, len(table.Columns))
+	dest := make([]interface{}, len(table.Columns))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	values := make([]interface{}, len(table.Columns))
+	for cursor.Next() {
+		if e := cursor.Scan(dest...); e != nil {
+			_ = w.Close()
+			return 0, errors.Convert(e)
+		}
+		for i, column := range table.Columns {
+			values[i], err = ConvertSnapshotValue(raw[i], column.Type)
+			if err != nil {
+				_ = w.Close()
+				return 0, errors.Default.Wrap(err, fmt.Sprintf("column %s", column.Name))
+			}
+		}
+		if err = w.Write(values); err != nil {
+			_ = w.Close()
+			return 0, err
+		}
+		rows++
+	}
+	return rows, w.Close()
+}
+
+var snapshotTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// ConvertSnapshotValue converts the value scanned from the database, which varies among the database
+// drivers, into the go type of the logical type, which is one of string, int64, float64, bool and time.Time
+func ConvertSnapshotValue(value interface{}, typ string) (interface{}, errors.Error) {
+	if value == nil {
+		return nil, nil
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	var e error
+	switch typ {
+	case SNAPSHOT_TYPE_INT64:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case uint64:
+			return int64(v), nil
+		case float64:
+			return int64(v), nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			var i int64
+			if i, e = strconv.ParseInt(v, 10, 64); e == nil {
+				return i, nil
+			}
+		}
+	case SNAPSHOT_TYPE_DOUBLE:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		case string:
+			var f float64
+			if f, e = strconv.ParseFloat(v, 64); e == nil {
+				return f, nil
+			}
+		}
+	case SNAPSHOT_TYPE_BOOLEAN:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case int:
+			return v != 0, nil
+		case string:
+			var b bool
+			if b, e = strconv.ParseBool(v); e == nil {
+				return b, nil
+			}
+		}
+	case SNAPSHOT_TYPE_TIMESTAMP:
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC(), nil
+		case string:
+			for _, layout := range snapshotTimeLayouts {
+				var t time.Time
+				if t, e = time.Parse(layout, v); e == nil {
+					return t.UTC(), nil
+				}
+			}
+		}
+	default:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case time.Time:
+			return v.UTC().Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	}
+	if e != nil {
+		return nil, errors.Convert(e)
+	}
+	return nil, errors.Default.New(fmt.Sprintf("unable to convert %v (%T) to %s", value, value, typ))
+}
+
+type snapshotWriter interface {
+	Write(values []interface{}) errors.Error
+	Close() errors.Error
+}
+
+type csvSnapshotWriter struct {
+	writer *pluginhelper.CsvFileWriter
+	record []string
+}
+
+func newCsvSnapshotWriter(path string, columns []SnapshotColumn) (*csvSnapshotWriter, errors.Error) {
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = column.Name
+	}
+	w, err := pluginhelper.NewCsvFileWriter(path, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &csvSnapshotWriter{writer: w, record: make([]string, len(columns))}, nil
+}
+
+// Write outputs NULL as empty string, and timestamps in RFC3339 format
+func (w *csvSnapshotWriter) Write(values []interface{}) errors.Error {
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+			w.record[i] = ""
+		case string:
+			w.record[i] = v
+		case float64:
+			w.record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			w.record[i] = v.Format(time.RFC3339Nano)
+		default:
+			w.record[i] = fmt.Sprint(v)
+		}
+	}
+	w.writer.Write(w.record)
+	return nil
+}
+
+func (w *csvSnapshotWriter) Close() errors.Error {
+	w.writer.Close()
+	return nil
+}
+
+type parquetSnapshotWriter struct {
+	file    *os.File
+	writer  *writer.JSONWriter
+	columns []SnapshotColumn
+	row     map[string]interface{}
+}
+
+func newParquetSnapshotWriter(path string, columns []SnapshotColumn) (*parquetSnapshotWriter, errors.Error) {
+	s, err := ParquetSchema(columns)
+	if err != nil {
+		return nil, err
+	}
+	file, e := os.Create(path)
+	if e != nil {
+		return nil, errors.Convert(e)
+	}
+	w, e := writer.NewJSONWriterFromWriter(s, file, 1)
+	if e != nil {
+		_ = file.Close()
+		return nil, errors.Default.Wrap(e, "failed to create parquet writer")
+	}
+	return &parquetSnapshotWriter{file: file, writer: w, columns: columns, row: make(map[string]interface{}, len(columns))}, nil
+}
+
+// ParquetSchema returns the json schema of the columns for the parquet writer, all columns are optional and
+// timestamps are stored as microseconds since epoch
+func ParquetSchema(columns []SnapshotColumn) (string, errors.Error) {
+	type parquetField struct {
+		Tag string
+	}
+	fields := make([]parquetField, len(columns))
+	for i, column := range columns {
+		var typ string
+		switch column.Type {
+		case SNAPSHOT_TYPE_INT64:
+			typ = "type=INT64"
+		case SNAPSHOT_TYPE_DOUBLE:
+			typ = "type=DOUBLE"
+		case SNAPSHOT_TYPE_BOOLEAN:
+			typ = "type=BOOLEAN"
+		case SNAPSHOT_TYPE_TIMESTAMP:
+			typ = "type=INT64, convertedtype=TIMESTAMP_MICROS"
+		default:
+			typ = "type=BYTE_ARRAY, convertedtype=UTF8"
+		}
+		fields[i].Tag = fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", column.Name, typ)
+	}
+	s, e := json.Marshal(map[string]interface{}{
+		"Tag":    "name=parquet_go_root, repetitiontype=REQUIRED",
+		"Fields": fields,
+	})
+	if e != nil {
+		return "", errors.Convert(e)
+	}
+	return string(s), nil
+}
+
+func (w *parquetSnapshotWriter) Write(values []interface{}) errors.Error {
+	for i, column := range w.columns {
+		if t, ok := values[i].(time.Time); ok {
+			w.row[column.Name] = t.UnixMicro()
+		} else {
+			w.row[column.Name] = values[i]
+		}
+	}
+	row, e := json.Marshal(w.row)
+	if e != nil {
+		return errors.Convert(e)
+	}
+	if e = w.writer.Write(string(row)); e != nil {
+		return errors.Default.Wrap(e, "failed to write parquet row")
+	}
+	return nil
+}
+
+func (w *parquetSnapshotWriter) Close() errors.Error {
+	e := w.writer.WriteStop()
+	if closeErr := w.file.Close(); e == nil {
+		e = closeErr
+	}
+	return errors.Convert(e)
+}
@@ -0,0 +1,142 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainlayerhelper
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper"
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestSnapshotColumns(t *testing.T) {
+	columns, primaryKeys, err := SnapshotColumns(&code.PullRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id"}, primaryKeys)
+	types := make(map[string]string)
+	for _, column := range columns {
+		types[column.Name] = column.Type
+	}
+	assert.Equal(t, SNAPSHOT_TYPE_STRING, types["id"])
+	assert.Equal(t, SNAPSHOT_TYPE_INT64, types["additions"])
+	assert.Equal(t, SNAPSHOT_TYPE_TIMESTAMP, types["created_date"])
+	assert.Equal(t, SNAPSHOT_TYPE_STRING, types["_raw_data_params"])
+	assert.Equal(t, "id", columns[0].Name)
+}
+
+func TestSnapshotFileName(t *testing.T) {
+	assert.Equal(t, "my_project", SnapshotFileName("my project"))
+	assert.Equal(t, "_.._etc_passwd", SnapshotFileName("/../etc/passwd"))
+	assert.Equal(t, "v1.0-rc_1", SnapshotFileName("v1.0-rc_1"))
+}
+
+func TestConvertSnapshotValue(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	cases := []struct {
+		value    interface{}
+		typ      string
+		expected interface{}
+	}{
+		{nil, SNAPSHOT_TYPE_INT64, nil},
+		{[]byte("42"), SNAPSHOT_TYPE_INT64, int64(42)},
+		{int64(42), SNAPSHOT_TYPE_INT64, int64(42)},
+		{[]byte("1.5"), SNAPSHOT_TYPE_DOUBLE, 1.5},
+		{int64(2), SNAPSHOT_TYPE_DOUBLE, 2.0},
+		{int64(1), SNAPSHOT_TYPE_BOOLEAN, true},
+		{[]byte("0"), SNAPSHOT_TYPE_BOOLEAN, false},
+		{ts, SNAPSHOT_TYPE_TIMESTAMP, ts},
+		{[]byte("2024-01-02 03:04:05.123456"), SNAPSHOT_TYPE_TIMESTAMP, ts},
+		{"2024-01-02T03:04:05.123456Z", SNAPSHOT_TYPE_TIMESTAMP, ts},
+		{[]byte("foo"), SNAPSHOT_TYPE_STRING, "foo"},
+		{int64(1), SNAPSHOT_TYPE_STRING, "1"},
+	}
+	for _, c := range cases {
+		actual, err := ConvertSnapshotValue(c.value, c.typ)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, actual, "%v to %s", c.value, c.typ)
+	}
+	_, err := ConvertSnapshotValue("foo", SNAPSHOT_TYPE_INT64)
+	assert.NotNil(t, err)
+}
+
+var testSnapshotColumns = []SnapshotColumn{
+	{Name: "id", Type: SNAPSHOT_TYPE_STRING},
+	{Name: "additions", Type: SNAPSHOT_TYPE_INT64},
+	{Name: "score", Type: SNAPSHOT_TYPE_DOUBLE},
+	{Name: "merged", Type: SNAPSHOT_TYPE_BOOLEAN},
+	{Name: "created_date", Type: SNAPSHOT_TYPE_TIMESTAMP},
+}
+
+var testSnapshotRows = [][]interface{}{
+	{"1", int64(10), 0.5, true, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	{"2", nil, nil, nil, nil},
+}
+
+func TestParquetSnapshotWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pull_requests.parquet")
+	w, err := newParquetSnapshotWriter(path, testSnapshotColumns)
+	assert.Nil(t, err)
+	for _, row := range testSnapshotRows {
+		assert.Nil(t, w.Write(row))
+	}
+	assert.Nil(t, w.Close())
+
+	file, e := local.NewLocalFileReader(path)
+	assert.Nil(t, e)
+	defer file.Close()
+	pr, e := reader.NewParquetReader(file, nil, 1)
+	assert.Nil(t, e)
+	defer pr.ReadStop()
+	assert.Equal(t, int64(2), pr.GetNumRows())
+	rows, e := pr.ReadByNumber(2)
+	assert.Nil(t, e)
+	content, e := json.Marshal(rows)
+	assert.Nil(t, e)
+	assert.JSONEq(t, `[
+		{"Id":"1","Additions":10,"Score":0.5,"Merged":true,"Created_date":1704164645000000},
+		{"Id":"2","Additions":null,"Score":null,"Merged":null,"Created_date":null}
+	]`, string(content))
+}
+
+func TestCsvSnapshotWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pull_requests.csv")
+	w, err := newCsvSnapshotWriter(path, testSnapshotColumns)
+	assert.Nil(t, err)
+	for _, row := range testSnapshotRows {
+		assert.Nil(t, w.Write(row))
+	}
+	assert.Nil(t, w.Close())
+
+	iterator, err := pluginhelper.NewCsvFileIterator(path)
+	assert.Nil(t, err)
+	defer iterator.Close()
+	var records []map[string]interface{}
+	for iterator.HasNext() {
+		records = append(records, iterator.Fetch())
+	}
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "1", "additions": "10", "score": "0.5", "merged": "true", "created_date": "2024-01-02T03:04:05Z"},
+		{"id": "2", "additions": "", "score": "", "merged": "", "created_date": ""},
+	}, records)
+}
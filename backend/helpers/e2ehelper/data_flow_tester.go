@@ -20,7 +20,9 @@ package e2ehelper
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -38,6 +40,8 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/runner"
 	"github.com/apache/incubator-devlake/core/utils"
@@ -107,9 +111,10 @@ func NewDataFlowTester(t *testing.T, pluginName string, pluginMeta plugin.Plugin
 	cfg := config.GetConfig()
 	e2eDbUrl := cfg.GetString(`E2E_DB_URL`)
 	if e2eDbUrl == `` {
-		panic(errors.Default.New(`e2e can only run with E2E_DB_URL, please set it in environment variable or .env file`))
+		// fallback to an in-process sqlite database owned by the current test binary
+		e2eDbUrl = defaultE2eDbUrl()
 	}
-	cfg.Set(`DB_URL`, cfg.GetString(`E2E_DB_URL`))
+	cfg.Set(`DB_URL`, e2eDbUrl)
 	db, err := runner.NewGormDb(cfg, logruslog.Global)
 	if err != nil {
 		// if here fail with error `acces denied for user` you need to create database by your self as follow command
@@ -118,6 +123,15 @@ func NewDataFlowTester(t *testing.T, pluginName string, pluginMeta plugin.Plugin
 		panic(err)
 	}
 	errors.Must(db.AutoMigrate(&models.SubtaskState{}))
+	if db.Dialector.Name() == "sqlite" {
+		// a fresh sqlite database has none of the tables that a shared e2e database would have
+		// accumulated from other plugins, create the domain layer tables once per process
+		domainTablesOnce.Do(func() {
+			for _, table := range domaininfo.GetDomainTablesInfo() {
+				errors.Must(db.AutoMigrate(table))
+			}
+		})
+	}
 	df := &DataFlowTester{
 		Cfg:    cfg,
 		Db:     db,
@@ -130,6 +144,32 @@ func NewDataFlowTester(t *testing.T, pluginName string, pluginMeta plugin.Plugin
 	return df
 }
 
+var (
+	sqliteDbOnce     sync.Once
+	sqliteDbUrl      string
+	domainTablesOnce sync.Once
+)
+
+// defaultE2eDbUrl returns the url of a sqlite database under the temporary directory, one per test package so
+// packages running in parallel would not block each other, and it is kept for inspection after the tests
+func defaultE2eDbUrl() string {
+	sqliteDbOnce.Do(func() {
+		wd, err := os.Getwd()
+		if err != nil {
+			panic(err)
+		}
+		dir := filepath.Join(os.TempDir(), "devlake-e2e")
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			panic(err)
+		}
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(wd))
+		name := fmt.Sprintf("%s-%x.db", filepath.Base(filepath.Dir(wd)), hash.Sum32())
+		sqliteDbUrl = fmt.Sprintf("sqlite://%s", filepath.Join(dir, name))
+	})
+	return sqliteDbUrl
+}
+
 // ImportCsvIntoRawTable imports records from specified csv file into target raw table, note that existing data would be deleted first.
 func (t *DataFlowTester) ImportCsvIntoRawTable(csvRelPath string, rawTableName string) {
 	csvIter, err := pluginhelper.NewCsvFileIterator(csvRelPath)
@@ -157,6 +197,7 @@ func (t *DataFlowTester) importCsv(csvRelPath string, dst schema.Tabler, nullabl
 	}
 	defer csvIter.Close()
 	t.FlushTabler(dst)
+	timeColumns := t.sqliteTimeColumns(dst)
 	// load rows and insert into target table
 	for csvIter.HasNext() {
 		toInsertValues := csvIter.Fetch()
@@ -170,6 +211,9 @@ func (t *DataFlowTester) importCsv(csvRelPath string, dst schema.Tabler, nullabl
 					toInsertValues[i] = nil
 				}
 			}
+			if str, ok := toInsertValues[i].(string); ok && timeColumns[i] {
+				toInsertValues[i] = parseCsvTime(str)
+			}
 		}
 		result := t.Db.Model(dst).Create(toInsertValues)
 		if result.Error != nil {
@@ -179,6 +223,38 @@ func (t *DataFlowTester) importCsv(csvRelPath string, dst schema.Tabler, nullabl
 	}
 }
 
+// sqliteTimeColumns returns the time columns of the tabler when running on sqlite, which stores the csv values
+// as they are instead of parsing them into datetime like mysql and postgres do
+func (t *DataFlowTester) sqliteTimeColumns(dst schema.Tabler) map[string]bool {
+	columns := make(map[string]bool)
+	if t.Db.Dialector.Name() != "sqlite" {
+		return columns
+	}
+	stmt := &gorm.Statement{DB: t.Db}
+	if err := stmt.Parse(dst); err != nil {
+		panic(err)
+	}
+	for _, field := range stmt.Schema.Fields {
+		if field.GORMDataType == schema.Time {
+			columns[field.DBName] = true
+		}
+	}
+	return columns
+}
+
+// parseCsvTime parses the datetime of csv files, which could be loosely formatted like `2022-1-11 4:51:47`
+func parseCsvTime(str string) interface{} {
+	if t, err := common.ConvertStringToTime(str); err == nil {
+		return t
+	}
+	for _, layout := range []string{"2006-1-2 15:04:05", "2006-1-2T15:04:05", "2006-1-2"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t
+		}
+	}
+	return str
+}
+
 // ImportCsvIntoTabler imports records from specified csv file into target tabler, the empty string will be taken as NULL. note that existing data would be deleted first.
 func (t *DataFlowTester) ImportCsvIntoTabler(csvRelPath string, dst schema.Tabler) {
 	t.importCsv(csvRelPath, dst, false)
@@ -235,6 +311,27 @@ func (t *DataFlowTester) SubtaskContext(taskData interface{}) plugin.SubTaskCont
 	return contextimpl.NewStandaloneSubTaskContext(context.Background(), runner.CreateBasicRes(t.Cfg, t.Log, t.Db), t.Name, taskData, t.Name, syncPolicy)
 }
 
+// ReplayApiClient creates an ApiAsyncClient serving the responses recorded in the cassette file, which could be
+// recorded by running the task with `API_CASSETTE_MODE=record`, so collectors could be verified offline
+func (t *DataFlowTester) ReplayApiClient(endpoint string, cassettePath string) *api.ApiAsyncClient {
+	cassette, err := api.NewCassette(cassettePath, api.CASSETTE_REPLAY)
+	if err != nil {
+		panic(err)
+	}
+	ctx := api.WithCassette(context.Background(), cassette)
+	basicRes := runner.CreateBasicRes(t.Cfg, t.Log, t.Db)
+	apiClient, err := api.NewApiClient(ctx, endpoint, nil, 0, "", basicRes)
+	if err != nil {
+		panic(err)
+	}
+	taskCtx := contextimpl.NewDefaultTaskContext(ctx, basicRes, t.Name, nil, nil)
+	asyncClient, err := api.CreateAsyncApiClient(taskCtx, apiClient, nil)
+	if err != nil {
+		panic(err)
+	}
+	return asyncClient
+}
+
 func filterColumn(column dal.ColumnMeta, opts TableOptions) bool {
 	for _, ignore := range opts.IgnoreFields {
 		if column.Name() == ignore {
@@ -448,6 +545,8 @@ func formatDbValue(value interface{}, nullable bool) string {
 	switch value := value.(type) {
 	case time.Time:
 		return value.In(location).Format("2006-01-02T15:04:05.000-07:00")
+	case []byte:
+		return string(value)
 	case bool:
 		if value {
 			return `1`
@@ -578,6 +677,11 @@ func (t *DataFlowTester) VerifyTableWithOptions(dst schema.Tabler, opts TableOpt
 		for _, field := range targetFields {
 			expectation := formatDbValue(expected[field], opts.Nullable)
 			reality := formatDbValue(actual[field], opts.Nullable)
+			if expectation != reality && isJsonDocument(expectation) && isJsonDocument(reality) {
+				// json columns are normalized differently by databases, i.e. sqlite keeps them as they are
+				assert.JSONEq(t.T, expectation, reality, fmt.Sprintf(`%s.%s not match (with params from csv %s)`, dst.TableName(), field, pkValues))
+				continue
+			}
 			if !assert.Equal(t.T, expectation, reality, fmt.Sprintf(`%s.%s not match (with params from csv %s)`, dst.TableName(), field, pkValues)) {
 				_ = t.T // useful for debugging
 			}
@@ -587,6 +691,11 @@ func (t *DataFlowTester) VerifyTableWithOptions(dst schema.Tabler, opts TableOpt
 	assert.Equal(t.T, expectedRowTotal, actualRowTotal, fmt.Sprintf(`records in %s count not match expectation count,[expected(CSV):%d][actual(DB):%d]`, dst.TableName(), expectedRowTotal, actualRowTotal))
 }
 
+func isJsonDocument(value string) bool {
+	value = strings.TrimSpace(value)
+	return (strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[")) && json.Valid([]byte(value))
+}
+
 // DuplicateRecords duplicates all records in the specified table and change the specified fields to the specified values
 func DuplicateRecords[T dal.Tabler](t *DataFlowTester, fields map[string]interface{}) {
 	all := make([]*T, 0)
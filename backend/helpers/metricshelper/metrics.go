@@ -0,0 +1,171 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricshelper
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "devlake"
+
+// durationBuckets covers from seconds to a whole day since pipelines and tasks could run for hours
+var durationBuckets = []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200, 14400, 43200, 86400}
+
+var (
+	pipelinesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelines_total",
+		Help:      "Number of finished pipelines by status",
+	}, []string{"status"})
+	pipelineDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pipeline_duration_seconds",
+		Help:      "Duration of finished pipelines by status",
+		Buckets:   durationBuckets,
+	}, []string{"status"})
+	pipelinesRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pipelines_running",
+		Help:      "Number of pipelines being run by this instance",
+	})
+	pipelineQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pipeline_queue_depth",
+		Help:      "Number of pipelines waiting to be run, as seen by the last dequeue",
+	})
+	tasksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_total",
+		Help:      "Number of finished tasks by plugin and status",
+	}, []string{"plugin", "status"})
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Duration of finished tasks by plugin and status",
+		Buckets:   durationBuckets,
+	}, []string{"plugin", "status"})
+	subtasksTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "subtasks_total",
+		Help:      "Number of subtask runs by plugin, subtask and status",
+	}, []string{"plugin", "subtask", "status"})
+	subtaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "subtask_duration_seconds",
+		Help:      "Duration of subtask runs by plugin, subtask and status",
+		Buckets:   durationBuckets,
+	}, []string{"plugin", "subtask", "status"})
+	apiRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Number of requests sent by the api async clients by plugin, connection and http status code, 0 for network errors",
+	}, []string{"plugin", "connection", "code"})
+	apiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of requests sent by the api async clients by plugin and connection",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"plugin", "connection"})
+	apiRequestRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_request_retries_total",
+		Help:      "Number of requests retried by the api async clients by plugin and connection",
+	}, []string{"plugin", "connection"})
+	schedulerTickInterval = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_scheduler_tick_interval_seconds",
+		Help:      "Tick intervals the worker schedulers were throttled with",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 30, 60},
+	})
+	schedulerInflight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_scheduler_inflight_jobs",
+		Help:      "Number of jobs submitted to the worker schedulers and not finished yet",
+	})
+)
+
+// Handler returns the http handler serving the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// PipelineStarted records a pipeline started running
+func PipelineStarted() {
+	pipelinesRunning.Inc()
+}
+
+// PipelineStopped records a pipeline stopped running, whether its status was saved or not
+func PipelineStopped() {
+	pipelinesRunning.Dec()
+}
+
+// PipelineFinished records a finished pipeline
+func PipelineFinished(status string, spent time.Duration) {
+	pipelinesTotal.WithLabelValues(status).Inc()
+	pipelineDuration.WithLabelValues(status).Observe(spent.Seconds())
+}
+
+// SetPipelineQueueDepth records the number of pipelines waiting to be run
+func SetPipelineQueueDepth(depth int64) {
+	pipelineQueueDepth.Set(float64(depth))
+}
+
+// TaskFinished records a finished task
+func TaskFinished(plugin string, status string, spent time.Duration) {
+	tasksTotal.WithLabelValues(plugin, status).Inc()
+	taskDuration.WithLabelValues(plugin, status).Observe(spent.Seconds())
+}
+
+// SubtaskFinished records a run of subtask, a retried subtask is recorded once per attempt
+func SubtaskFinished(plugin string, subtask string, status string, spent time.Duration) {
+	subtasksTotal.WithLabelValues(plugin, subtask, status).Inc()
+	subtaskDuration.WithLabelValues(plugin, subtask, status).Observe(spent.Seconds())
+}
+
+// ApiRequestFinished records a request sent by the api async client, statusCode is 0 for network errors
+func ApiRequestFinished(plugin string, connectionId uint64, statusCode int, spent time.Duration) {
+	connection := strconv.FormatUint(connectionId, 10)
+	apiRequestsTotal.WithLabelValues(plugin, connection, strconv.Itoa(statusCode)).Inc()
+	apiRequestDuration.WithLabelValues(plugin, connection).Observe(spent.Seconds())
+}
+
+// ApiRequestRetried records a request to be retried by the api async client
+func ApiRequestRetried(plugin string, connectionId uint64) {
+	apiRequestRetries.WithLabelValues(plugin, strconv.FormatUint(connectionId, 10)).Inc()
+}
+
+// SchedulerTickIntervalSet records the tick interval a worker scheduler was (re)set to
+func SchedulerTickIntervalSet(interval time.Duration) {
+	schedulerTickInterval.Observe(interval.Seconds())
+}
+
+// SchedulerJobSubmitted records a job submitted to a worker scheduler
+func SchedulerJobSubmitted() {
+	schedulerInflight.Inc()
+}
+
+// SchedulerJobEnded records a job of a worker scheduler ended, whether it was executed or not
+func SchedulerJobEnded() {
+	schedulerInflight.Dec()
+}
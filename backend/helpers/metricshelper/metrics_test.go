@@ -0,0 +1,47 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricshelper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiRequestFinished(t *testing.T) {
+	ApiRequestFinished("github", 1, 200, 100*time.Millisecond)
+	ApiRequestFinished("github", 1, 200, 200*time.Millisecond)
+	ApiRequestFinished("github", 1, 502, time.Second)
+	ApiRequestRetried("github", 1)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(apiRequestsTotal.WithLabelValues("github", "1", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(apiRequestsTotal.WithLabelValues("github", "1", "502")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(apiRequestRetries.WithLabelValues("github", "1")))
+}
+
+func TestPipelineGauges(t *testing.T) {
+	PipelineStarted()
+	PipelineStarted()
+	PipelineStopped()
+	assert.Equal(t, float64(1), testutil.ToFloat64(pipelinesRunning))
+
+	SetPipelineQueueDepth(5)
+	assert.Equal(t, float64(5), testutil.ToFloat64(pipelineQueueDepth))
+}
@@ -21,7 +21,6 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
-	"net/url"
 	"reflect"
 	"strings"
 
@@ -127,25 +126,9 @@ func ChangePrimaryKeyColumnsType[D any](
 	update func(tmpColumnParams []interface{}) errors.Error,
 ) (err errors.Error) {
 	db := basicRes.GetDal()
-	dbUrl := basicRes.GetConfig("DB_URL")
-	if dbUrl == "" {
-		return errors.BadInput.New("DB_URL is required")
-	}
-	u, errParse := url.Parse(dbUrl)
-	if errParse != nil {
-		return errors.Convert(errParse)
-	}
 	// Delete the primary key
-	if u.Scheme == "mysql" {
-		sql := fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", tableName)
-		if err := db.Exec(sql); err != nil {
-			return err
-		}
-	} else {
-		sql := fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s_pkey", tableName, tableName)
-		if err := db.Exec(sql); err != nil {
-			return err
-		}
+	if err := DropPrimaryKey(db, tableName); err != nil {
+		return err
 	}
 	// Change the type of the primary key
 	err = ChangeColumnsType[D](basicRes, script, tableName, TargetPriColumns, update)
@@ -154,11 +137,80 @@ func ChangePrimaryKeyColumnsType[D any](
 	}
 
 	// Add the primary key
-	sql := fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", tableName, strings.Join(TargetPriColumns, ","))
-	if err := db.Exec(sql); err != nil {
-		return err
+	return AddPrimaryKey(db, tableName, TargetPriColumns...)
+}
+
+// DropPrimaryKey drops the primary key of the table
+func DropPrimaryKey(db dal.Dal, tableName string) errors.Error {
+	switch db.Dialect() {
+	case "mysql":
+		return db.Exec(fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", tableName))
+	case "sqlite":
+		return rebuildSqliteTable(db, tableName, nil)
+	default:
+		return db.Exec(fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s_pkey", tableName, tableName))
 	}
+}
+
+// AddPrimaryKey adds the primary key to the table which has none
+func AddPrimaryKey(db dal.Dal, tableName string, columns ...string) errors.Error {
+	if db.Dialect() == "sqlite" {
+		return rebuildSqliteTable(db, tableName, columns)
+	}
+	return db.Exec(fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", tableName, strings.Join(columns, ",")))
+}
 
+// rebuildSqliteTable recreates the table with the specified primary key since sqlite can not alter constraints
+func rebuildSqliteTable(db dal.Dal, tableName string, primaryKeys []string) errors.Error {
+	var columns []struct {
+		Name      string
+		Type      string
+		Notnull   bool
+		DfltValue *string
+	}
+	err := db.All(&columns, dal.Select("name, type, \"notnull\", dflt_value"), dal.From(dal.DalClause{
+		Expr: "pragma_table_info(?)", Params: []interface{}{tableName},
+	}), dal.Orderby("cid"))
+	if err != nil {
+		return err
+	}
+	var indexes []string
+	err = db.Pluck("sql", &indexes, dal.From("sqlite_master"), dal.Where(
+		"type = 'index' AND tbl_name = ? AND sql IS NOT NULL", tableName,
+	))
+	if err != nil {
+		return err
+	}
+	definitions := make([]string, 0, len(columns)+1)
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		definition := fmt.Sprintf("`%s` %s", column.Name, column.Type)
+		if column.Notnull {
+			definition += " NOT NULL"
+		}
+		if column.DfltValue != nil {
+			definition += " DEFAULT " + *column.DfltValue
+		}
+		definitions = append(definitions, definition)
+		names = append(names, fmt.Sprintf("`%s`", column.Name))
+	}
+	if len(primaryKeys) > 0 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ",")))
+	}
+	tmpTableName := tableName + "_rebuild"
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE %s (%s)", tmpTableName, strings.Join(definitions, ", ")),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", tmpTableName, strings.Join(names, ","), strings.Join(names, ","), tableName),
+		fmt.Sprintf("DROP TABLE %s", tableName),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmpTableName, tableName),
+	}
+	// the indexes were dropped along with the original table
+	statements = append(statements, indexes...)
+	for _, statement := range statements {
+		if err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -30,6 +30,7 @@ import (
 	"github.com/apache/incubator-devlake/core/log"
 	plugin "github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/utils"
+	"github.com/apache/incubator-devlake/helpers/metricshelper"
 )
 
 // HttpMinStatusRetryCode is which status will retry
@@ -44,6 +45,7 @@ type ApiAsyncClient struct {
 	maxRetry     int
 	numOfWorkers int
 	logger       log.Logger
+	pluginName   string
 }
 
 const defaultTimeout = 120 * time.Second
@@ -129,6 +131,7 @@ func CreateAsyncApiClient(
 		retry,
 		numOfWorkers,
 		logger,
+		taskCtx.GetName(),
 	}, nil
 }
 
@@ -161,7 +164,13 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		var respBody []byte
 
 		apiClient.logger.Debug("endpoint: %s  method: %s  header: %s  body: %s query: %s", path, method, header, body, query)
+		start := time.Now()
 		res, err = apiClient.Do(method, path, query, body, header)
+		statusCode := 0
+		if res != nil {
+			statusCode = res.StatusCode
+		}
+		metricshelper.ApiRequestFinished(apiClient.pluginName, apiClient.GetConnectionId(), statusCode, time.Since(start))
 		if err == ErrIgnoreAndContinue {
 			// make sure defer func got be executed
 			err = nil //nolint
@@ -196,6 +205,7 @@ func (apiClient *ApiAsyncClient) DoAsync(
 			// check whether we still have retry times and not error from handler and canceled error
 			if retry < apiClient.maxRetry && err != context.Canceled {
 				apiClient.logger.Warn(err, "retry #%d calling %s", retry, path)
+				metricshelper.ApiRequestRetried(apiClient.pluginName, apiClient.GetConnectionId())
 				retry++
 				apiClient.NextTick(func() errors.Error {
 					apiClient.SubmitBlocking(request)
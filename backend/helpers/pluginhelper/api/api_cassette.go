@@ -0,0 +1,232 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// CassetteMode decides whether the Cassette records the http interactions or replays them
+type CassetteMode string
+
+const (
+	CASSETTE_RECORD CassetteMode = "record"
+	CASSETTE_REPLAY CassetteMode = "replay"
+)
+
+// CassetteInteraction is a recorded request/response pair, headers of the request are never recorded since they
+// carry the credentials
+type CassetteInteraction struct {
+	Method         string      `json:"method"`
+	Path           string      `json:"path"`
+	Query          string      `json:"query"`
+	RequestBody    string      `json:"requestBody,omitempty"`
+	StatusCode     int         `json:"statusCode"`
+	ResponseHeader http.Header `json:"responseHeader"`
+	ResponseBody   string      `json:"responseBody"`
+}
+
+func (i *CassetteInteraction) key() string {
+	return fmt.Sprintf("%s %s?%s\n%s", i.Method, i.Path, i.Query, i.RequestBody)
+}
+
+// Cassette records the http interactions of a task into a file, and serves the responses from the file in the
+// replay mode, so the collectors could be re-executed offline. Requests are matched on method, path and query,
+// plus the body for requests carrying one (i.e. graphql), the recorded responses of the same request are served
+// in their original order, and the last one is repeated once they run out.
+type Cassette struct {
+	mode         CassetteMode
+	path         string
+	mutex        sync.Mutex
+	interactions []*CassetteInteraction
+	replaying    map[string][]*CassetteInteraction
+}
+
+// NewCassette creates a Cassette for the given file, which is loaded right away in the replay mode
+func NewCassette(path string, mode CassetteMode) (*Cassette, errors.Error) {
+	cassette := &Cassette{mode: mode, path: path}
+	switch mode {
+	case CASSETTE_RECORD:
+		return cassette, nil
+	case CASSETTE_REPLAY:
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to read cassette %s", path))
+		}
+		if err = json.Unmarshal(content, &cassette.interactions); err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to parse cassette %s", path))
+		}
+		cassette.replaying = make(map[string][]*CassetteInteraction)
+		for _, interaction := range cassette.interactions {
+			key := interaction.key()
+			cassette.replaying[key] = append(cassette.replaying[key], interaction)
+		}
+		return cassette, nil
+	}
+	return nil, errors.BadInput.New(fmt.Sprintf("unknown cassette mode %s", mode))
+}
+
+// Mode returns the mode of the Cassette
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// Path returns the file path of the Cassette
+func (c *Cassette) Path() string {
+	return c.path
+}
+
+// Interactions returns the recorded interactions
+func (c *Cassette) Interactions() []*CassetteInteraction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]*CassetteInteraction(nil), c.interactions...)
+}
+
+// Save writes the recorded interactions to the file, it does nothing in the replay mode
+func (c *Cassette) Save() errors.Error {
+	if c.mode != CASSETTE_RECORD {
+		return nil
+	}
+	c.mutex.Lock()
+	content, err := json.MarshalIndent(c.interactions, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to serialize cassette")
+	}
+	if err = os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to create directory for cassette %s", c.path))
+	}
+	if err = os.WriteFile(c.path, content, 0o600); err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to write cassette %s", c.path))
+	}
+	return nil
+}
+
+// RoundTripper wraps the given http.RoundTripper to record or replay the interactions, http.DefaultTransport
+// is used if next is nil
+func (c *Cassette) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cassetteTransport{cassette: c, next: next}
+}
+
+// WrapHttpClient returns a copy of the http.Client with its transport wrapped by the Cassette
+func (c *Cassette) WrapHttpClient(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	wrapped := *client
+	wrapped.Transport = c.RoundTripper(client.Transport)
+	return &wrapped
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	next     http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	interaction := &CassetteInteraction{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		interaction.RequestBody = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if t.cassette.mode == CASSETTE_REPLAY {
+		return t.cassette.replay(req, interaction)
+	}
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	interaction.StatusCode = res.StatusCode
+	interaction.ResponseHeader = res.Header.Clone()
+	interaction.ResponseHeader.Del("Set-Cookie")
+	interaction.ResponseBody = string(body)
+	t.cassette.mutex.Lock()
+	t.cassette.interactions = append(t.cassette.interactions, interaction)
+	t.cassette.mutex.Unlock()
+	return res, nil
+}
+
+func (c *Cassette) replay(req *http.Request, interaction *CassetteInteraction) (*http.Response, error) {
+	key := interaction.key()
+	c.mutex.Lock()
+	recorded := c.replaying[key]
+	if len(recorded) > 1 {
+		c.replaying[key] = recorded[1:]
+	}
+	c.mutex.Unlock()
+	if len(recorded) == 0 {
+		return nil, errors.NotFound.New(fmt.Sprintf("no interaction recorded in cassette %s for %s %s", c.path, req.Method, req.URL.RequestURI()))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded[0].StatusCode, http.StatusText(recorded[0].StatusCode)),
+		StatusCode:    recorded[0].StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded[0].ResponseHeader.Clone(),
+		Body:          io.NopCloser(bytes.NewBufferString(recorded[0].ResponseBody)),
+		ContentLength: int64(len(recorded[0].ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+type cassetteCtxKey struct{}
+
+// WithCassette returns a copy of ctx carrying the Cassette, ApiClients created with it record or replay their
+// interactions with the Cassette
+func WithCassette(ctx gocontext.Context, cassette *Cassette) gocontext.Context {
+	return gocontext.WithValue(ctx, cassetteCtxKey{}, cassette)
+}
+
+// CassetteFromContext returns the Cassette carried by ctx, nil if there isn't one
+func CassetteFromContext(ctx gocontext.Context) *Cassette {
+	if ctx == nil {
+		return nil
+	}
+	cassette, _ := ctx.Value(cassetteCtxKey{}).(*Cassette)
+	return cassette
+}
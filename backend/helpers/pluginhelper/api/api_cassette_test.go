@@ -0,0 +1,106 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cassetteTestRequests(t *testing.T, apiClient *ApiClient) []string {
+	var bodies []string
+	do := func(method, path string, query url.Values, body interface{}) {
+		res, err := apiClient.Do(method, path, query, body, nil)
+		assert.Nil(t, err)
+		content, e := io.ReadAll(res.Body)
+		assert.Nil(t, e)
+		_ = res.Body.Close()
+		bodies = append(bodies, fmt.Sprintf("%d %s %s", res.StatusCode, res.Header.Get("X-Total"), content))
+	}
+	do(http.MethodGet, "items", url.Values{"page": {"1"}, "per_page": {"2"}}, nil)
+	do(http.MethodGet, "items", url.Values{"per_page": {"2"}, "page": {"2"}}, nil)
+	do(http.MethodGet, "rate_limit", nil, nil)
+	do(http.MethodGet, "rate_limit", nil, nil)
+	do(http.MethodPost, "graphql", nil, map[string]interface{}{"query": "a"})
+	do(http.MethodPost, "graphql", nil, map[string]interface{}{"query": "b"})
+	return bodies
+}
+
+func TestCassette(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		w.Header().Set("X-Total", "4")
+		w.Header().Set("Set-Cookie", "session=1")
+		if r.URL.Path == "/graphql" {
+			_, _ = fmt.Fprintf(w, `{"data":%s}`, body)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"path":%q,"query":%q,"call":%d}`, r.URL.Path, r.URL.RawQuery, n)
+	}))
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	// record
+	recorder, err := NewCassette(path, CASSETTE_RECORD)
+	assert.Nil(t, err)
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, map[string]string{"Authorization": "secret"}, 0)
+	apiClient.SetCassette(recorder)
+	recorded := cassetteTestRequests(t, apiClient)
+	assert.Nil(t, recorder.Save())
+	assert.Len(t, recorder.Interactions(), 6)
+	assert.Empty(t, recorder.Interactions()[0].ResponseHeader.Get("Set-Cookie"))
+	server.Close()
+
+	// replay without the server
+	player, err := NewCassette(path, CASSETTE_REPLAY)
+	assert.Nil(t, err)
+	apiClient = &ApiClient{}
+	apiClient.Setup(server.URL, nil, 0)
+	apiClient.SetCassette(player)
+	replayed := cassetteTestRequests(t, apiClient)
+	assert.Equal(t, recorded, replayed)
+	assert.Equal(t, int32(6), calls)
+
+	// the last response is repeated once the recorded ones run out
+	res, err := apiClient.Get("rate_limit", nil, nil)
+	assert.Nil(t, err)
+	content, _ := io.ReadAll(res.Body)
+	assert.Equal(t, `{"path":"/rate_limit","query":"","call":4}`, string(content))
+
+	// requests which were not recorded
+	_, err = apiClient.Get("items", url.Values{"page": {"3"}}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestCassetteReplayMissingFile(t *testing.T) {
+	_, err := NewCassette(filepath.Join(t.TempDir(), "missing.json"), CASSETTE_REPLAY)
+	assert.NotNil(t, err)
+	_, err = NewCassette("test.json", CassetteMode("rewind"))
+	assert.NotNil(t, err)
+}
@@ -41,6 +41,8 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/utils"
+	"github.com/apache/incubator-devlake/helpers/tracinghelper"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ErrIgnoreAndContinue is a error which should be ignored
@@ -67,6 +69,7 @@ type ApiClient struct {
 	afterResponse plugin.ApiClientAfterResponse
 	ctx           gocontext.Context
 	logger        log.Logger
+	connectionId  uint64
 }
 
 // NewApiClientFromConnection creates ApiClient based on given connection.
@@ -82,6 +85,9 @@ func NewApiClientFromConnection(
 	if err != nil {
 		return nil, err
 	}
+	if c, ok := connection.(interface{ ConnectionId() uint64 }); ok {
+		apiClient.connectionId = c.ConnectionId()
+	}
 
 	// if connection needs to prepare the ApiClient, i.e. fetch token for future requests
 	if prepareApiClient, ok := connection.(plugin.PrepareApiClient); ok {
@@ -137,20 +143,25 @@ func NewApiClient(
 		apiClient.client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
+	// there is nothing to connect to when responses are replayed from the cassette
+	cassette := CassetteFromContext(ctx)
+	checkConnectivity := cassette == nil || cassette.Mode() != CASSETTE_REPLAY
 	if proxy != "" {
 		err := apiClient.SetProxy(proxy)
 		if err != nil {
 			return nil, errors.Convert(err)
 		}
-		// check connectivity
-		res, err := apiClient.Get("/", nil, nil)
-		if err != nil {
-			return nil, err
-		}
-		if res.StatusCode == http.StatusBadGateway {
-			return nil, errors.BadInput.New(fmt.Sprintf("fail to connect to %v via %v", endpoint, proxy))
+		if checkConnectivity {
+			// check connectivity
+			res, err := apiClient.Get("/", nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if res.StatusCode == http.StatusBadGateway {
+				return nil, errors.BadInput.New(fmt.Sprintf("fail to connect to %v via %v", endpoint, proxy))
+			}
 		}
-	} else {
+	} else if checkConnectivity {
 		// check connectivity
 		parsedUrl, err := url.Parse(endpoint)
 		if err != nil {
@@ -181,6 +192,9 @@ func NewApiClient(
 			return ErrRedirectionNotAllowed
 		}
 	}
+	if cassette != nil {
+		apiClient.SetCassette(cassette)
+	}
 
 	return apiClient, nil
 }
@@ -208,6 +222,11 @@ func (apiClient *ApiClient) GetEndpoint() string {
 	return apiClient.endpoint
 }
 
+// GetConnectionId returns the id of the connection the ApiClient was created from, 0 if unknown
+func (apiClient *ApiClient) GetConnectionId() uint64 {
+	return apiClient.connectionId
+}
+
 // SetTimeout FIXME ...
 func (apiClient *ApiClient) SetTimeout(timeout time.Duration) {
 	apiClient.client.Timeout = timeout
@@ -294,6 +313,11 @@ func (apiClient *ApiClient) SetProxy(proxyUrl string) errors.Error {
 	return nil
 }
 
+// SetCassette makes the ApiClient record its interactions to the Cassette, or replay them from it
+func (apiClient *ApiClient) SetCassette(cassette *Cassette) {
+	apiClient.client.Transport = cassette.RoundTripper(apiClient.client.Transport)
+}
+
 // SetLogger FIXME ...
 func (apiClient *ApiClient) SetLogger(logger log.Logger) {
 	apiClient.logger = logger
@@ -318,6 +342,32 @@ func (apiClient *ApiClient) Do(
 	query url.Values,
 	body interface{},
 	headers http.Header,
+) (*http.Response, errors.Error) {
+	ctx, span := tracinghelper.Start(apiClient.ctx, "HTTP "+method,
+		attribute.String("http.method", method),
+		attribute.String("http.endpoint", apiClient.endpoint),
+		attribute.String("http.path", path),
+		attribute.Int64("connection.id", int64(apiClient.connectionId)),
+	)
+	res, err := apiClient.do(ctx, method, path, query, body, headers)
+	if res != nil {
+		span.SetAttributes(attribute.Int("http.status_code", res.StatusCode))
+	}
+	if err == ErrIgnoreAndContinue {
+		tracinghelper.End(span, nil)
+	} else {
+		tracinghelper.End(span, err)
+	}
+	return res, err
+}
+
+func (apiClient *ApiClient) do(
+	ctx gocontext.Context,
+	method string,
+	path string,
+	query url.Values,
+	body interface{},
+	headers http.Header,
 ) (*http.Response, errors.Error) {
 	uri, err := GetURIStringPointer(apiClient.endpoint, path, query)
 	if err != nil {
@@ -332,12 +382,7 @@ func (apiClient *ApiClient) Do(
 		}
 		reqBody = bytes.NewBuffer(reqJson)
 	}
-	var req *http.Request
-	if apiClient.ctx != nil {
-		req, err = errors.Convert01(http.NewRequestWithContext(apiClient.ctx, method, *uri, reqBody))
-	} else {
-		req, err = errors.Convert01(http.NewRequest(method, *uri, reqBody))
-	}
+	req, err := errors.Convert01(http.NewRequestWithContext(ctx, method, *uri, reqBody))
 	if err != nil {
 		return nil, errors.Default.Wrap(err, fmt.Sprintf("unable to create API request for %s", *uri))
 	}
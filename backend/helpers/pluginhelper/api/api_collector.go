@@ -174,6 +174,10 @@ func (collector *ApiCollector) Execute() errors.Error {
 			return errors.Default.Wrap(err, "error deleting data from collector")
 		}
 	}
+	err = collector.recordSync(isIncremental)
+	if err != nil {
+		return errors.Default.Wrap(err, "error recording raw data sync")
+	}
 
 	// if MinTickInterval was specified
 	if collector.args.MinTickInterval != nil {
@@ -474,12 +478,11 @@ func (collector *ApiCollector) fetchAsync(reqData *RequestData, handler func(int
 		urlString := res.Request.URL.String()
 		rows := make([]*RawData, count)
 		for i, msg := range items {
-			rows[i] = &RawData{
-				Params: collector.params,
-				Data:   msg,
-				Url:    urlString,
-				Input:  reqData.InputJSON,
+			row, err := collector.newRawData(msg, urlString, reqData.InputJSON)
+			if err != nil {
+				return err
 			}
+			rows[i] = row
 		}
 		err = db.Create(rows, dal.From(collector.table))
 		if err != nil {
@@ -36,6 +36,7 @@ type StatefulApiCollector struct {
 	// *ApiCollector
 	// *GraphqlCollector
 	nestedCollectors []plugin.SubTask
+	rawDataSubTask   *RawDataSubTask
 }
 
 // NewStatefulApiCollector create a new StatefulApiCollector
@@ -52,6 +53,7 @@ func NewStatefulApiCollector(args RawDataSubTaskArgs) (*StatefulApiCollector, er
 	return &StatefulApiCollector{
 		RawDataSubTaskArgs:    args,
 		CollectorStateManager: *stateManager,
+		rawDataSubTask:        rawDataSubTask,
 	}, nil
 }
 
@@ -63,6 +65,7 @@ func (m *StatefulApiCollector) InitCollector(args ApiCollectorArgs) errors.Error
 	if err != nil {
 		return err
 	}
+	apiCollector.nested = true
 	m.nestedCollectors = append(m.nestedCollectors, apiCollector)
 	return nil
 }
@@ -87,12 +90,18 @@ func (m *StatefulApiCollector) InitGraphQLCollector(args GraphqlCollectorArgs) e
 	if err != nil {
 		return err
 	}
+	graphqlCollector.nested = true
 	m.nestedCollectors = append(m.nestedCollectors, graphqlCollector)
 	return nil
 }
 
 // Execute all nested collectors and save the state if all collectors succeed
 func (m *StatefulApiCollector) Execute() errors.Error {
+	// nested collectors share the same raw table and params, they make up a single sync
+	err := m.rawDataSubTask.recordSync(m.CollectorStateManager.IsIncremental())
+	if err != nil {
+		return errors.Default.Wrap(err, "error recording raw data sync")
+	}
 	for _, subtask := range m.nestedCollectors {
 		err := subtask.Execute()
 		if err != nil {
@@ -46,7 +46,8 @@ func TestFetchPageUndetermined(t *testing.T) {
 	mockDal := new(mockdal.Dal)
 	mockDal.On("AutoMigrate", mock.Anything, mock.Anything).Return(nil).Once()
 	mockDal.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
-	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+	// one for the raw data sync, the other for the raw rows
+	mockDal.On("Create", mock.Anything, mock.Anything).Return(nil).Twice()
 
 	mockCtx := unithelper.DummySubTaskContext(mockDal)
 
@@ -111,6 +111,10 @@ func (extractor *ApiExtractor) Execute() errors.Error {
 		if err != nil {
 			return errors.Default.Wrap(err, "error fetching row")
 		}
+		row.Data, err = DecompressRawData(row.Data)
+		if err != nil {
+			return err
+		}
 
 		results, err := extractor.args.Extract(row)
 		if err != nil {
@@ -154,6 +154,10 @@ func (extractor *StatefulApiExtractor[InputType]) Execute() errors.Error {
 		if err != nil {
 			return errors.Default.Wrap(err, "error loading full row by ID")
 		}
+		row.Data, err = DecompressRawData(row.Data)
+		if err != nil {
+			return err
+		}
 
 		body := new(InputType)
 		err = errors.Convert(json.Unmarshal(row.Data, body))
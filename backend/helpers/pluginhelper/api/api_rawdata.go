@@ -18,15 +18,25 @@ limitations under the License.
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 	"time"
 
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
 	plugin "github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/utils"
 )
 
+// RawDataCompressionEnv enables the gzip compression of `RawData.Data` for newly collected rows
+const RawDataCompressionEnv = "RAW_DATA_COMPRESSION"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // RawData is raw data structure in DB storage
 type RawData struct {
 	ID        uint64 `gorm:"primaryKey"`
@@ -58,9 +68,12 @@ type RawDataSubTaskArgs struct {
 
 // RawDataSubTask is Common features for raw data sub-tasks
 type RawDataSubTask struct {
-	args   *RawDataSubTaskArgs
-	table  string
-	params string
+	args     *RawDataSubTaskArgs
+	table    string
+	params   string
+	compress bool
+	// nested is true when the subtask is run by a StatefulApiCollector, which records the sync by itself
+	nested bool
 }
 
 // NewRawDataSubTask constructor for RawDataSubTask
@@ -83,10 +96,15 @@ func NewRawDataSubTask(args RawDataSubTaskArgs) (*RawDataSubTask, errors.Error)
 	} else {
 		paramsString = plugin.MarshalScopeParams(params)
 	}
+	compress, err := utils.StrToBoolOr(args.Ctx.GetConfig(RawDataCompressionEnv), false)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid %s", RawDataCompressionEnv))
+	}
 	return &RawDataSubTask{
-		args:   &args,
-		table:  fmt.Sprintf("_raw_%s", args.Table),
-		params: paramsString,
+		args:     &args,
+		table:    fmt.Sprintf("_raw_%s", args.Table),
+		params:   paramsString,
+		compress: compress,
 	}, nil
 }
 
@@ -99,3 +117,69 @@ func (r *RawDataSubTask) GetTable() string {
 func (r *RawDataSubTask) GetParams() string {
 	return r.params
 }
+
+// newRawData creates a raw row for the subtask, the data gets compressed if RAW_DATA_COMPRESSION is enabled
+func (r *RawDataSubTask) newRawData(data []byte, url string, input json.RawMessage) (*RawData, errors.Error) {
+	if r.compress {
+		compressed, err := CompressRawData(data)
+		if err != nil {
+			return nil, err
+		}
+		data = compressed
+	}
+	return &RawData{
+		Params: r.params,
+		Data:   data,
+		Url:    url,
+		Input:  input,
+	}, nil
+}
+
+// recordSync marks the start of a collection, rows created afterward belong to the new sync
+func (r *RawDataSubTask) recordSync(incremental bool) errors.Error {
+	if r.nested {
+		return nil
+	}
+	return r.args.Ctx.GetDal().Create(&models.RawDataSync{
+		RawDataTable:  r.table,
+		RawDataParams: r.params,
+		StartedAt:     time.Now(),
+		Incremental:   incremental,
+	})
+}
+
+// IsRawDataCompressed tells whether the data was compressed by CompressRawData
+func IsRawDataCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+// CompressRawData compresses the data with gzip
+func CompressRawData(data []byte) ([]byte, errors.Error) {
+	buf := &bytes.Buffer{}
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, errors.Convert(err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Convert(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressRawData returns the data as it is unless it was compressed by CompressRawData, so rows
+// collected before RAW_DATA_COMPRESSION was enabled could still be read
+func DecompressRawData(data []byte) ([]byte, errors.Error) {
+	if !IsRawDataCompressed(data) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to decompress raw data")
+	}
+	defer reader.Close()
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to decompress raw data")
+	}
+	return data, nil
+}
@@ -145,6 +145,10 @@ func (collector *GraphqlCollector) Execute() errors.Error {
 			return errors.Default.Wrap(err, "error deleting data from collector")
 		}
 	}
+	err = collector.recordSync(collector.args.Incremental)
+	if err != nil {
+		return errors.Default.Wrap(err, "error recording raw data sync")
+	}
 
 	collector.args.Ctx.SetProgress(0, -1)
 	if collector.args.Input != nil {
@@ -293,11 +297,10 @@ func (collector *GraphqlCollector) fetchAsync(reqData *GraphqlRequestData, handl
 
 	results, err := collector.args.ResponseParser(query)
 	for _, result := range results {
-		row := &RawData{
-			Params: collector.params,
-			Data:   result,
-			Url:    queryStr,
-			Input:  variablesJson,
+		row, err := collector.newRawData(result, queryStr, variablesJson)
+		if err != nil {
+			collector.checkError(err)
+			return
 		}
 		// collector.batchSave.Add(row)
 		err = db.Create(row, dal.From(collector.table))
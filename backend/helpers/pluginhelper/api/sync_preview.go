@@ -0,0 +1,134 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// SyncPreview tells how a subtask would run for a scope if the pipeline was started now
+type SyncPreview struct {
+	IsIncremental bool       `json:"isIncremental"`
+	Since         *time.Time `json:"since"`
+	Until         *time.Time `json:"until"`
+}
+
+// PreviewSubtaskSync predicts the sync mode and the time range of the subtask for the scope identified by the
+// task options without touching any state. It is a best-effort guess since the states are keyed by the raw
+// params of the scope, which are matched against the options by name, and the subtask config is assumed
+// unchanged as it is only known at run time.
+func PreviewSubtaskSync(
+	db dal.Dal,
+	syncPolicy *models.SyncPolicy,
+	pluginName string,
+	subtaskName string,
+	options map[string]interface{},
+) (*SyncPreview, errors.Error) {
+	if syncPolicy == nil {
+		syncPolicy = &models.SyncPolicy{}
+	}
+	now := time.Now()
+	preview := &SyncPreview{Since: syncPolicy.TimeAfter, Until: &now}
+	// subtasks managed by the SubtaskStateManager
+	subtaskStates := make([]*models.SubtaskState, 0)
+	err := db.All(&subtaskStates, dal.Where("plugin = ? AND subtask = ?", pluginName, subtaskName))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load subtask states")
+	}
+	for _, state := range subtaskStates {
+		if paramsMatchOptions(state.Params, options) {
+			preview.IsIncremental, preview.Since = calculateStateManagerIncrementalMode(syncPolicy, state, state.PrevConfig)
+			if preview.Since == nil {
+				preview.Since = state.TimeAfter
+			}
+			return preview, nil
+		}
+	}
+	if !strings.Contains(strings.ToLower(subtaskName), "collect") {
+		// the rest of extractors and convertors always process all the data
+		return preview, nil
+	}
+	// collectors managed by the CollectorStateManager, which are keyed by raw tables unknown before running,
+	// the collector would run incrementally only if all the raw tables of the scope were collected before
+	collectorStates := make([]*models.CollectorLatestState, 0)
+	err = db.All(&collectorStates, dal.Where("raw_data_table LIKE ?", fmt.Sprintf("_raw_%s_%%", pluginName)))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load collector states")
+	}
+	var since *time.Time
+	for _, state := range collectorStates {
+		if !strings.HasPrefix(state.RawDataTable, fmt.Sprintf("_raw_%s_", pluginName)) || !paramsMatchOptions(state.RawDataParams, options) {
+			continue
+		}
+		if syncPolicy.FullSync || state.LatestSuccessStart == nil {
+			return preview, nil
+		}
+		if syncPolicy.TimeAfter != nil && state.TimeAfter != nil && syncPolicy.TimeAfter.Before(*state.TimeAfter) {
+			return preview, nil
+		}
+		if since == nil || state.LatestSuccessStart.Before(*since) {
+			since = state.LatestSuccessStart
+		}
+	}
+	if since != nil {
+		preview.IsIncremental = true
+		preview.Since = since
+	}
+	return preview, nil
+}
+
+// paramsMatchOptions tells whether all fields of the raw params, e.g. `{"ConnectionId":1,"BoardId":2}`, are
+// found in the task options with the same value, field names are compared case-insensitively
+func paramsMatchOptions(params string, options map[string]interface{}) bool {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(params), &fields); err != nil || len(fields) == 0 {
+		return false
+	}
+	for name, value := range fields {
+		matched := false
+		for key, option := range options {
+			if strings.EqualFold(name, key) && formatParamValue(value) == formatParamValue(option) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// formatParamValue formats numbers without exponent, so large ids decoded as float64 could be compared as well
+func formatParamValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return fmt.Sprint(value)
+}
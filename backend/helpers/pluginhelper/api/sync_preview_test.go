@@ -0,0 +1,81 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParamsMatchOptions(t *testing.T) {
+	options := map[string]interface{}{"connectionId": float64(1), "boardId": float64(12345678), "scopeConfigId": float64(3)}
+	assert.True(t, paramsMatchOptions(`{"ConnectionId":1,"BoardId":12345678}`, options))
+	assert.False(t, paramsMatchOptions(`{"ConnectionId":1,"BoardId":2}`, options))
+	assert.False(t, paramsMatchOptions(`{"ConnectionId":1,"ProjectId":12345678}`, options))
+	assert.False(t, paramsMatchOptions(`{}`, options))
+	assert.False(t, paramsMatchOptions(`not json`, options))
+}
+
+func TestPreviewSubtaskSync(t *testing.T) {
+	time1 := errors.Must1(time.Parse(time.RFC3339, "2021-01-01T00:00:00Z"))
+	time2 := errors.Must1(time.Parse(time.RFC3339, "2022-01-01T00:00:00Z"))
+	options := map[string]interface{}{"connectionId": float64(1), "boardId": float64(2)}
+	mockDal := new(mockdal.Dal)
+	mockDal.On("All", mock.AnythingOfType("*[]*models.SubtaskState"), mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*[]*models.SubtaskState) = []*models.SubtaskState{
+			{Params: `{"ConnectionId":1,"BoardId":3}`},
+			{Params: `{"ConnectionId":1,"BoardId":2}`, PrevStartedAt: &time2},
+		}
+	}).Return(nil).Once()
+	mockDal.On("All", mock.AnythingOfType("*[]*models.SubtaskState"), mock.Anything).Return(nil)
+	mockDal.On("All", mock.AnythingOfType("*[]*models.CollectorLatestState"), mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*[]*models.CollectorLatestState) = []*models.CollectorLatestState{
+			{RawDataTable: "_raw_jira_api_issues", RawDataParams: `{"ConnectionId":1,"BoardId":2}`, LatestSuccessStart: &time2},
+			{RawDataTable: "_raw_jira_api_sprints", RawDataParams: `{"ConnectionId":1,"BoardId":2}`, LatestSuccessStart: &time1},
+			{RawDataTable: "_raw_jira_api_sprints", RawDataParams: `{"ConnectionId":1,"BoardId":3}`},
+		}
+	}).Return(nil)
+
+	// stateful subtask
+	preview, err := PreviewSubtaskSync(mockDal, nil, "jira", "extractIssues", options)
+	assert.Nil(t, err)
+	assert.True(t, preview.IsIncremental)
+	assert.Equal(t, &time2, preview.Since)
+
+	// stateless subtask
+	preview, err = PreviewSubtaskSync(mockDal, &models.SyncPolicy{TimeAfter: &time1}, "jira", "convertIssues", options)
+	assert.Nil(t, err)
+	assert.False(t, preview.IsIncremental)
+	assert.Equal(t, &time1, preview.Since)
+
+	// collector starts from the earliest collected raw table
+	preview, err = PreviewSubtaskSync(mockDal, nil, "jira", "collectIssues", options)
+	assert.Nil(t, err)
+	assert.True(t, preview.IsIncremental)
+	assert.Equal(t, &time1, preview.Since)
+
+	preview, err = PreviewSubtaskSync(mockDal, &models.SyncPolicy{TriggerSyncPolicy: models.TriggerSyncPolicy{FullSync: true}}, "jira", "collectIssues", options)
+	assert.Nil(t, err)
+	assert.False(t, preview.IsIncremental)
+}
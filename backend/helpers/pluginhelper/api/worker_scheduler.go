@@ -26,6 +26,7 @@ import (
 
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/helpers/metricshelper"
 
 	"github.com/panjf2000/ants/v2"
 )
@@ -77,6 +78,7 @@ func NewWorkerScheduler(
 		return nil, errors.Convert(err)
 	}
 	s.pool = pool
+	metricshelper.SchedulerTickIntervalSet(tickInterval)
 	return s, nil
 }
 
@@ -92,8 +94,10 @@ func (s *WorkerScheduler) SubmitBlocking(task func() errors.Error) {
 		return
 	}
 	s.waitGroup.Add(1)
-	s.checkError(s.pool.Submit(func() {
+	metricshelper.SchedulerJobSubmitted()
+	err := s.pool.Submit(func() {
 		defer s.waitGroup.Done()
+		defer metricshelper.SchedulerJobEnded()
 
 		id := atomic.AddInt32(&s.counter, 1)
 		s.logger.Debug("schedulerJob >>> %d started", id)
@@ -113,7 +117,11 @@ func (s *WorkerScheduler) SubmitBlocking(task func() errors.Error) {
 				panic(err)
 			}
 		}
-	}))
+	})
+	if err != nil {
+		metricshelper.SchedulerJobEnded()
+	}
+	s.checkError(err)
 }
 
 /*
@@ -179,6 +187,7 @@ func (s *WorkerScheduler) WaitAsync() errors.Error {
 func (s *WorkerScheduler) Reset(interval time.Duration) {
 	s.tickInterval = interval
 	s.ticker.Reset(interval)
+	metricshelper.SchedulerTickIntervalSet(interval)
 }
 
 // GetTickInterval returns current tick interval of the WorkScheduler
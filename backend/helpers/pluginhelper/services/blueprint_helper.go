@@ -103,6 +103,12 @@ func (b *BlueprintManager) SaveDbBlueprint(blueprint *models.Blueprint) errors.E
 			errors.Must(b.db.Create(scope))
 		}
 	}
+	errors.Must(b.db.Delete(&models.BlueprintTrigger{}, dal.Where("blueprint_id = ?", blueprint.ID)))
+	for _, trigger := range blueprint.Triggers {
+		trigger.ID = 0
+		trigger.BlueprintId = blueprint.ID
+		errors.Must(b.db.Create(trigger))
+	}
 	return nil
 }
 
@@ -276,6 +282,7 @@ func (b *BlueprintManager) DeleteBlueprint(id uint64) errors.Error {
 	}
 	errors.Must(tx.Delete(&models.BlueprintConnection{}, dal.Where("blueprint_id = ?", id)))
 	errors.Must(tx.Delete(&models.BlueprintScope{}, dal.Where("blueprint_id = ?", id)))
+	errors.Must(tx.Delete(&models.BlueprintTrigger{}, dal.Where("blueprint_id = ?", id)))
 	return tx.Commit()
 }
 
@@ -305,5 +312,11 @@ func (b *BlueprintManager) fillBlueprintDetail(blueprint *models.Blueprint) erro
 			),
 		)
 	}
+	errors.Must(
+		b.db.All(
+			&blueprint.Triggers,
+			dal.Where("blueprint_id = ?", blueprint.ID),
+		),
+	)
 	return nil
 }
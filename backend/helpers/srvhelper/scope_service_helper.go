@@ -241,7 +241,7 @@ func (scopeSrv *ScopeSrvHelper[C, S, SC]) deleteScopeData(scope plugin.ToolLayer
 			params = []interface{}{rawDataParams}
 		} else {
 			// framework tables: should check plugin, connection and scope
-			if table == (models.CollectorLatestState{}.TableName()) {
+			if table == (models.CollectorLatestState{}.TableName()) || table == (models.RawDataSync{}.TableName()) {
 				// diff sync state
 				where = "raw_data_table LIKE ? AND raw_data_params = ?"
 			} else {
@@ -300,7 +300,7 @@ func (scopeSrv *ScopeSrvHelper[C, S, SC]) getAffectedTables() ([]string, errors.
 		}
 	}
 	// additional tables
-	tables = append(tables, models.CollectorLatestState{}.TableName())
+	tables = append(tables, models.CollectorLatestState{}.TableName(), models.RawDataSync{}.TableName())
 	scopeSrv.log.Debug("Discovered %d tables used by plugin \"%s\": %v", len(tables), scopeSrv.pluginName, tables)
 	return tables, nil
 }
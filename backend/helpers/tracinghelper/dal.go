@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracinghelper
+
+import (
+	gocontext "context"
+	"database/sql"
+	"reflect"
+
+	corecontext "github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracedBasicRes hands out the dal reporting spans under the context
+type tracedBasicRes struct {
+	corecontext.BasicRes
+	ctx gocontext.Context
+}
+
+// WrapBasicRes returns the BasicRes whose dal reports heavy operations as spans under the ctx
+func WrapBasicRes(ctx gocontext.Context, basicRes corecontext.BasicRes) corecontext.BasicRes {
+	return &tracedBasicRes{BasicRes: basicRes, ctx: ctx}
+}
+
+func (r *tracedBasicRes) GetDal() dal.Dal {
+	return &tracedDal{Dal: r.BasicRes.GetDal(), ctx: r.ctx}
+}
+
+func (r *tracedBasicRes) NestedLogger(name string) corecontext.BasicRes {
+	return &tracedBasicRes{BasicRes: r.BasicRes.NestedLogger(name), ctx: r.ctx}
+}
+
+func (r *tracedBasicRes) ReplaceLogger(logger log.Logger) corecontext.BasicRes {
+	return &tracedBasicRes{BasicRes: r.BasicRes.ReplaceLogger(logger), ctx: r.ctx}
+}
+
+// tracedDal reports the operations which might be slow on large tables, others are passed through
+type tracedDal struct {
+	dal.Dal
+	ctx gocontext.Context
+}
+
+func (d *tracedDal) start(operation string, target interface{}, clauses []dal.Clause) func(err error) {
+	_, span := Start(d.ctx, "dal."+operation,
+		attribute.String("db.operation", operation),
+		attribute.String("db.table", tableOf(target, clauses)),
+	)
+	return func(err error) {
+		End(span, err)
+	}
+}
+
+func (d *tracedDal) Exec(query string, params ...interface{}) (err errors.Error) {
+	end := d.start("Exec", nil, nil)
+	defer func() { end(err) }()
+	return d.Dal.Exec(query, params...)
+}
+
+func (d *tracedDal) Cursor(clauses ...dal.Clause) (rows dal.Rows, err errors.Error) {
+	end := d.start("Cursor", nil, clauses)
+	defer func() { end(err) }()
+	return d.Dal.Cursor(clauses...)
+}
+
+func (d *tracedDal) RawCursor(query string, params ...interface{}) (rows *sql.Rows, err errors.Error) {
+	end := d.start("RawCursor", nil, nil)
+	defer func() { end(err) }()
+	return d.Dal.RawCursor(query, params...)
+}
+
+func (d *tracedDal) All(dst interface{}, clauses ...dal.Clause) (err errors.Error) {
+	end := d.start("All", dst, clauses)
+	defer func() { end(err) }()
+	return d.Dal.All(dst, clauses...)
+}
+
+func (d *tracedDal) Count(clauses ...dal.Clause) (count int64, err errors.Error) {
+	end := d.start("Count", nil, clauses)
+	defer func() { end(err) }()
+	return d.Dal.Count(clauses...)
+}
+
+func (d *tracedDal) CreateOrUpdate(entity interface{}, clauses ...dal.Clause) (err errors.Error) {
+	end := d.start("CreateOrUpdate", entity, clauses)
+	defer func() { end(err) }()
+	return d.Dal.CreateOrUpdate(entity, clauses...)
+}
+
+func (d *tracedDal) CreateIfNotExist(entity interface{}, clauses ...dal.Clause) (err errors.Error) {
+	end := d.start("CreateIfNotExist", entity, clauses)
+	defer func() { end(err) }()
+	return d.Dal.CreateIfNotExist(entity, clauses...)
+}
+
+func (d *tracedDal) UpdateColumns(entityOrTable interface{}, set []dal.DalSet, clauses ...dal.Clause) (err errors.Error) {
+	end := d.start("UpdateColumns", entityOrTable, clauses)
+	defer func() { end(err) }()
+	return d.Dal.UpdateColumns(entityOrTable, set, clauses...)
+}
+
+func (d *tracedDal) Delete(entity interface{}, clauses ...dal.Clause) (err errors.Error) {
+	end := d.start("Delete", entity, clauses)
+	defer func() { end(err) }()
+	return d.Dal.Delete(entity, clauses...)
+}
+
+// tableOf finds out the table name from the entity or the From clause, best effort
+func tableOf(target interface{}, clauses []dal.Clause) string {
+	for _, c := range clauses {
+		if c.Type == dal.FromClause {
+			target = c.Data
+		}
+	}
+	switch t := target.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case dal.DalClause:
+		return t.Expr
+	case dal.Tabler:
+		return t.TableName()
+	}
+	// slices of entities
+	t := reflect.TypeOf(target)
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		if tabler, ok := reflect.New(t).Interface().(dal.Tabler); ok {
+			return tabler.TableName()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,208 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracinghelper
+
+import (
+	gocontext "context"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	EXPORTER_OTLP = "otlp"
+	EXPORTER_FILE = "file"
+
+	tracerName = "github.com/apache/incubator-devlake"
+)
+
+var provider *sdktrace.TracerProvider
+
+// Init sets up the global tracer provider according to the configuration:
+// TRACING_EXPORTER: `otlp` to export spans to the collector specified by the standard OTEL_EXPORTER_OTLP_* variables,
+// `file` to write them as json lines into TRACING_FILE, tracing is disabled if empty
+// TRACING_SAMPLE_RATIO: ratio of the pipelines to be traced, defaults to 1
+func Init(cfg config.ConfigReader, logger log.Logger) errors.Error {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(cfg.GetString("TRACING_EXPORTER")) {
+	case "":
+		return nil
+	case EXPORTER_OTLP:
+		var opts []otlptracehttp.Option
+		opts, err = otlpOptions(cfg.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
+		if err == nil {
+			exporter, err = otlptracehttp.New(gocontext.Background(), opts...)
+		}
+	case EXPORTER_FILE:
+		path := cfg.GetString("TRACING_FILE")
+		if path == "" {
+			path = filepath.Join(cfg.GetString("LOGGING_DIR"), "traces.json")
+		}
+		var file *os.File
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err == nil {
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		}
+	default:
+		return errors.BadInput.New("TRACING_EXPORTER should be either otlp or file")
+	}
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to create the tracing exporter")
+	}
+	ratio := 1.0
+	if cfg.IsSet("TRACING_SAMPLE_RATIO") {
+		ratio = cfg.GetFloat64("TRACING_SAMPLE_RATIO")
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", "devlake")))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to create the tracing resource")
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Info("tracing enabled with the %s exporter", cfg.GetString("TRACING_EXPORTER"))
+	return nil
+}
+
+// otlpOptions converts the endpoint from the .env file to options since the exporter only reads it from the
+// environment variables
+func otlpOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	if endpoint == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path.Join("/", u.Path, "v1/traces")),
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return opts, nil
+}
+
+// Shutdown flushes the pending spans
+func Shutdown(ctx gocontext.Context) {
+	if provider != nil {
+		_ = provider.Shutdown(ctx)
+	}
+}
+
+// Start creates a span as the child of the span in ctx, or the current span of the holder installed by
+// WithCurrentSpanHolder if ctx carries no more specific span
+func Start(ctx gocontext.Context, name string, attrs ...attribute.KeyValue) (gocontext.Context, trace.Span) {
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	// spans are compared by their span contexts since some implementations are not comparable
+	if holder, ok := ctx.Value(holderKey{}).(*spanHolder); ok && trace.SpanContextFromContext(ctx).Equal(holder.base.SpanContext()) {
+		if current := holder.get(); current != nil {
+			ctx = current
+		}
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span and marks it as failed if err is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type holderKey struct{}
+
+// spanHolder keeps the span being executed sequentially under a long living context, i.e. the subtask
+// being run by a task, so clients created with the context could report spans as its children
+type spanHolder struct {
+	base    trace.Span
+	mu      sync.RWMutex
+	current gocontext.Context
+}
+
+func (h *spanHolder) get() gocontext.Context {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.current
+}
+
+// WithCurrentSpanHolder installs a holder for SetCurrentSpan into the context
+func WithCurrentSpanHolder(ctx gocontext.Context) gocontext.Context {
+	return gocontext.WithValue(ctx, holderKey{}, &spanHolder{base: trace.SpanFromContext(ctx)})
+}
+
+// SetCurrentSpan sets the context of the span being executed, nil to reset
+func SetCurrentSpan(ctx gocontext.Context, current gocontext.Context) {
+	if holder, ok := ctx.Value(holderKey{}).(*spanHolder); ok {
+		holder.mu.Lock()
+		defer holder.mu.Unlock()
+		holder.current = current
+	}
+}
+
+// ScopeAttributes picks the connection id and the scope params, e.g. `repoId` or `fullName`, out of the task
+// options. Other options are left out since they might carry credentials, i.e. the url of a git repo
+func ScopeAttributes(options map[string]interface{}) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0)
+	for key, value := range options {
+		if key != "connectionId" && !strings.HasSuffix(key, "Id") && !strings.HasSuffix(key, "Name") {
+			continue
+		}
+		name := "scope." + key
+		if key == "connectionId" {
+			name = "connection.id"
+		}
+		switch v := value.(type) {
+		case string:
+			attrs = append(attrs, attribute.String(name, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(name, v))
+		case int:
+			attrs = append(attrs, attribute.Int(name, v))
+		case int64:
+			attrs = append(attrs, attribute.Int64(name, v))
+		case uint64:
+			attrs = append(attrs, attribute.Int64(name, int64(v)))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,63 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracinghelper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestScopeAttributes(t *testing.T) {
+	attrs := ScopeAttributes(map[string]interface{}{
+		"connectionId": float64(1),
+		"fullName":     "apache/incubator-devlake",
+		"token":        "secret",
+		"scopeConfig":  map[string]interface{}{"id": 1},
+	})
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.Float64("connection.id", 1),
+		attribute.String("scope.fullName", "apache/incubator-devlake"),
+	}, attrs)
+}
+
+func TestTableOf(t *testing.T) {
+	assert.Equal(t, "_devlake_pipelines", tableOf(&models.Pipeline{}, nil))
+	assert.Equal(t, "_devlake_pipelines", tableOf(&[]models.Pipeline{}, nil))
+	assert.Equal(t, "issues", tableOf(nil, []dal.Clause{dal.From("issues")}))
+	assert.Equal(t, "", tableOf(nil, nil))
+}
+
+func TestStartWithoutProvider(t *testing.T) {
+	// spans created without a provider are not comparable
+	ctx, task := Start(context.Background(), "task")
+	ctx = WithCurrentSpanHolder(ctx)
+	assert.NotPanics(t, func() {
+		spanCtx, subtask := Start(ctx, "subtask")
+		SetCurrentSpan(ctx, spanCtx)
+		_, request := Start(ctx, "request")
+		End(request, nil)
+		SetCurrentSpan(ctx, nil)
+		End(subtask, nil)
+	})
+	End(task, nil)
+}
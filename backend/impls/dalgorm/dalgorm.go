@@ -119,6 +119,10 @@ func buildTx(tx *gorm.DB, clauses []dal.Clause) *gorm.DB {
 		case dal.HavingClause:
 			tx = tx.Having(d.(dal.DalClause).Expr, transformParams(d.(dal.DalClause).Params)...)
 		case dal.LockClause:
+			if tx.Dialector.Name() == "sqlite" {
+				// sqlite has no row locking, the write lock of the whole database is acquired by the transaction
+				continue
+			}
 			locking := clause.Locking{}
 			params := d.([]bool)
 			write := params[0]
@@ -341,6 +345,20 @@ func (d *Dalgorm) DropColumns(table string, columnNames ...string) errors.Error
 		_ = d.Exec("SELECT * FROM ? LIMIT 1", clause.Table{Name: table})
 	}()
 	for _, columnName := range columnNames {
+		if d.db.Dialector.Name() == "sqlite" {
+			// sqlite refuses to drop indexed columns while mysql and pg drop the indexes along with them
+			var indexNames []string
+			err := d.db.Raw(
+				"SELECT il.name FROM pragma_index_list(?) il, pragma_index_info(il.name) ii WHERE il.origin = 'c' AND ii.name = ?",
+				table, columnName,
+			).Scan(&indexNames).Error
+			if err != nil {
+				return d.convertGormError(err)
+			}
+			if err := d.DropIndexes(table, indexNames...); err != nil {
+				return err
+			}
+		}
 		err := d.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: columnName})
 		// err := d.db.Migrator().DropColumn(table, columnName)
 		if err != nil {
@@ -380,8 +398,12 @@ func (d *Dalgorm) ModifyColumnType(table, columnName, columnType string) errors.
 		_ = d.Exec("SELECT * FROM ? LIMIT 1", clause.Table{Name: table})
 	}()
 	query := "ALTER TABLE ? MODIFY COLUMN ? %s"
-	if d.db.Dialector.Name() == "postgres" {
+	switch d.db.Dialector.Name() {
+	case "postgres":
 		query = "ALTER TABLE ? ALTER COLUMN ? TYPE %s"
+	case "sqlite":
+		// sqlite can not alter columns, and it does not have to since the type of a column is merely a hint
+		return nil
 	}
 	return d.Exec(
 		fmt.Sprintf(query, columnType),
@@ -393,9 +415,12 @@ func (d *Dalgorm) ModifyColumnType(table, columnName, columnType string) errors.
 // AllTables returns all tables in the database
 func (d *Dalgorm) AllTables() ([]string, errors.Error) {
 	var tableSql string
-	if d.db.Dialector.Name() == "mysql" {
+	switch d.db.Dialector.Name() {
+	case "mysql":
 		tableSql = "show tables"
-	} else {
+	case "sqlite":
+		tableSql = "select name from sqlite_master where type = 'table' and name not like 'sqlite_%'"
+	default:
 		tableSql = "select table_name from information_schema.tables where table_schema = 'public' and table_name not like '_devlake%'"
 	}
 	var tables []string
@@ -447,7 +472,42 @@ func (d *Dalgorm) HasColumn(table interface{}, columnName string) bool {
 // RenameTable renames table name
 func (d *Dalgorm) RenameTable(oldName, newName string) errors.Error {
 	err := d.db.Migrator().RenameTable(oldName, newName)
-	return d.convertGormError(err)
+	if err != nil {
+		return d.convertGormError(err)
+	}
+	if d.db.Dialector.Name() == "sqlite" {
+		return d.renameSqliteIndexes(oldName, newName)
+	}
+	return nil
+}
+
+// renameSqliteIndexes renames the indexes named after the renamed table, index names are unique across the whole
+// sqlite database, they would conflict with the indexes of the table being created with the old name otherwise
+func (d *Dalgorm) renameSqliteIndexes(oldName, newName string) errors.Error {
+	var indexes []struct {
+		Name string
+		Sql  string
+	}
+	err := d.db.Raw(
+		"SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL",
+		newName,
+	).Scan(&indexes).Error
+	if err != nil {
+		return d.convertGormError(err)
+	}
+	for _, index := range indexes {
+		if !strings.Contains(index.Name, oldName) {
+			continue
+		}
+		newIndexName := strings.Replace(index.Name, oldName, newName, 1)
+		if err := d.Exec("DROP INDEX ?", clause.Table{Name: index.Name}); err != nil {
+			return err
+		}
+		if err := d.Exec(strings.Replace(index.Sql, index.Name, newIndexName, 1)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // DropIndexes drops indexes for specified table
@@ -493,7 +553,9 @@ func (d *Dalgorm) IsErrorNotFound(err error) bool {
 
 // IsDuplicationError checking if the sql error is not found.
 func (d *Dalgorm) IsDuplicationError(err error) bool {
-	return strings.Contains(strings.ToLower(err.Error()), "duplicate")
+	msg := strings.ToLower(err.Error())
+	// sqlite reports `UNIQUE constraint failed` instead
+	return strings.Contains(msg, "duplicate") || strings.Contains(msg, "unique constraint failed")
 }
 
 // IsCachedPlanError checks if the error is related to postgres cached query plan
@@ -510,7 +572,7 @@ func (d *Dalgorm) IsJsonOrderError(err error) bool {
 
 // IsTableExist checks if table exists
 func (d *Dalgorm) IsTableExist(err error) bool {
-	return strings.Contains(err.Error(), "Unknown table")
+	return strings.Contains(err.Error(), "Unknown table") || strings.Contains(err.Error(), "no such table")
 }
 
 // RawCursor (Deprecated) executes raw sql query and returns a database cursor
@@ -82,6 +82,9 @@ func (t *DalgormTransaction) LockTables(lockTables dal.LockTables) errors.Error
 			}
 		}
 		return nil
+	case "sqlite":
+		// sqlite has no table locks, the transaction holds the write lock of the whole database already
+		return nil
 	default:
 		panic(fmt.Errorf("unknown dialect %s", t.Dialect()))
 	}
@@ -93,8 +96,8 @@ func (t *DalgormTransaction) UnlockTables() errors.Error {
 		// mysql would not release lock automatically on Rollback
 		// according to https://dev.mysql.com/doc/refman/8.0/en/lock-tables.html
 		return t.Exec("UNLOCK TABLES")
-	case "postgres":
-		// pg has no unlock tables
+	case "postgres", "sqlite":
+		// pg and sqlite have no unlock tables
 		return nil
 	default:
 		panic(fmt.Errorf("unknown dialect %s", t.Dialect()))
@@ -35,6 +35,8 @@ var _ schema.SerializerInterface = (*EncDecSerializer)(nil)
 // Ref: https://gorm.io/docs/serializer.html
 type EncDecSerializer struct {
 	encryptionSecret string
+	// previousSecrets are accepted for decryption while rotating the encryptionSecret
+	previousSecrets []string
 }
 
 // Scan implements serializer interface
@@ -52,7 +54,7 @@ func (es *EncDecSerializer) Scan(ctx context.Context, field *schema.Field, dst r
 			return fmt.Errorf("failed to decrypt value: %#v", dbValue)
 		}
 
-		decrypted, err := plugin.Decrypt(es.encryptionSecret, base64str)
+		decrypted, _, err := plugin.DecryptWithAny(append([]string{es.encryptionSecret}, es.previousSecrets...), base64str)
 		if err != nil {
 			return err
 		}
@@ -99,7 +101,8 @@ func (es *EncDecSerializer) Value(ctx context.Context, field *schema.Field, dst
 	return plugin.Encrypt(es.encryptionSecret, target)
 }
 
-// Init the encdec serializer
-func Init(encryptionSecret string) {
-	schema.RegisterSerializer("encdec", &EncDecSerializer{encryptionSecret: encryptionSecret})
+// Init the encdec serializer, values are always encrypted by the encryptionSecret while the previousSecrets
+// are accepted for decryption as well
+func Init(encryptionSecret string, previousSecrets ...string) {
+	schema.RegisterSerializer("encdec", &EncDecSerializer{encryptionSecret: encryptionSecret, previousSecrets: previousSecrets})
 }
@@ -0,0 +1,61 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dalgorm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+func TestEncDecSerializer_ScanWithPreviousSecrets(t *testing.T) {
+	type model struct {
+		Token string
+	}
+	field := &schema.Field{
+		Name:      "Token",
+		FieldType: reflect.TypeOf(""),
+		StructField: reflect.StructField{
+			Name: "Token",
+			Type: reflect.TypeOf(""),
+		},
+	}
+	field.ReflectValueOf = func(ctx context.Context, v reflect.Value) reflect.Value {
+		return reflect.Indirect(v).FieldByName("Token")
+	}
+	encrypted, err := plugin.Encrypt("old", "token")
+	assert.Nil(t, err)
+
+	dst := &model{}
+	serializer := &EncDecSerializer{encryptionSecret: "new", previousSecrets: []string{"old"}}
+	assert.Nil(t, serializer.Scan(context.Background(), field, reflect.ValueOf(dst), encrypted))
+	assert.Equal(t, "token", dst.Token)
+
+	serializer = &EncDecSerializer{encryptionSecret: "new"}
+	assert.NotNil(t, serializer.Scan(context.Background(), field, reflect.ValueOf(dst), encrypted))
+
+	value, e := (&EncDecSerializer{encryptionSecret: "new", previousSecrets: []string{"old"}}).Value(context.Background(), field, reflect.ValueOf(dst), "token")
+	assert.Nil(t, e)
+	plainText, err := plugin.Decrypt("new", value.(string))
+	assert.Nil(t, err)
+	assert.Equal(t, "token", plainText)
+}
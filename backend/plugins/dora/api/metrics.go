@@ -0,0 +1,271 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+type DoraMetricsOutput struct {
+	ProjectName string        `json:"projectName"`
+	ServiceName string        `json:"serviceName,omitempty"`
+	Report      string        `json:"report"`
+	Granularity string        `json:"granularity"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Overall     DoraMetrics   `json:"overall"`
+	Periods     []*DoraPeriod `json:"periods"`
+}
+
+// @Summary get the DORA metrics of a project
+// @Description deployment frequency, change lead time, change failure rate and recovery time of the project,
+// @Description along with the deployment rework rate and failed deployment recovery time
+// @Description with the benchmark classification of the report, for the whole range and each period of it.
+// @Description The dora plugin must have been run for the project.
+// @Tags plugins/dora
+// @Param projectName query string true "project name"
+// @Param serviceName query string false "the service of the project defined in the dora options, the whole project by default"
+// @Param from query string false "inclusive start of the range, 6 months before `to` by default"
+// @Param to query string false "exclusive end of the range, now by default"
+// @Param granularity query string false "week, month or quarter, month by default"
+// @Param report query string false "the DORA report to benchmark against, 2021 or 2023, 2023 by default"
+// @Success 200  {object} DoraMetricsOutput
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /plugins/dora/metrics [GET]
+func GetMetrics(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	output := &DoraMetricsOutput{
+		ProjectName: input.Query.Get("projectName"),
+		ServiceName: input.Query.Get("serviceName"),
+		Report:      input.Query.Get("report"),
+		Granularity: input.Query.Get("granularity"),
+		To:          time.Now(),
+	}
+	if output.ProjectName == "" {
+		return nil, errors.BadInput.New("projectName is required")
+	}
+	if output.Report == "" {
+		output.Report = DORA_REPORT_2023
+	}
+	if err := validateReport(output.Report); err != nil {
+		return nil, err
+	}
+	if output.Granularity == "" {
+		output.Granularity = GRANULARITY_MONTH
+	}
+	var err errors.Error
+	if to := input.Query.Get("to"); to != "" {
+		if output.To, err = parseMetricsTime(to); err != nil {
+			return nil, errors.BadInput.Wrap(err, "invalid to")
+		}
+	}
+	output.From = output.To.AddDate(0, -6, 0)
+	if from := input.Query.Get("from"); from != "" {
+		if output.From, err = parseMetricsTime(from); err != nil {
+			return nil, errors.BadInput.Wrap(err, "invalid from")
+		}
+	}
+	if !output.From.Before(output.To) {
+		return nil, errors.BadInput.New("from must be before to")
+	}
+	periods, err := splitPeriods(output.From, output.To, output.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	data, err := loadDoraData(basicRes.GetDal(), output.ProjectName, output.ServiceName, output.To)
+	if err != nil {
+		return nil, err
+	}
+	output.Overall = calculateDoraMetrics(data, output.From, output.To, output.Report)
+	for _, period := range periods {
+		output.Periods = append(output.Periods, &DoraPeriod{
+			Start:       period[0],
+			End:         period[1],
+			DoraMetrics: calculateDoraMetrics(data, period[0], period[1], output.Report),
+		})
+	}
+	return &plugin.ApiResourceOutput{Body: output, Status: http.StatusOK}, nil
+}
+
+func parseMetricsTime(value string) (time.Time, errors.Error) {
+	t, e := common.ConvertStringToTime(value)
+	if e != nil {
+		return t, errors.BadInput.Wrap(e, fmt.Sprintf("failed to parse %s", value))
+	}
+	return t, nil
+}
+
+// loadDoraData loads the production deployments, the deployed PRs and the incidents of the project or one of its
+// services until `to`
+func loadDoraData(db dal.Dal, projectName, serviceName string, to time.Time) (*doraData, errors.Error) {
+	data := &doraData{}
+	var err errors.Error
+	if serviceName == "" {
+		err = loadProjectDoraData(db, data, projectName, to)
+	} else {
+		err = loadServiceDoraData(db, data, projectName, serviceName, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	data.hasDeployments = len(data.deployments) > 0
+	if !data.hasDeployments {
+		count, err := db.Count(dal.From(&devops.CicdDeploymentCommit{}))
+		if err != nil {
+			return nil, err
+		}
+		data.hasDeployments = count > 0
+	}
+	data.hasIncidents = len(data.incidents) > 0
+	if !data.hasIncidents {
+		count, err := db.Count(dal.From("incidents"))
+		if err != nil {
+			return nil, err
+		}
+		data.hasIncidents = count > 0
+	}
+	return data, nil
+}
+
+// loadProjectDoraData queries the tables produced by the dora plugin the same way as the DORA dashboards do
+func loadProjectDoraData(db dal.Dal, data *doraData, projectName string, to time.Time) errors.Error {
+	err := db.All(
+		&data.deployments,
+		dal.Select("cdc.cicd_deployment_id AS id, MAX(cdc.finished_date) AS finished_date"),
+		dal.From("cicd_deployment_commits cdc"),
+		dal.Join("JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = cdc.cicd_scope_id)"),
+		dal.Where(
+			"pm.project_name = ? AND cdc.result = ? AND cdc.environment = ? AND cdc.finished_date < ?",
+			projectName, devops.RESULT_SUCCESS, devops.PRODUCTION, to,
+		),
+		dal.Groupby("cdc.cicd_deployment_id"),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load deployments")
+	}
+	err = db.All(
+		&data.prCycleTimes,
+		dal.Select("DISTINCT pr.id, ppm.pr_cycle_time, cdc.finished_date AS deployment_finished_date"),
+		dal.From("pull_requests pr"),
+		dal.Join("JOIN project_pr_metrics ppm ON (ppm.id = pr.id)"),
+		dal.Join("JOIN project_mapping pm ON (pm.table = 'repos' AND pm.row_id = pr.base_repo_id)"),
+		dal.Join("JOIN cicd_deployment_commits cdc ON (cdc.id = ppm.deployment_commit_id)"),
+		dal.Where(
+			"pm.project_name = ? AND ppm.project_name = ? AND pr.merged_date IS NOT NULL AND ppm.pr_cycle_time IS NOT NULL AND cdc.finished_date < ?",
+			projectName, projectName, to,
+		),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load pull requests")
+	}
+	err = db.All(
+		&data.deployedIncidents,
+		dal.Select("i.id, i.resolution_date, pidr.deployment_id"),
+		dal.From("incidents i"),
+		dal.Join("JOIN project_incident_deployment_relationships pidr ON (pidr.id = i.id)"),
+		dal.Where("pidr.project_name = ?", projectName),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load incidents of deployments")
+	}
+	err = db.All(
+		&data.incidents,
+		dal.Select("DISTINCT i.id, i.lead_time_minutes, i.resolution_date"),
+		dal.From("incidents i"),
+		dal.Join("JOIN project_mapping pm ON (pm.row_id = i.scope_id AND pm.table = i.table)"),
+		dal.Where("pm.project_name = ? AND i.resolution_date < ?", projectName, to),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load incidents")
+	}
+	err = db.All(
+		&data.deploymentMetrics,
+		dal.Select("pdm.id, pdm.is_failed, pdm.is_rework, pdm.recovered_date, pdm.recovery_time"),
+		dal.From("project_deployment_metrics pdm"),
+		dal.Where("pdm.project_name = ? AND pdm.finished_date < ?", projectName, to),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load deployment metrics")
+	}
+	return nil
+}
+
+// loadServiceDoraData loads the deployments, pull requests and incidents assigned to the service by the dora plugin
+func loadServiceDoraData(db dal.Dal, data *doraData, projectName, serviceName string, to time.Time) errors.Error {
+	err := db.All(
+		&data.deployments,
+		dal.Select("psd.id, psd.finished_date"),
+		dal.From("project_service_deployments psd"),
+		dal.Where("psd.project_name = ? AND psd.service_name = ? AND psd.finished_date < ?", projectName, serviceName, to),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load deployments of the service")
+	}
+	err = db.All(
+		&data.prCycleTimes,
+		dal.Select("pspm.id, pspm.pr_cycle_time, cdc.finished_date AS deployment_finished_date"),
+		dal.From("project_service_pr_metrics pspm"),
+		dal.Join("JOIN cicd_deployment_commits cdc ON (cdc.id = pspm.deployment_commit_id)"),
+		dal.Where(
+			"pspm.project_name = ? AND pspm.service_name = ? AND pspm.pr_cycle_time IS NOT NULL AND cdc.finished_date < ?",
+			projectName, serviceName, to,
+		),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load pull requests of the service")
+	}
+	err = db.All(
+		&data.deployedIncidents,
+		dal.Select("i.id, i.resolution_date, psi.deployment_id"),
+		dal.From("incidents i"),
+		dal.Join("JOIN project_service_incidents psi ON (psi.id = i.id)"),
+		dal.Where("psi.project_name = ? AND psi.service_name = ? AND psi.deployment_id <> ''", projectName, serviceName),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load incidents of deployments of the service")
+	}
+	err = db.All(
+		&data.incidents,
+		dal.Select("i.id, i.lead_time_minutes, i.resolution_date"),
+		dal.From("incidents i"),
+		dal.Join("JOIN project_service_incidents psi ON (psi.id = i.id)"),
+		dal.Where("psi.project_name = ? AND psi.service_name = ? AND i.resolution_date < ?", projectName, serviceName, to),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load incidents of the service")
+	}
+	err = db.All(
+		&data.deploymentMetrics,
+		dal.Select("pdm.id, pdm.is_failed, pdm.is_rework, pdm.recovered_date, pdm.recovery_time"),
+		dal.From("project_deployment_metrics pdm"),
+		dal.Join("JOIN project_service_deployments psd ON (psd.id = pdm.id AND psd.project_name = pdm.project_name)"),
+		dal.Where("psd.project_name = ? AND psd.service_name = ? AND pdm.finished_date < ?", projectName, serviceName, to),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to load deployment metrics of the service")
+	}
+	return nil
+}
@@ -0,0 +1,507 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+const (
+	GRANULARITY_WEEK    = "week"
+	GRANULARITY_MONTH   = "month"
+	GRANULARITY_QUARTER = "quarter"
+
+	DORA_REPORT_2021 = "2021"
+	DORA_REPORT_2023 = "2023"
+
+	LEVEL_ELITE  = "elite"
+	LEVEL_HIGH   = "high"
+	LEVEL_MEDIUM = "medium"
+	LEVEL_LOW    = "low"
+)
+
+// DoraMetric is the value of a metric in a period along with its benchmark classification,
+// Value and Level are empty when the data is not collected
+type DoraMetric struct {
+	Value      *float64 `json:"value"`
+	Unit       string   `json:"unit"`
+	Level      string   `json:"level"`
+	Benchmark  string   `json:"benchmark"`
+	SampleSize int      `json:"sampleSize"`
+}
+
+// DoraMetrics are the four key metrics, the recovery time is the "failed deployment recovery time" in the 2023 report
+// and the "time to restore service" in the 2021 report. The deployment rework rate and the failed deployment recovery
+// time introduced by the 2024 report are calculated from the rework deployments identified by the dora plugin
+type DoraMetrics struct {
+	DeploymentFrequency          *DoraMetric `json:"deploymentFrequency"`
+	ChangeLeadTime               *DoraMetric `json:"changeLeadTime"`
+	ChangeFailureRate            *DoraMetric `json:"changeFailureRate"`
+	RecoveryTime                 *DoraMetric `json:"recoveryTime"`
+	DeploymentReworkRate         *DoraMetric `json:"deploymentReworkRate"`
+	FailedDeploymentRecoveryTime *DoraMetric `json:"failedDeploymentRecoveryTime"`
+}
+
+type DoraPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	DoraMetrics
+}
+
+// doraDeployment is a production deployment, deployment commits of the same deployment are counted as one
+type doraDeployment struct {
+	Id           string
+	FinishedDate *time.Time
+}
+
+type doraPrCycleTime struct {
+	Id                     string
+	PrCycleTime            *int64
+	DeploymentFinishedDate *time.Time
+}
+
+type doraDeployedIncident struct {
+	Id             string
+	ResolutionDate *time.Time
+	DeploymentId   string
+}
+
+type doraIncident struct {
+	Id              string
+	LeadTimeMinutes *uint
+	ResolutionDate  *time.Time
+}
+
+// doraDeploymentMetric is the rework and recovery of a production deployment
+type doraDeploymentMetric struct {
+	Id            string
+	IsFailed      bool
+	IsRework      bool
+	RecoveredDate *time.Time
+	RecoveryTime  *int64
+}
+
+// doraData holds everything of a project needed to calculate the metrics in any period
+type doraData struct {
+	deployments       []*doraDeployment
+	prCycleTimes      []*doraPrCycleTime
+	deployedIncidents []*doraDeployedIncident
+	incidents         []*doraIncident
+	deploymentMetrics []*doraDeploymentMetric
+	// hasDeployments and hasIncidents tell whether the data is collected at all, to tell a low level from N/A
+	hasDeployments bool
+	hasIncidents   bool
+}
+
+func validateReport(report string) errors.Error {
+	if report != DORA_REPORT_2021 && report != DORA_REPORT_2023 {
+		return errors.BadInput.New(fmt.Sprintf("report must be one of %s and %s", DORA_REPORT_2021, DORA_REPORT_2023))
+	}
+	return nil
+}
+
+// splitPeriods splits [from, to) by the granularity, the first and last periods could be partial
+func splitPeriods(from, to time.Time, granularity string) ([][2]time.Time, errors.Error) {
+	var next func(t time.Time) time.Time
+	switch granularity {
+	case GRANULARITY_WEEK:
+		next = func(t time.Time) time.Time {
+			start := startOfWeek(t)
+			return start.AddDate(0, 0, 7)
+		}
+	case GRANULARITY_MONTH:
+		next = func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, 1, 0)
+		}
+	case GRANULARITY_QUARTER:
+		next = func(t time.Time) time.Time {
+			month := time.Month((int(t.Month())-1)/3*3 + 1)
+			return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location()).AddDate(0, 3, 0)
+		}
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("granularity must be one of %s, %s and %s", GRANULARITY_WEEK, GRANULARITY_MONTH, GRANULARITY_QUARTER))
+	}
+	var periods [][2]time.Time
+	for start := from; start.Before(to); {
+		end := next(start)
+		if end.After(to) {
+			end = to
+		}
+		periods = append(periods, [2]time.Time{start, end})
+		start = end
+	}
+	return periods, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns the monday of the week
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func inPeriod(t *time.Time, from, to time.Time) bool {
+	return t != nil && !t.Before(from) && t.Before(to)
+}
+
+// lowerMedian returns the largest value whose percent rank is no more than 0.5, which is how the dashboards
+// calculate the median
+func lowerMedian(values []float64) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	median := sorted[0]
+	firstIndex := 0
+	for i, value := range sorted {
+		if value != sorted[firstIndex] {
+			firstIndex = i
+		}
+		if len(sorted) > 1 && float64(firstIndex)/float64(len(sorted)-1) > 0.5 {
+			break
+		}
+		median = value
+	}
+	return median, true
+}
+
+// upperMedian returns the smallest value whose percent rank is no less than 0.5
+func upperMedian(values []float64) (float64, bool) {
+	if len(values) == 0 {
+		return 0, false
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	if len(sorted) == 1 {
+		return sorted[0], true
+	}
+	firstIndex := 0
+	for i, value := range sorted {
+		if value != sorted[firstIndex] {
+			firstIndex = i
+		}
+		if float64(firstIndex)/float64(len(sorted)-1) >= 0.5 {
+			return value, true
+		}
+	}
+	return sorted[len(sorted)-1], true
+}
+
+func calculateDoraMetrics(data *doraData, from, to time.Time, report string) DoraMetrics {
+	return DoraMetrics{
+		DeploymentFrequency: calculateDeploymentFrequency(data, from, to, report),
+		ChangeLeadTime:      calculateChangeLeadTime(data, from, to, report),
+		ChangeFailureRate:   calculateChangeFailureRate(data, from, to, report),
+		RecoveryTime:        calculateRecoveryTime(data, from, to, report),
+
+		DeploymentReworkRate:         calculateDeploymentReworkRate(data, from, to),
+		FailedDeploymentRecoveryTime: calculateFailedDeploymentRecoveryTime(data, from, to),
+	}
+}
+
+// calculateDeploymentFrequency counts the days with production deployments per calendar week, month and six months
+// of the period, the value is the median number of deployment days per week
+func calculateDeploymentFrequency(data *doraData, from, to time.Time, report string) *DoraMetric {
+	metric := &DoraMetric{Unit: "days per week"}
+	deploymentDays := make(map[time.Time]bool)
+	for _, deployment := range data.deployments {
+		if inPeriod(deployment.FinishedDate, from, to) {
+			deploymentDays[startOfDay(deployment.FinishedDate.In(from.Location()))] = true
+			metric.SampleSize++
+		}
+	}
+	weekly := make(map[time.Time]float64)
+	monthly := make(map[time.Time]float64)
+	var weeks, months []time.Time
+	for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		week, month := startOfWeek(day), startOfMonth(day)
+		if _, ok := weekly[week]; !ok {
+			weeks = append(weeks, week)
+			weekly[week] = 0
+		}
+		if _, ok := monthly[month]; !ok {
+			months = append(months, month)
+			monthly[month] = 0
+		}
+		if deploymentDays[day] {
+			weekly[week]++
+			monthly[month]++
+		}
+	}
+	var weekValues, monthValues, sixMonthValues []float64
+	for _, week := range weeks {
+		weekValues = append(weekValues, weekly[week])
+	}
+	for i, month := range months {
+		monthValues = append(monthValues, monthly[month])
+		if i%6 == 0 {
+			sixMonthValues = append(sixMonthValues, 0)
+		}
+		sixMonthValues[len(sixMonthValues)-1] += monthly[month]
+	}
+	perWeek, ok := lowerMedian(weekValues)
+	if !ok {
+		return metric
+	}
+	perMonth, _ := lowerMedian(monthValues)
+	perSixMonths, _ := upperMedian(sixMonthValues)
+	metric.Value = &perWeek
+	switch {
+	case perWeek >= 5:
+		metric.Level, metric.Benchmark = LEVEL_ELITE, "On-demand(elite)"
+	case report == DORA_REPORT_2023 && perWeek >= 1:
+		metric.Level, metric.Benchmark = LEVEL_HIGH, "Between once per day and once per week(high)"
+	case report == DORA_REPORT_2023 && perMonth >= 1:
+		metric.Level, metric.Benchmark = LEVEL_MEDIUM, "Between once per week and once per month(medium)"
+	case report == DORA_REPORT_2023 && data.hasDeployments:
+		metric.Level, metric.Benchmark = LEVEL_LOW, "Fewer than once per month(low)"
+	case report == DORA_REPORT_2021 && perMonth >= 1:
+		metric.Level, metric.Benchmark = LEVEL_HIGH, "Between once per day and once per month(high)"
+	case report == DORA_REPORT_2021 && perSixMonths >= 1:
+		metric.Level, metric.Benchmark = LEVEL_MEDIUM, "Between once per month and once every 6 months(medium)"
+	case report == DORA_REPORT_2021 && data.hasDeployments:
+		metric.Level, metric.Benchmark = LEVEL_LOW, "Fewer than once per six months(low)"
+	default:
+		metric.Value = nil
+		metric.Benchmark = "N/A. Please check if you have collected deployments."
+	}
+	return metric
+}
+
+// calculateChangeLeadTime takes the median cycle time of the PRs deployed in the period
+func calculateChangeLeadTime(data *doraData, from, to time.Time, report string) *DoraMetric {
+	metric := &DoraMetric{Unit: "minutes"}
+	seen := make(map[string]bool)
+	var values []float64
+	for _, pr := range data.prCycleTimes {
+		if pr.PrCycleTime == nil || seen[pr.Id] || !inPeriod(pr.DeploymentFinishedDate, from, to) {
+			continue
+		}
+		seen[pr.Id] = true
+		values = append(values, float64(*pr.PrCycleTime))
+	}
+	metric.SampleSize = len(values)
+	median, ok := lowerMedian(values)
+	if !ok {
+		metric.Benchmark = "N/A. Please check if you have collected deployments/pull_requests."
+		return metric
+	}
+	metric.Value = &median
+	const day = 24 * 60
+	if report == DORA_REPORT_2023 {
+		switch {
+		case median < day:
+			metric.Level, metric.Benchmark = LEVEL_ELITE, "Less than one day(elite)"
+		case median < 7*day:
+			metric.Level, metric.Benchmark = LEVEL_HIGH, "Between one day and one week(high)"
+		case median < 30*day:
+			metric.Level, metric.Benchmark = LEVEL_MEDIUM, "Between one week and one month(medium)"
+		default:
+			metric.Level, metric.Benchmark = LEVEL_LOW, "More than one month(low)"
+		}
+		return metric
+	}
+	switch {
+	case median < 60:
+		metric.Level, metric.Benchmark = LEVEL_ELITE, "Less than one hour(elite)"
+	case median < 7*day:
+		metric.Level, metric.Benchmark = LEVEL_HIGH, "Less than one week(high)"
+	case median < 180*day:
+		metric.Level, metric.Benchmark = LEVEL_MEDIUM, "Between one week and six months(medium)"
+	default:
+		metric.Level, metric.Benchmark = LEVEL_LOW, "More than six months(low)"
+	}
+	return metric
+}
+
+// calculateChangeFailureRate is the ratio of the deployments in the period causing at least one incident
+func calculateChangeFailureRate(data *doraData, from, to time.Time, report string) *DoraMetric {
+	metric := &DoraMetric{Unit: "ratio"}
+	switch {
+	case !data.hasDeployments && !data.hasIncidents:
+		metric.Benchmark = "N/A. Please check if you have collected deployments/incidents."
+		return metric
+	case !data.hasIncidents:
+		metric.Benchmark = "N/A. Please check if you have collected incidents."
+		return metric
+	case !data.hasDeployments:
+		metric.Benchmark = "N/A. Please check if you have collected deployments."
+		return metric
+	}
+	failed := make(map[string]bool)
+	for _, incident := range data.deployedIncidents {
+		failed[incident.DeploymentId] = true
+	}
+	var failures int
+	for _, deployment := range data.deployments {
+		if inPeriod(deployment.FinishedDate, from, to) {
+			metric.SampleSize++
+			if failed[deployment.Id] {
+				failures++
+			}
+		}
+	}
+	if metric.SampleSize == 0 {
+		metric.Benchmark = "N/A. Please check if you have collected deployments/incidents."
+		return metric
+	}
+	rate := float64(failures) / float64(metric.SampleSize)
+	metric.Value = &rate
+	if report == DORA_REPORT_2023 {
+		switch {
+		case rate <= .05:
+			metric.Level, metric.Benchmark = LEVEL_ELITE, "0-5%(elite)"
+		case rate <= .10:
+			metric.Level, metric.Benchmark = LEVEL_HIGH, "5%-10%(high)"
+		case rate <= .15:
+			metric.Level, metric.Benchmark = LEVEL_MEDIUM, "10%-15%(medium)"
+		default:
+			metric.Level, metric.Benchmark = LEVEL_LOW, "> 15%(low)"
+		}
+		return metric
+	}
+	switch {
+	case rate <= .15:
+		metric.Level, metric.Benchmark = LEVEL_ELITE, "0-15%(elite)"
+	case rate <= .20:
+		metric.Level, metric.Benchmark = LEVEL_HIGH, "16%-20%(high)"
+	case rate <= .30:
+		metric.Level, metric.Benchmark = LEVEL_MEDIUM, "21%-30%(medium)"
+	default:
+		metric.Level, metric.Benchmark = LEVEL_LOW, "> 30%(low)"
+	}
+	return metric
+}
+
+// calculateRecoveryTime takes the median time from the failed deployments to the resolution of their incidents
+// resolved in the period by the 2023 report, or the median lead time of the incidents resolved in the period
+// by the 2021 report
+func calculateRecoveryTime(data *doraData, from, to time.Time, report string) *DoraMetric {
+	metric := &DoraMetric{Unit: "minutes"}
+	var values []float64
+	if report == DORA_REPORT_2023 {
+		deployments := make(map[string]*doraDeployment, len(data.deployments))
+		for _, deployment := range data.deployments {
+			deployments[deployment.Id] = deployment
+		}
+		for _, incident := range data.deployedIncidents {
+			deployment := deployments[incident.DeploymentId]
+			if deployment == nil || deployment.FinishedDate == nil || !inPeriod(incident.ResolutionDate, from, to) {
+				continue
+			}
+			values = append(values, float64(int64(incident.ResolutionDate.Sub(*deployment.FinishedDate).Minutes())))
+		}
+	} else {
+		for _, incident := range data.incidents {
+			if incident.LeadTimeMinutes != nil && inPeriod(incident.ResolutionDate, from, to) {
+				values = append(values, float64(*incident.LeadTimeMinutes))
+			}
+		}
+	}
+	metric.SampleSize = len(values)
+	median, ok := lowerMedian(values)
+	if !ok {
+		metric.Benchmark = "N/A. Please check if you have collected incidents."
+		if report == DORA_REPORT_2023 {
+			metric.Benchmark = "N/A. Please check if you have collected deployments or incidents."
+		}
+		return metric
+	}
+	metric.Value = &median
+	classifyRecoveryTime(metric, median)
+	return metric
+}
+
+func classifyRecoveryTime(metric *DoraMetric, median float64) {
+	const hour = 60
+	switch {
+	case median < hour:
+		metric.Level, metric.Benchmark = LEVEL_ELITE, "Less than one hour(elite)"
+	case median < 24*hour:
+		metric.Level, metric.Benchmark = LEVEL_HIGH, "Less than one day(high)"
+	case median < 7*24*hour:
+		metric.Level, metric.Benchmark = LEVEL_MEDIUM, "Between one day and one week(medium)"
+	default:
+		metric.Level, metric.Benchmark = LEVEL_LOW, "More than one week(low)"
+	}
+}
+
+// calculateDeploymentReworkRate is the ratio of the deployments in the period rolling back or fixing forward failed
+// deployments, there is no benchmark level since the report publishes no thresholds for it
+func calculateDeploymentReworkRate(data *doraData, from, to time.Time) *DoraMetric {
+	metric := &DoraMetric{Unit: "ratio"}
+	if len(data.deploymentMetrics) == 0 {
+		metric.Benchmark = "N/A. Please check if you have collected deployments and calculated deployment rework."
+		return metric
+	}
+	rework := make(map[string]bool)
+	for _, deployment := range data.deploymentMetrics {
+		if deployment.IsRework {
+			rework[deployment.Id] = true
+		}
+	}
+	var reworks int
+	for _, deployment := range data.deployments {
+		if inPeriod(deployment.FinishedDate, from, to) {
+			metric.SampleSize++
+			if rework[deployment.Id] {
+				reworks++
+			}
+		}
+	}
+	if metric.SampleSize == 0 {
+		metric.Benchmark = "N/A. Please check if you have collected deployments."
+		return metric
+	}
+	rate := float64(reworks) / float64(metric.SampleSize)
+	metric.Value = &rate
+	return metric
+}
+
+// calculateFailedDeploymentRecoveryTime takes the median time from the failed deployments to their recovery in the
+// period, a failed deployment is recovered by its first rework deployment or the resolution of its incidents
+func calculateFailedDeploymentRecoveryTime(data *doraData, from, to time.Time) *DoraMetric {
+	metric := &DoraMetric{Unit: "minutes"}
+	var values []float64
+	for _, deployment := range data.deploymentMetrics {
+		if deployment.IsFailed && deployment.RecoveryTime != nil && inPeriod(deployment.RecoveredDate, from, to) {
+			values = append(values, float64(*deployment.RecoveryTime))
+		}
+	}
+	metric.SampleSize = len(values)
+	median, ok := lowerMedian(values)
+	if !ok {
+		metric.Benchmark = "N/A. Please check if you have collected deployments/incidents and calculated deployment rework."
+		return metric
+	}
+	metric.Value = &median
+	classifyRecoveryTime(metric, median)
+	return metric
+}
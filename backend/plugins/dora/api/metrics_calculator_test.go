@@ -0,0 +1,208 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestSplitPeriods(t *testing.T) {
+	periods, err := splitPeriods(*date("2024-01-10T00:00:00Z"), *date("2024-04-05T00:00:00Z"), GRANULARITY_QUARTER)
+	assert.Nil(t, err)
+	assert.Equal(t, [][2]time.Time{
+		{*date("2024-01-10T00:00:00Z"), *date("2024-04-01T00:00:00Z")},
+		{*date("2024-04-01T00:00:00Z"), *date("2024-04-05T00:00:00Z")},
+	}, periods)
+
+	// 2024-01-10 is a wednesday
+	periods, err = splitPeriods(*date("2024-01-10T00:00:00Z"), *date("2024-01-22T00:00:00Z"), GRANULARITY_WEEK)
+	assert.Nil(t, err)
+	assert.Equal(t, [][2]time.Time{
+		{*date("2024-01-10T00:00:00Z"), *date("2024-01-15T00:00:00Z")},
+		{*date("2024-01-15T00:00:00Z"), *date("2024-01-22T00:00:00Z")},
+	}, periods)
+
+	periods, err = splitPeriods(*date("2024-01-31T00:00:00Z"), *date("2024-03-01T00:00:00Z"), GRANULARITY_MONTH)
+	assert.Nil(t, err)
+	assert.Len(t, periods, 2)
+
+	_, err = splitPeriods(*date("2024-01-31T00:00:00Z"), *date("2024-03-01T00:00:00Z"), "day")
+	assert.NotNil(t, err)
+}
+
+func TestMedians(t *testing.T) {
+	_, ok := lowerMedian(nil)
+	assert.False(t, ok)
+	for _, tc := range []struct {
+		values []float64
+		lower  float64
+		upper  float64
+	}{
+		{[]float64{7}, 7, 7},
+		{[]float64{4, 1, 3, 2}, 2, 3},
+		{[]float64{1, 2, 3}, 2, 2},
+		{[]float64{2, 1, 2, 2}, 2, 2},
+		{[]float64{0, 0, 0, 5}, 0, 5},
+	} {
+		lower, _ := lowerMedian(tc.values)
+		upper, _ := upperMedian(tc.values)
+		assert.Equal(t, tc.lower, lower, "%v", tc.values)
+		assert.Equal(t, tc.upper, upper, "%v", tc.values)
+	}
+}
+
+func TestCalculateDeploymentFrequency(t *testing.T) {
+	from, to := *date("2024-01-01T00:00:00Z"), *date("2024-01-15T00:00:00Z")
+	data := &doraData{hasDeployments: true}
+	// deployed 2 days in both weeks
+	for i, d := range []string{"2024-01-01T10:00:00Z", "2024-01-01T12:00:00Z", "2024-01-03T00:00:00Z", "2024-01-08T00:00:00Z", "2024-01-14T23:00:00Z", "2024-01-15T00:00:00Z"} {
+		data.deployments = append(data.deployments, &doraDeployment{Id: string(rune('a' + i)), FinishedDate: date(d)})
+	}
+	metric := calculateDeploymentFrequency(data, from, to, DORA_REPORT_2023)
+	assert.Equal(t, 2.0, *metric.Value)
+	assert.Equal(t, 5, metric.SampleSize)
+	assert.Equal(t, LEVEL_HIGH, metric.Level)
+
+	metric = calculateDeploymentFrequency(data, from, to, DORA_REPORT_2021)
+	assert.Equal(t, LEVEL_HIGH, metric.Level)
+	assert.Equal(t, "Between once per day and once per month(high)", metric.Benchmark)
+
+	metric = calculateDeploymentFrequency(&doraData{}, from, to, DORA_REPORT_2023)
+	assert.Nil(t, metric.Value)
+	assert.Empty(t, metric.Level)
+
+	metric = calculateDeploymentFrequency(&doraData{hasDeployments: true}, from, to, DORA_REPORT_2023)
+	assert.Equal(t, 0.0, *metric.Value)
+	assert.Equal(t, LEVEL_LOW, metric.Level)
+}
+
+func TestCalculateChangeLeadTime(t *testing.T) {
+	from, to := *date("2024-01-01T00:00:00Z"), *date("2024-02-01T00:00:00Z")
+	cycleTime := func(minutes int64) *int64 { return &minutes }
+	data := &doraData{prCycleTimes: []*doraPrCycleTime{
+		{Id: "1", PrCycleTime: cycleTime(30), DeploymentFinishedDate: date("2024-01-02T00:00:00Z")},
+		{Id: "1", PrCycleTime: cycleTime(30), DeploymentFinishedDate: date("2024-01-03T00:00:00Z")},
+		{Id: "2", PrCycleTime: cycleTime(2 * 24 * 60), DeploymentFinishedDate: date("2024-01-05T00:00:00Z")},
+		{Id: "3", PrCycleTime: cycleTime(3 * 24 * 60), DeploymentFinishedDate: date("2024-01-06T00:00:00Z")},
+		{Id: "4", PrCycleTime: cycleTime(10), DeploymentFinishedDate: date("2024-02-06T00:00:00Z")},
+	}}
+	metric := calculateChangeLeadTime(data, from, to, DORA_REPORT_2023)
+	assert.Equal(t, 3, metric.SampleSize)
+	assert.Equal(t, float64(2*24*60), *metric.Value)
+	assert.Equal(t, LEVEL_HIGH, metric.Level)
+
+	metric = calculateChangeLeadTime(data, from, to, DORA_REPORT_2021)
+	assert.Equal(t, "Less than one week(high)", metric.Benchmark)
+
+	metric = calculateChangeLeadTime(&doraData{}, from, to, DORA_REPORT_2023)
+	assert.Nil(t, metric.Value)
+}
+
+func TestCalculateChangeFailureRate(t *testing.T) {
+	from, to := *date("2024-01-01T00:00:00Z"), *date("2024-02-01T00:00:00Z")
+	data := &doraData{
+		hasDeployments: true,
+		hasIncidents:   true,
+		deployedIncidents: []*doraDeployedIncident{
+			{Id: "i1", DeploymentId: "d1"},
+			{Id: "i2", DeploymentId: "d1"},
+			{Id: "i3", DeploymentId: "d5"},
+		},
+	}
+	for i := 1; i <= 5; i++ {
+		data.deployments = append(data.deployments, &doraDeployment{Id: "d" + string(rune('0'+i)), FinishedDate: date("2024-01-1" + string(rune('0'+i)) + "T00:00:00Z")})
+	}
+	metric := calculateChangeFailureRate(data, from, to, DORA_REPORT_2023)
+	assert.Equal(t, 5, metric.SampleSize)
+	assert.Equal(t, 0.4, *metric.Value)
+	assert.Equal(t, LEVEL_LOW, metric.Level)
+
+	data.hasIncidents = false
+	metric = calculateChangeFailureRate(data, from, to, DORA_REPORT_2023)
+	assert.Nil(t, metric.Value)
+	assert.Equal(t, "N/A. Please check if you have collected incidents.", metric.Benchmark)
+}
+
+func TestCalculateRecoveryTime(t *testing.T) {
+	from, to := *date("2024-01-01T00:00:00Z"), *date("2024-02-01T00:00:00Z")
+	leadTime := uint(90)
+	data := &doraData{
+		deployments: []*doraDeployment{
+			{Id: "d1", FinishedDate: date("2023-12-31T23:00:00Z")},
+		},
+		deployedIncidents: []*doraDeployedIncident{
+			{Id: "i1", DeploymentId: "d1", ResolutionDate: date("2024-01-01T00:30:00Z")},
+			{Id: "i2", DeploymentId: "d1", ResolutionDate: date("2024-02-01T00:30:00Z")},
+			{Id: "i3", DeploymentId: "unknown", ResolutionDate: date("2024-01-01T00:30:00Z")},
+		},
+		incidents: []*doraIncident{
+			{Id: "i1", LeadTimeMinutes: &leadTime, ResolutionDate: date("2024-01-01T00:30:00Z")},
+		},
+	}
+	metric := calculateRecoveryTime(data, from, to, DORA_REPORT_2023)
+	assert.Equal(t, 1, metric.SampleSize)
+	assert.Equal(t, 90.0, *metric.Value)
+	assert.Equal(t, LEVEL_HIGH, metric.Level)
+
+	metric = calculateRecoveryTime(data, from, to, DORA_REPORT_2021)
+	assert.Equal(t, 90.0, *metric.Value)
+	assert.Equal(t, "Less than one day(high)", metric.Benchmark)
+}
+
+func TestCalculateDeploymentRework(t *testing.T) {
+	from, to := *date("2024-01-01T00:00:00Z"), *date("2024-02-01T00:00:00Z")
+	data := &doraData{
+		deployments: []*doraDeployment{
+			{Id: "d1", FinishedDate: date("2024-01-02T10:00:00Z")},
+			{Id: "d2", FinishedDate: date("2024-01-02T11:00:00Z")},
+			{Id: "d3", FinishedDate: date("2024-01-03T10:00:00Z")},
+			{Id: "d4", FinishedDate: date("2024-01-04T10:00:00Z")},
+		},
+	}
+	metric := calculateDeploymentReworkRate(data, from, to)
+	assert.Nil(t, metric.Value)
+	assert.Nil(t, calculateFailedDeploymentRecoveryTime(data, from, to).Value)
+
+	recoveryTime := int64(60)
+	data.deploymentMetrics = []*doraDeploymentMetric{
+		{Id: "d1", IsFailed: true, RecoveredDate: date("2024-01-02T11:00:00Z"), RecoveryTime: &recoveryTime},
+		{Id: "d2", IsRework: true},
+		{Id: "d3"},
+		{Id: "d4", IsFailed: true},
+	}
+	metric = calculateDeploymentReworkRate(data, from, to)
+	assert.Equal(t, 4, metric.SampleSize)
+	assert.Equal(t, 0.25, *metric.Value)
+	assert.Empty(t, metric.Level)
+
+	metric = calculateFailedDeploymentRecoveryTime(data, from, to)
+	assert.Equal(t, 1, metric.SampleSize)
+	assert.Equal(t, 60.0, *metric.Value)
+	assert.Equal(t, LEVEL_HIGH, metric.Level)
+}
@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/plugins/dora/impl"
+	"github.com/apache/incubator-devlake/plugins/dora/tasks"
+)
+
+func TestCalculateDeploymentReworkDataFlow(t *testing.T) {
+	var plugin impl.Dora
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "dora", plugin)
+
+	taskData, err := plugin.PrepareTaskData(nil, map[string]interface{}{
+		"projectName": "project1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// import raw data table
+	dataflowTester.ImportCsvIntoTabler("./deployment_rework/cicd_deployment_commits.csv", &devops.CicdDeploymentCommit{})
+	dataflowTester.ImportCsvIntoTabler("./deployment_rework/project_mapping.csv", &crossdomain.ProjectMapping{})
+	dataflowTester.ImportCsvIntoTabler("./deployment_rework/incidents.csv", &ticket.Incident{})
+	dataflowTester.ImportCsvIntoTabler("./deployment_rework/project_incident_deployment_relationships.csv", &crossdomain.ProjectIncidentDeploymentRelationship{})
+	dataflowTester.ImportCsvIntoTabler("./deployment_rework/commit_parents.csv", &code.CommitParent{})
+
+	// verify converter
+	dataflowTester.FlushTabler(&crossdomain.ProjectDeploymentMetric{})
+	dataflowTester.Subtask(tasks.CalculateDeploymentReworkMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ProjectDeploymentMetric{}, e2ehelper.TableOptions{
+		CSVRelPath:  "./deployment_rework/project_deployment_metrics.csv",
+		IgnoreTypes: []interface{}{common.NoPKModel{}},
+	})
+}
@@ -0,0 +1,70 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	"github.com/apache/incubator-devlake/plugins/dora/impl"
+	"github.com/apache/incubator-devlake/plugins/dora/tasks"
+)
+
+func TestIncidentAttributionStrategiesDataFlow(t *testing.T) {
+	var plugin impl.Dora
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "dora", plugin)
+
+	taskData, err := plugin.PrepareTaskData(nil, map[string]interface{}{
+		"projectName": "project1",
+		"services": []interface{}{
+			map[string]interface{}{"name": "payments", "deploymentPattern": "^payments-", "components": []string{"payments"}},
+		},
+		"incidentAttributionStrategies": []string{
+			crossdomain.ATTRIBUTION_STRATEGY_REFERENCE,
+			crossdomain.ATTRIBUTION_STRATEGY_SERVICE,
+			crossdomain.ATTRIBUTION_STRATEGY_TIME_WINDOW,
+			crossdomain.ATTRIBUTION_STRATEGY_LATEST,
+		},
+		"incidentAttributionWindowHours": 12,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// import raw data table
+	dataflowTester.ImportCsvIntoTabler("./incident_attribution/project_mapping.csv", &crossdomain.ProjectMapping{})
+	dataflowTester.ImportCsvIntoTabler("./incident_attribution/cicd_scopes.csv", &devops.CicdScope{})
+	dataflowTester.ImportCsvIntoTabler("./incident_attribution/cicd_deployment_commits.csv", &devops.CicdDeploymentCommit{})
+	dataflowTester.ImportNullableCsvIntoTabler("./incident_attribution/commits_diffs.csv", &code.CommitsDiff{})
+	dataflowTester.ImportCsvIntoTabler("./incident_attribution/pull_requests.csv", &code.PullRequest{})
+	dataflowTester.ImportCsvIntoTabler("./incident_attribution/pull_request_issues.csv", &crossdomain.PullRequestIssue{})
+	dataflowTester.FlushTabler(&crossdomain.IssueCommit{})
+	dataflowTester.ImportCsvIntoTabler("./incident_attribution/incidents.csv", &ticket.Incident{})
+
+	// verify converter
+	dataflowTester.FlushTabler(&crossdomain.ProjectIncidentDeploymentRelationship{})
+	dataflowTester.Subtask(tasks.ConnectIncidentToDeploymentMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ProjectIncidentDeploymentRelationship{}, e2ehelper.TableOptions{
+		CSVRelPath:  "./incident_attribution/project_incident_deployment_relationships.csv",
+		IgnoreTypes: []interface{}{common.NoPKModel{}},
+	})
+}
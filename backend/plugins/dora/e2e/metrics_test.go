@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/plugins/dora/api"
+	"github.com/apache/incubator-devlake/plugins/dora/impl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMetrics(t *testing.T) {
+	var dora impl.Dora
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "dora", dora)
+	dataflowTester.ImportCsvIntoTabler("./metrics/cicd_deployment_commits.csv", &devops.CicdDeploymentCommit{})
+	dataflowTester.ImportCsvIntoTabler("./metrics/project_mapping.csv", &crossdomain.ProjectMapping{})
+	dataflowTester.ImportCsvIntoTabler("./metrics/pull_requests.csv", &code.PullRequest{})
+	dataflowTester.ImportCsvIntoTabler("./metrics/project_pr_metrics.csv", &crossdomain.ProjectPrMetric{})
+	dataflowTester.ImportCsvIntoTabler("./metrics/incidents.csv", &ticket.Incident{})
+	dataflowTester.ImportCsvIntoTabler("./metrics/project_incident_deployment_relationships.csv", &crossdomain.ProjectIncidentDeploymentRelationship{})
+	dataflowTester.ImportCsvIntoTabler("./metrics/project_deployment_metrics.csv", &crossdomain.ProjectDeploymentMetric{})
+	api.Init(contextimpl.NewDefaultBasicRes(dataflowTester.Cfg, dataflowTester.Log, dataflowTester.Dal))
+
+	output, err := api.GetMetrics(&plugin.ApiResourceInput{Query: url.Values{
+		"projectName": {"project1"},
+		"from":        {"2024-01-01T00:00:00Z"},
+		"to":          {"2024-03-01T00:00:00Z"},
+	}})
+	assert.Nil(t, err)
+	metrics := output.Body.(*api.DoraMetricsOutput)
+
+	overall := metrics.Overall
+	assert.Equal(t, 4, overall.DeploymentFrequency.SampleSize)
+	assert.Equal(t, 0.0, *overall.DeploymentFrequency.Value)
+	assert.Equal(t, api.LEVEL_MEDIUM, overall.DeploymentFrequency.Level)
+	assert.Equal(t, 2, overall.ChangeLeadTime.SampleSize)
+	assert.Equal(t, 600.0, *overall.ChangeLeadTime.Value)
+	assert.Equal(t, api.LEVEL_ELITE, overall.ChangeLeadTime.Level)
+	assert.Equal(t, 0.5, *overall.ChangeFailureRate.Value)
+	assert.Equal(t, api.LEVEL_LOW, overall.ChangeFailureRate.Level)
+	assert.Equal(t, 120.0, *overall.RecoveryTime.Value)
+	assert.Equal(t, api.LEVEL_HIGH, overall.RecoveryTime.Level)
+	assert.Equal(t, 0.25, *overall.DeploymentReworkRate.Value)
+	assert.Equal(t, 120.0, *overall.FailedDeploymentRecoveryTime.Value)
+	assert.Equal(t, 2, overall.FailedDeploymentRecoveryTime.SampleSize)
+
+	assert.Len(t, metrics.Periods, 2)
+	january, february := metrics.Periods[0], metrics.Periods[1]
+	assert.Equal(t, 3, january.DeploymentFrequency.SampleSize)
+	assert.Equal(t, api.LEVEL_HIGH, january.DeploymentFrequency.Level)
+	assert.Equal(t, 1, february.DeploymentFrequency.SampleSize)
+	assert.Equal(t, api.LEVEL_MEDIUM, february.DeploymentFrequency.Level)
+	assert.Equal(t, 1.0/3, *january.ChangeFailureRate.Value)
+	assert.Equal(t, 3000.0, *february.ChangeLeadTime.Value)
+	assert.Equal(t, 2880.0, *february.RecoveryTime.Value)
+	assert.Equal(t, 0.0, *february.DeploymentReworkRate.Value)
+	assert.Equal(t, 2880.0, *february.FailedDeploymentRecoveryTime.Value)
+
+	output, err = api.GetMetrics(&plugin.ApiResourceInput{Query: url.Values{
+		"projectName": {"project1"},
+		"from":        {"2024-01-01T00:00:00Z"},
+		"to":          {"2024-03-01T00:00:00Z"},
+		"report":      {"2021"},
+		"granularity": {"quarter"},
+	}})
+	assert.Nil(t, err)
+	metrics = output.Body.(*api.DoraMetricsOutput)
+	assert.Len(t, metrics.Periods, 1)
+	assert.Equal(t, 60.0, *metrics.Overall.RecoveryTime.Value)
+	assert.Equal(t, "Less than one day(high)", metrics.Overall.RecoveryTime.Benchmark)
+	assert.Equal(t, "Less than one week(high)", metrics.Overall.ChangeLeadTime.Benchmark)
+
+	_, err = api.GetMetrics(&plugin.ApiResourceInput{Query: url.Values{"projectName": {"project1"}, "report": {"2019"}}})
+	assert.NotNil(t, err)
+}
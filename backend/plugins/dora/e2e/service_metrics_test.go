@@ -0,0 +1,95 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	corePlugin "github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/e2ehelper"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/plugins/dora/api"
+	"github.com/apache/incubator-devlake/plugins/dora/impl"
+	"github.com/apache/incubator-devlake/plugins/dora/tasks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateServiceMetricsDataFlow(t *testing.T) {
+	var plugin impl.Dora
+	dataflowTester := e2ehelper.NewDataFlowTester(t, "dora", plugin)
+
+	taskData, err := plugin.PrepareTaskData(nil, map[string]interface{}{
+		"projectName": "project1",
+		"services": []interface{}{
+			map[string]interface{}{"name": "api", "pathPattern": "^api/"},
+			map[string]interface{}{"name": "web", "pathPattern": "^web/", "components": []string{"web-ui"}},
+			map[string]interface{}{"name": "payments", "cicdScopeIds": []string{"cicd2"}, "deploymentPattern": "(?i)payments"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// import raw data table
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/cicd_deployment_commits.csv", &devops.CicdDeploymentCommit{})
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/project_mapping.csv", &crossdomain.ProjectMapping{})
+	dataflowTester.ImportNullableCsvIntoTabler("./service_metrics/commits_diffs.csv", &code.CommitsDiff{})
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/commit_files.csv", &code.CommitFile{})
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/incidents.csv", &ticket.Incident{})
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/project_incident_deployment_relationships.csv", &crossdomain.ProjectIncidentDeploymentRelationship{})
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/project_pr_metrics.csv", &crossdomain.ProjectPrMetric{})
+	dataflowTester.ImportCsvIntoTabler("./service_metrics/pull_request_commits.csv", &code.PullRequestCommit{})
+
+	// verify converter
+	dataflowTester.FlushTabler(&crossdomain.ProjectServiceDeployment{})
+	dataflowTester.FlushTabler(&crossdomain.ProjectServicePrMetric{})
+	dataflowTester.FlushTabler(&crossdomain.ProjectServiceIncident{})
+	dataflowTester.Subtask(tasks.CalculateServiceMetricsMeta, taskData)
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ProjectServiceDeployment{}, e2ehelper.TableOptions{
+		CSVRelPath:  "./service_metrics/project_service_deployments.csv",
+		IgnoreTypes: []interface{}{common.NoPKModel{}},
+	})
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ProjectServicePrMetric{}, e2ehelper.TableOptions{
+		CSVRelPath:  "./service_metrics/project_service_pr_metrics.csv",
+		IgnoreTypes: []interface{}{common.NoPKModel{}},
+	})
+	dataflowTester.VerifyTableWithOptions(&crossdomain.ProjectServiceIncident{}, e2ehelper.TableOptions{
+		CSVRelPath:  "./service_metrics/project_service_incidents.csv",
+		IgnoreTypes: []interface{}{common.NoPKModel{}},
+	})
+
+	// verify the metrics of a service
+	api.Init(contextimpl.NewDefaultBasicRes(dataflowTester.Cfg, dataflowTester.Log, dataflowTester.Dal))
+	output, err := api.GetMetrics(&corePlugin.ApiResourceInput{Query: url.Values{
+		"projectName": {"project1"},
+		"serviceName": {"api"},
+		"from":        {"2024-01-01T00:00:00Z"},
+		"to":          {"2024-02-01T00:00:00Z"},
+	}})
+	assert.Nil(t, err)
+	overall := output.Body.(*api.DoraMetricsOutput).Overall
+	assert.Equal(t, 2, overall.DeploymentFrequency.SampleSize)
+	assert.Equal(t, 2, overall.ChangeLeadTime.SampleSize)
+	assert.Equal(t, 100.0, *overall.ChangeLeadTime.Value)
+	assert.Equal(t, 0.5, *overall.ChangeFailureRate.Value)
+}
@@ -19,11 +19,14 @@ package impl
 
 import (
 	"encoding/json"
+	"regexp"
 
+	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	coreModels "github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/dora/api"
 	"github.com/apache/incubator-devlake/plugins/dora/models/migrationscripts"
 	"github.com/apache/incubator-devlake/plugins/dora/tasks"
 )
@@ -31,15 +34,22 @@ import (
 // make sure interface is implemented
 var _ interface {
 	plugin.PluginMeta
+	plugin.PluginInit
 	plugin.PluginTask
 	plugin.PluginModel
 	plugin.PluginMetric
 	plugin.PluginMigration
+	plugin.PluginApi
 	plugin.MetricPluginBlueprintV200
 } = (*Dora)(nil)
 
 type Dora struct{}
 
+func (p Dora) Init(basicRes context.BasicRes) errors.Error {
+	api.Init(basicRes)
+	return nil
+}
+
 func (p Dora) Description() string {
 	return "collect some Dora data"
 }
@@ -96,6 +106,8 @@ func (p Dora) SubTaskMetas() []plugin.SubTaskMeta {
 		tasks.CalculateChangeLeadTimeMeta,
 		tasks.IssuesToIncidentsMeta,
 		tasks.ConnectIncidentToDeploymentMeta,
+		tasks.CalculateDeploymentReworkMeta,
+		tasks.CalculateServiceMetricsMeta,
 	}
 }
 
@@ -104,8 +116,15 @@ func (p Dora) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]int
 	if err != nil {
 		return nil, err
 	}
+	services, err := tasks.CompileServices(op.Services)
+	if err != nil {
+		return nil, err
+	}
 	return &tasks.DoraTaskData{
-		Options: op,
+		Options:        op,
+		Services:       services,
+		HotfixRegexp:   regexp.MustCompile(op.HotfixPattern),
+		RollbackRegexp: regexp.MustCompile(op.RollbackPattern),
 	}, nil
 }
 
@@ -118,6 +137,14 @@ func (p Dora) MigrationScripts() []plugin.MigrationScript {
 	return migrationscripts.All()
 }
 
+func (p Dora) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
+	return map[string]map[string]plugin.ApiResourceHandler{
+		"metrics": {
+			"GET": api.GetMetrics,
+		},
+	}
+}
+
 func (p Dora) MakeMetricPluginPipelinePlanV200(projectName string, options json.RawMessage) (coreModels.PipelinePlan, errors.Error) {
 	op := &tasks.DoraOptions{}
 	if options != nil && string(options) != "\"\"" {
@@ -127,6 +154,24 @@ func (p Dora) MakeMetricPluginPipelinePlanV200(projectName string, options json.
 		}
 	}
 
+	metricOptions := map[string]interface{}{
+		"projectName": projectName,
+	}
+	if op.HotfixPattern != "" {
+		metricOptions["hotfixPattern"] = op.HotfixPattern
+	}
+	if op.RollbackPattern != "" {
+		metricOptions["rollbackPattern"] = op.RollbackPattern
+	}
+	if len(op.Services) > 0 {
+		metricOptions["services"] = op.Services
+	}
+	if len(op.IncidentAttributionStrategies) > 0 {
+		metricOptions["incidentAttributionStrategies"] = op.IncidentAttributionStrategies
+	}
+	if op.IncidentAttributionWindowHours > 0 {
+		metricOptions["incidentAttributionWindowHours"] = op.IncidentAttributionWindowHours
+	}
 	plan := coreModels.PipelinePlan{
 		{
 			{
@@ -154,14 +199,14 @@ func (p Dora) MakeMetricPluginPipelinePlanV200(projectName string, options json.
 		},
 		{
 			{
-				Plugin: "dora",
-				Options: map[string]interface{}{
-					"projectName": projectName,
-				},
+				Plugin:  "dora",
+				Options: metricOptions,
 				Subtasks: []string{
 					"calculateChangeLeadTime",
 					tasks.IssuesToIncidentsMeta.Name,
 					"ConnectIncidentToDeployment",
+					tasks.CalculateDeploymentReworkMeta.Name,
+					tasks.CalculateServiceMetricsMeta.Name,
 				},
 			},
 		},
@@ -63,6 +63,8 @@ func TestMakeMetricPluginPipelinePlanV200(t *testing.T) {
 					"calculateChangeLeadTime",
 					tasks.IssuesToIncidentsMeta.Name,
 					"ConnectIncidentToDeployment",
+					tasks.CalculateDeploymentReworkMeta.Name,
+					tasks.CalculateServiceMetricsMeta.Name,
 				},
 				Options: map[string]interface{}{"projectName": projectName},
 			},
@@ -70,3 +72,17 @@ func TestMakeMetricPluginPipelinePlanV200(t *testing.T) {
 	}
 	assert.Equal(t, doraOutputPlan, plan)
 }
+
+func TestMakeMetricPluginPipelinePlanV200WithReworkPatterns(t *testing.T) {
+	var dora Dora
+	const projectName = "TestMakePlanV200-project"
+	optionJson, err := json.Marshal(map[string]interface{}{
+		"projectName":     projectName,
+		"rollbackPattern": "^rollback-",
+	})
+	assert.Nil(t, err)
+	plan, err := dora.MakeMetricPluginPipelinePlanV200(projectName, optionJson)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"projectName": projectName}, plan[0][0].Options)
+	assert.Equal(t, map[string]interface{}{"projectName": projectName, "rollbackPattern": "^rollback-"}, plan[2][0].Options)
+}
@@ -113,11 +113,26 @@ func CalculateChangeLeadTime(taskCtx plugin.SubTaskContext) errors.Error {
 			projectPrMetric.PrCreatedDate = &pr.CreatedDate
 			projectPrMetric.PrMergedDate = pr.MergedDate
 
-			// Get the deployment for the PR
-			deployment, err := getDeploymentCommit(pr.MergeCommitSha, data.Options.ProjectName, db)
+			// Get the first deployments to the testing, staging and production environments for the PR
+			deployments, err := getDeploymentCommits(pr.MergeCommitSha, data.Options.ProjectName, db)
 			if err != nil {
 				return nil, err
 			}
+			testingDeployment := deployments[devops.TESTING]
+			if testingDeployment != nil && testingDeployment.FinishedDate != nil {
+				projectPrMetric.PrTestingDeployTime = computeTimeSpan(pr.MergedDate, testingDeployment.FinishedDate)
+				projectPrMetric.TestingDeploymentCommitId = testingDeployment.Id
+				projectPrMetric.PrTestingDeployedDate = testingDeployment.FinishedDate
+			}
+			stagingDeployment := deployments[devops.STAGING]
+			if stagingDeployment != nil && stagingDeployment.FinishedDate != nil {
+				projectPrMetric.PrStagingDeployTime = computeTimeSpan(pr.MergedDate, stagingDeployment.FinishedDate)
+				projectPrMetric.StagingDeploymentCommitId = stagingDeployment.Id
+				projectPrMetric.PrStagingDeployedDate = stagingDeployment.FinishedDate
+			}
+
+			// Get the deployment for the PR
+			deployment := deployments[devops.PRODUCTION]
 
 			// Calculate PR deploy time
 			if deployment != nil && deployment.FinishedDate != nil {
@@ -206,11 +221,11 @@ func getFirstReview(prId string, prCreator string, db dal.Dal) (*code.PullReques
 	return review, nil
 }
 
-// getDeploymentCommit takes a merge commit SHA, a repository ID, a list of deployment pairs, and a database connection as input.
-// It returns the deployment pair related to the merge commit, or nil if not found.
-func getDeploymentCommit(mergeSha string, projectName string, db dal.Dal) (*devops.CicdDeploymentCommit, errors.Error) {
-	deploymentCommits := make([]*devops.CicdDeploymentCommit, 0, 1)
-	// do not use `.First` method since gorm would append ORDER BY ID to the query which leads to a error
+// getDeploymentCommits takes a merge commit SHA, a project name and a database connection as input.
+// It returns the first deployment commits deploying the merge commit to the testing, staging and production
+// environments by the environment, the environments without such deployment commit are left out.
+func getDeploymentCommits(mergeSha string, projectName string, db dal.Dal) (map[string]*devops.CicdDeploymentCommit, errors.Error) {
+	deploymentCommits := make([]*devops.CicdDeploymentCommit, 0, 3)
 	err := db.All(
 		&deploymentCommits,
 		dal.Select("dc.*"),
@@ -219,18 +234,20 @@ func getDeploymentCommit(mergeSha string, projectName string, db dal.Dal) (*devo
 		dal.Join("LEFT JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = dc.cicd_scope_id)"),
 		dal.Join("INNER JOIN commits_diffs cd ON (cd.new_commit_sha = dc.commit_sha AND cd.old_commit_sha = COALESCE (p.commit_sha, ''))"),
 		dal.Where("dc.prev_success_deployment_commit_id <> ''"),
-		dal.Where("dc.environment = 'PRODUCTION'"), // TODO: remove this when multi-environment is supported
+		dal.Where("dc.environment IN ?", []string{devops.TESTING, devops.STAGING, devops.PRODUCTION}),
 		dal.Where("pm.project_name = ? AND cd.commit_sha = ? AND dc.RESULT = ?", projectName, mergeSha, devops.RESULT_SUCCESS),
 		dal.Orderby("dc.started_date, dc.id ASC"),
-		dal.Limit(1),
 	)
 	if err != nil {
 		return nil, err
 	}
-	if len(deploymentCommits) == 0 {
-		return nil, nil
+	firstDeploymentCommits := make(map[string]*devops.CicdDeploymentCommit)
+	for _, deploymentCommit := range deploymentCommits {
+		if firstDeploymentCommits[deploymentCommit.Environment] == nil {
+			firstDeploymentCommits[deploymentCommit.Environment] = deploymentCommit
+		}
 	}
-	return deploymentCommits[0], nil
+	return firstDeploymentCommits, nil
 }
 
 func computeTimeSpan(start, end *time.Time) *int64 {
@@ -0,0 +1,321 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// CalculateDeploymentReworkMeta contains metadata for the CalculateDeploymentRework subtask.
+var CalculateDeploymentReworkMeta = plugin.SubTaskMeta{
+	Name:             "calculateDeploymentRework",
+	EntryPoint:       CalculateDeploymentRework,
+	EnabledByDefault: true,
+	Description:      "Identify rework deployments and calculate failed deployment recovery time",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CICD, plugin.DOMAIN_TYPE_CODE},
+}
+
+// the max number of commits to walk back from a failed deployment to find the commit a rollback deployed
+const rollbackAncestorDepth = 1000
+
+type reworkDeploymentCommit struct {
+	CicdDeploymentId string
+	CicdScopeId      string
+	Name             string
+	FinishedDate     *time.Time
+	CommitSha        string
+	RepoUrl          string
+}
+
+type reworkIncident struct {
+	DeploymentId   string
+	ResolutionDate *time.Time
+}
+
+type reworkDeployment struct {
+	metric *crossdomain.ProjectDeploymentMetric
+	// commits by repo url
+	commits   map[string]string
+	incidents []*reworkIncident
+}
+
+// CalculateDeploymentRework identifies the deployments rolling back or fixing forward the failed deployments, namely
+// the deployments causing incidents, and calculates when the failed deployments were recovered. A deployment after
+// a failed one in the same cicd scope and production environment is taken as a rework if
+//  1. its name matches the rollback or hotfix pattern, or
+//  2. it deploys a commit deployed before the failed deployment, found by the `commit_parents`
+//
+// A failed deployment is recovered by its first rework deployment, or by the resolution of its incidents otherwise.
+func CalculateDeploymentRework(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	logger := taskCtx.GetLogger()
+	data := taskCtx.GetData().(*DoraTaskData)
+	projectName := data.Options.ProjectName
+	// Clear previous results from the project
+	err := db.Exec("DELETE FROM project_deployment_metrics WHERE project_name = ?", projectName)
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting previous project_deployment_metrics")
+	}
+
+	var deploymentCommits []*reworkDeploymentCommit
+	err = db.All(
+		&deploymentCommits,
+		dal.Select("cdc.cicd_deployment_id, cdc.cicd_scope_id, cdc.name, cdc.finished_date, cdc.commit_sha, cdc.repo_url"),
+		dal.From("cicd_deployment_commits cdc"),
+		dal.Join("LEFT JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = cdc.cicd_scope_id)"),
+		dal.Where(
+			"pm.project_name = ? AND cdc.result = ? AND cdc.environment = ? AND cdc.finished_date IS NOT NULL",
+			projectName, devops.RESULT_SUCCESS, devops.PRODUCTION,
+		),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "error loading deployment commits")
+	}
+	var incidents []*reworkIncident
+	err = db.All(
+		&incidents,
+		dal.Select("pidr.deployment_id, i.resolution_date"),
+		dal.From("project_incident_deployment_relationships pidr"),
+		dal.Join("JOIN incidents i ON (i.id = pidr.id)"),
+		dal.Where("pidr.project_name = ?", projectName),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "error loading incidents of deployments")
+	}
+
+	params, e := json.Marshal(DoraApiParams{ProjectName: projectName})
+	if e != nil {
+		return errors.Convert(e)
+	}
+	deploymentsByScope := groupReworkDeployments(deploymentCommits, incidents, projectName, string(params))
+	ancestors := newCommitAncestors(db)
+	batch, err := api.NewBatchSave(taskCtx, reflect.TypeOf(&crossdomain.ProjectDeploymentMetric{}), 500)
+	if err != nil {
+		return err
+	}
+	for _, deployments := range deploymentsByScope {
+		if err = identifyReworkDeployments(deployments, data, ancestors); err != nil {
+			return err
+		}
+		for _, deployment := range deployments {
+			if err = batch.Add(deployment.metric); err != nil {
+				return err
+			}
+		}
+	}
+	logger.Info("calculated rework of the deployments in %d cicd scopes", len(deploymentsByScope))
+	return batch.Close()
+}
+
+// groupReworkDeployments merges the deployment commits into deployments finished by the last commit, and returns
+// them ordered by the finished date for each cicd scope
+func groupReworkDeployments(
+	deploymentCommits []*reworkDeploymentCommit,
+	incidents []*reworkIncident,
+	projectName string,
+	params string,
+) map[string][]*reworkDeployment {
+	deployments := make(map[string]*reworkDeployment)
+	for _, commit := range deploymentCommits {
+		deployment := deployments[commit.CicdDeploymentId]
+		if deployment == nil {
+			deployment = &reworkDeployment{
+				metric: &crossdomain.ProjectDeploymentMetric{
+					DomainEntity: domainlayer.DomainEntity{Id: commit.CicdDeploymentId},
+					ProjectName:  projectName,
+					CicdScopeId:  commit.CicdScopeId,
+				},
+				commits: make(map[string]string),
+			}
+			deployment.metric.RawDataTable = "cicd_deployment_commits"
+			deployment.metric.RawDataParams = params
+			deployments[commit.CicdDeploymentId] = deployment
+		}
+		if deployment.metric.Name == "" {
+			deployment.metric.Name = commit.Name
+		}
+		if deployment.metric.FinishedDate == nil || commit.FinishedDate.After(*deployment.metric.FinishedDate) {
+			deployment.metric.FinishedDate = commit.FinishedDate
+		}
+		deployment.commits[commit.RepoUrl] = commit.CommitSha
+	}
+	for _, incident := range incidents {
+		if deployment := deployments[incident.DeploymentId]; deployment != nil {
+			deployment.metric.IsFailed = true
+			deployment.incidents = append(deployment.incidents, incident)
+		}
+	}
+	byScope := make(map[string][]*reworkDeployment)
+	for _, deployment := range deployments {
+		byScope[deployment.metric.CicdScopeId] = append(byScope[deployment.metric.CicdScopeId], deployment)
+	}
+	for _, scopeDeployments := range byScope {
+		sort.Slice(scopeDeployments, func(i, j int) bool {
+			a, b := scopeDeployments[i].metric, scopeDeployments[j].metric
+			if a.FinishedDate.Equal(*b.FinishedDate) {
+				return a.Id < b.Id
+			}
+			return a.FinishedDate.Before(*b.FinishedDate)
+		})
+	}
+	return byScope
+}
+
+// identifyReworkDeployments marks the rework deployments and the recovery of the failed deployments of a cicd scope
+func identifyReworkDeployments(deployments []*reworkDeployment, data *DoraTaskData, ancestors *commitAncestors) errors.Error {
+	for i, failed := range deployments {
+		if !failed.metric.IsFailed {
+			continue
+		}
+		// incidents are open until the last of them is resolved
+		var resolvedDate *time.Time
+		for _, incident := range failed.incidents {
+			if incident.ResolutionDate == nil {
+				resolvedDate = nil
+				break
+			}
+			if resolvedDate == nil || incident.ResolutionDate.After(*resolvedDate) {
+				resolvedDate = incident.ResolutionDate
+			}
+		}
+		for j := i + 1; j < len(deployments); j++ {
+			candidate := deployments[j]
+			// the rework of the next failed deployment is its own business
+			if candidate.metric.IsFailed && j > i+1 {
+				break
+			}
+			open := resolvedDate == nil || !candidate.metric.FinishedDate.After(*resolvedDate)
+			if !open {
+				break
+			}
+			if candidate.metric.IsRework {
+				continue
+			}
+			reworkType, err := getReworkType(candidate, failed, deployments[:i], data, ancestors)
+			if err != nil {
+				return err
+			}
+			if reworkType == "" {
+				continue
+			}
+			candidate.metric.IsRework = true
+			candidate.metric.ReworkType = reworkType
+			candidate.metric.ReworkOfDeploymentId = failed.metric.Id
+			if failed.metric.RecoveredDate == nil {
+				failed.metric.RecoveredDate = candidate.metric.FinishedDate
+				failed.metric.RecoveredByDeploymentId = candidate.metric.Id
+			}
+		}
+		if failed.metric.RecoveredDate == nil {
+			failed.metric.RecoveredDate = resolvedDate
+		}
+		if failed.metric.RecoveredDate != nil {
+			failed.metric.RecoveryTime = computeTimeSpan(failed.metric.FinishedDate, failed.metric.RecoveredDate)
+		}
+	}
+	// unplanned deployments named after the patterns are rework even if the failure was not tracked
+	for _, deployment := range deployments {
+		if deployment.metric.IsRework {
+			continue
+		}
+		if data.RollbackRegexp.MatchString(deployment.metric.Name) {
+			deployment.metric.IsRework, deployment.metric.ReworkType = true, crossdomain.REWORK_TYPE_ROLLBACK
+		} else if data.HotfixRegexp.MatchString(deployment.metric.Name) {
+			deployment.metric.IsRework, deployment.metric.ReworkType = true, crossdomain.REWORK_TYPE_HOTFIX
+		}
+	}
+	return nil
+}
+
+func getReworkType(
+	candidate, failed *reworkDeployment,
+	previous []*reworkDeployment,
+	data *DoraTaskData,
+	ancestors *commitAncestors,
+) (string, errors.Error) {
+	if data.RollbackRegexp.MatchString(candidate.metric.Name) {
+		return crossdomain.REWORK_TYPE_ROLLBACK, nil
+	}
+	for repo, failedSha := range failed.commits {
+		sha := candidate.commits[repo]
+		if sha == "" || sha == failedSha {
+			continue
+		}
+		for _, deployment := range previous {
+			if deployment.commits[repo] == sha {
+				return crossdomain.REWORK_TYPE_ROLLBACK, nil
+			}
+		}
+		isAncestor, err := ancestors.isAncestor(sha, failedSha)
+		if err != nil {
+			return "", err
+		}
+		if isAncestor {
+			return crossdomain.REWORK_TYPE_ROLLBACK, nil
+		}
+	}
+	if data.HotfixRegexp.MatchString(candidate.metric.Name) {
+		return crossdomain.REWORK_TYPE_HOTFIX, nil
+	}
+	return "", nil
+}
+
+// commitAncestors walks the `commit_parents` backward, ancestors are cached by the descendant
+type commitAncestors struct {
+	db    dal.Dal
+	cache map[string]map[string]bool
+}
+
+func newCommitAncestors(db dal.Dal) *commitAncestors {
+	return &commitAncestors{db: db, cache: make(map[string]map[string]bool)}
+}
+
+func (c *commitAncestors) isAncestor(ancestor, descendant string) (bool, errors.Error) {
+	ancestors, ok := c.cache[descendant]
+	if !ok {
+		ancestors = make(map[string]bool)
+		frontier := []string{descendant}
+		for depth := 0; depth < rollbackAncestorDepth && len(frontier) > 0; depth++ {
+			var parents []string
+			err := c.db.Pluck("parent_commit_sha", &parents, dal.From("commit_parents"), dal.Where("commit_sha IN ?", frontier))
+			if err != nil {
+				return false, err
+			}
+			frontier = frontier[:0]
+			for _, parent := range parents {
+				if !ancestors[parent] {
+					ancestors[parent] = true
+					frontier = append(frontier, parent)
+				}
+			}
+		}
+		c.cache[descendant] = ancestors
+	}
+	return ancestors[ancestor], nil
+}
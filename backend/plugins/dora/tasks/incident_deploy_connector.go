@@ -42,9 +42,20 @@ var ConnectIncidentToDeploymentMeta = plugin.SubTaskMeta{
 type simpleCicdDeploymentCommit struct {
 	Id           string
 	FinishedDate *time.Time
+	CicdScopeId  string
+	Name         string
+	ScopeName    string
+}
+
+type referencedDeploymentCommit struct {
+	ReferencedCommitSha string
+	CicdDeploymentId    string
+	FinishedDate        *time.Time
 }
 
 // ConnectIncidentToDeployment will generate data to crossdomain.ProjectIncidentDeploymentRelationship.
+// The attribution strategies are tried in order for each incident, the first strategy finding a production
+// deployment finished before the incident is recorded along with the deployment.
 func ConnectIncidentToDeployment(taskCtx plugin.SubTaskContext) errors.Error {
 	db := taskCtx.GetDal()
 	data := taskCtx.GetData().(*DoraTaskData)
@@ -55,24 +66,39 @@ func ConnectIncidentToDeployment(taskCtx plugin.SubTaskContext) errors.Error {
 		return errors.Default.Wrap(err, "error deleting previous project_incident_deployment_relationships")
 	}
 	logger.Info("delete previous project_incident_deployment_relationships")
+	strategies := data.Options.IncidentAttributionStrategies
+	if len(strategies) == 0 {
+		strategies = DEFAULT_INCIDENT_ATTRIBUTION_STRATEGIES
+	}
+	windowHours := data.Options.IncidentAttributionWindowHours
+	if windowHours == 0 {
+		windowHours = DEFAULT_INCIDENT_ATTRIBUTION_WINDOW_HOURS
+	}
+	// the deployment commits of the project, the latest first
+	var deploymentCommits []*simpleCicdDeploymentCommit
+	err = db.All(
+		&deploymentCommits,
+		dal.Select("cdc.cicd_deployment_id AS id, cdc.finished_date, cdc.cicd_scope_id, cdc.name, COALESCE(cs.name, '') AS scope_name"),
+		dal.From("cicd_deployment_commits cdc"),
+		dal.Join("LEFT JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = cdc.cicd_scope_id)"),
+		dal.Join("LEFT JOIN cicd_scopes cs ON (cs.id = cdc.cicd_scope_id)"),
+		dal.Where(
+			"pm.project_name = ? AND cdc.result = ? AND cdc.environment = ? AND cdc.finished_date IS NOT NULL",
+			data.Options.ProjectName, devops.RESULT_SUCCESS, devops.PRODUCTION,
+		),
+		dal.Orderby("cdc.finished_date DESC"),
+	)
+	if err != nil {
+		return errors.Default.Wrap(err, "error loading deployment commits")
+	}
 	// select all issues belongs to the board
 	clauses := []dal.Clause{
+		dal.Select("i.*"),
 		dal.From(`incidents i`),
 		dal.Join(`left join project_mapping pm on pm.row_id = i.scope_id and pm.table = i.table`),
 		dal.Where("pm.project_name = ?", data.Options.ProjectName),
 	}
 
-	//count, err := db.Count(
-	//	dal.From(`incidents i`),
-	//	dal.Join(`left join project_mapping pm on pm.row_id = i.scope_id and pm.table = i.table`),
-	//	dal.Where("pm.project_name = ?", data.Options.ProjectName),
-	//)
-	//if err != nil {
-	//	logger.Error(err, "count incidents")
-	//} else {
-	//	logger.Info("incident count is %d", count)
-	//}
-
 	cursor, err := db.Cursor(clauses...)
 	if err != nil {
 		logger.Error(err, "db.cursor error")
@@ -99,39 +125,43 @@ func ConnectIncidentToDeployment(taskCtx plugin.SubTaskContext) errors.Error {
 				ProjectName: data.Options.ProjectName,
 			}
 			logger.Debug("get incident: %+v", incident.Id)
-			cicdDeploymentCommit := &devops.CicdDeploymentCommit{}
-			cicdDeploymentCommitClauses := []dal.Clause{
-				dal.Select("cicd_deployment_commits.cicd_deployment_id as id, cicd_deployment_commits.finished_date as finished_date"),
-				dal.From(cicdDeploymentCommit),
-				dal.Join("left join project_mapping pm on cicd_deployment_commits.cicd_scope_id = pm.row_id"),
-				dal.Where(
-					`cicd_deployment_commits.finished_date < ?
-					    and cicd_deployment_commits.result = ?
-						and cicd_deployment_commits.environment = ?
-						and pm.table = ?
-						and pm.project_name = ?`,
-					incident.CreatedDate, devops.RESULT_SUCCESS, devops.PRODUCTION, "cicd_scopes", data.Options.ProjectName,
-				),
-				dal.Orderby("finished_date DESC"),
-				dal.Limit(1),
+			if incident.CreatedDate == nil {
+				logger.Debug("created date is empty, incident will be ignored: %+v", incident.Id)
+				return nil, nil
 			}
-
-			scdc := &simpleCicdDeploymentCommit{}
-			err = db.All(scdc, cicdDeploymentCommitClauses...)
-			if err != nil {
-				if db.IsErrorNotFound(err) {
-					logger.Warn(err, "deployment commit not found")
-					return nil, nil
-				} else {
-					logger.Error(err, "get all deployment commits")
-					return nil, err
-				}
+			createdDate := *incident.CreatedDate
+			inService := func(deployment *simpleCicdDeploymentCommit) bool {
+				return matchIncidentService(incident.Component, deployment, data.Services)
 			}
-			if scdc.Id != "" {
-				projectIssueMetric.DeploymentId = scdc.Id
-				return []interface{}{projectIssueMetric}, nil
+			for _, strategy := range strategies {
+				deploymentId := ""
+				switch strategy {
+				case crossdomain.ATTRIBUTION_STRATEGY_REFERENCE:
+					deploymentId, err = getReferencedDeploymentId(incident, data.Options.ProjectName, db)
+					if err != nil {
+						return nil, err
+					}
+				case crossdomain.ATTRIBUTION_STRATEGY_SERVICE:
+					if incident.Component != "" {
+						deploymentId = getLatestDeploymentId(deploymentCommits, createdDate, nil, inService)
+					}
+				case crossdomain.ATTRIBUTION_STRATEGY_TIME_WINDOW:
+					since := createdDate.Add(-time.Duration(windowHours) * time.Hour)
+					if incident.Component != "" && getLatestDeploymentId(deploymentCommits, createdDate, nil, inService) != "" {
+						deploymentId = getLatestDeploymentId(deploymentCommits, createdDate, &since, inService)
+					} else {
+						deploymentId = getLatestDeploymentId(deploymentCommits, createdDate, &since, nil)
+					}
+				case crossdomain.ATTRIBUTION_STRATEGY_LATEST:
+					deploymentId = getLatestDeploymentId(deploymentCommits, createdDate, nil, nil)
+				}
+				if deploymentId != "" {
+					projectIssueMetric.DeploymentId = deploymentId
+					projectIssueMetric.AttributionStrategy = strategy
+					return []interface{}{projectIssueMetric}, nil
+				}
 			}
-			logger.Debug("scdc.id is empty, incident will be ignored: %+v", incident.Id)
+			logger.Debug("no deployment found, incident will be ignored: %+v", incident.Id)
 			return nil, nil
 		},
 	})
@@ -141,3 +171,106 @@ func ConnectIncidentToDeployment(taskCtx plugin.SubTaskContext) errors.Error {
 
 	return enricher.Execute()
 }
+
+// getLatestDeploymentId returns the deployment of the latest deployment commit finished before the incident
+// and since the given time, accepted by the filter
+func getLatestDeploymentId(
+	deploymentCommits []*simpleCicdDeploymentCommit,
+	createdDate time.Time,
+	since *time.Time,
+	filter func(*simpleCicdDeploymentCommit) bool,
+) string {
+	for _, deploymentCommit := range deploymentCommits {
+		if !deploymentCommit.FinishedDate.Before(createdDate) {
+			continue
+		}
+		if since != nil && deploymentCommit.FinishedDate.Before(*since) {
+			return ""
+		}
+		if filter == nil || filter(deploymentCommit) {
+			return deploymentCommit.Id
+		}
+	}
+	return ""
+}
+
+// matchIncidentService tells whether the deployment belongs to the service of the incident component, that is
+// the cicd scope is named after the component, or the deployment matches the cicd scopes or the deployment pattern
+// of a service claiming the component
+func matchIncidentService(component string, deployment *simpleCicdDeploymentCommit, services []*ServiceRule) bool {
+	if component == "" {
+		return false
+	}
+	if deployment.ScopeName == component {
+		return true
+	}
+	for _, service := range services {
+		if !service.Components[component] {
+			continue
+		}
+		if service.CicdScopeIds[deployment.CicdScopeId] ||
+			(service.DeploymentRegexp != nil && service.DeploymentRegexp.MatchString(deployment.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getReferencedDeploymentId returns the latest of the first production deployments of the pull requests and the
+// commits referenced by the incident, deployed before the incident was created
+func getReferencedDeploymentId(incident *ticket.Incident, projectName string, db dal.Dal) (string, errors.Error) {
+	var commitShas []string
+	err := db.Pluck(
+		"pr.merge_commit_sha", &commitShas,
+		dal.From("pull_request_issues pri"),
+		dal.Join("JOIN pull_requests pr ON (pr.id = pri.pull_request_id)"),
+		dal.Where("pri.issue_id = ? AND pr.merge_commit_sha <> ''", incident.Id),
+	)
+	if err != nil {
+		return "", errors.Default.Wrap(err, "error loading pull requests referenced by incident")
+	}
+	var issueCommitShas []string
+	err = db.Pluck("commit_sha", &issueCommitShas, dal.From("issue_commits"), dal.Where("issue_id = ?", incident.Id))
+	if err != nil {
+		return "", errors.Default.Wrap(err, "error loading commits referenced by incident")
+	}
+	commitShas = append(commitShas, issueCommitShas...)
+	if len(commitShas) == 0 {
+		return "", nil
+	}
+	// the production deployment commits deploying any of the commits, the first deployment of each commit first
+	var deploymentCommits []*referencedDeploymentCommit
+	err = db.All(
+		&deploymentCommits,
+		dal.Select("cd.commit_sha AS referenced_commit_sha, dc.cicd_deployment_id, dc.finished_date"),
+		dal.From("cicd_deployment_commits dc"),
+		dal.Join("LEFT JOIN cicd_deployment_commits p ON (dc.prev_success_deployment_commit_id = p.id)"),
+		dal.Join("LEFT JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = dc.cicd_scope_id)"),
+		dal.Join("INNER JOIN commits_diffs cd ON (cd.new_commit_sha = dc.commit_sha AND cd.old_commit_sha = COALESCE (p.commit_sha, ''))"),
+		dal.Where("dc.prev_success_deployment_commit_id <> ''"),
+		dal.Where("dc.environment = ?", devops.PRODUCTION),
+		dal.Where("pm.project_name = ? AND cd.commit_sha IN ? AND dc.RESULT = ?", projectName, commitShas, devops.RESULT_SUCCESS),
+		dal.Orderby("dc.started_date, dc.id ASC"),
+	)
+	if err != nil {
+		return "", errors.Default.Wrap(err, "error loading deployments of commits referenced by incident")
+	}
+	var latest *referencedDeploymentCommit
+	firstDeployed := make(map[string]bool)
+	for _, deploymentCommit := range deploymentCommits {
+		if firstDeployed[deploymentCommit.ReferencedCommitSha] {
+			continue
+		}
+		firstDeployed[deploymentCommit.ReferencedCommitSha] = true
+		if deploymentCommit.FinishedDate == nil || !deploymentCommit.FinishedDate.Before(*incident.CreatedDate) {
+			continue
+		}
+		if latest == nil || deploymentCommit.FinishedDate.After(*latest.FinishedDate) {
+			latest = deploymentCommit
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	return latest.CicdDeploymentId, nil
+}
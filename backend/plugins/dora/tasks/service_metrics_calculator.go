@@ -0,0 +1,434 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// CalculateServiceMetricsMeta contains metadata for the CalculateServiceMetrics subtask.
+var CalculateServiceMetricsMeta = plugin.SubTaskMeta{
+	Name:             "calculateServiceMetrics",
+	EntryPoint:       CalculateServiceMetrics,
+	EnabledByDefault: true,
+	Description:      "Group the deployments, pull requests and incidents of the project by the services",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CICD, plugin.DOMAIN_TYPE_CODE, plugin.DOMAIN_TYPE_TICKET},
+}
+
+type serviceDeploymentCommit struct {
+	Id               string
+	CicdDeploymentId string
+	CicdScopeId      string
+	Name             string
+	FinishedDate     *time.Time
+}
+
+type serviceDeployment struct {
+	*crossdomain.ProjectServiceDeployment
+	services map[string]bool
+}
+
+type servicePath struct {
+	OwnerId  string
+	FilePath string
+}
+
+type servicePrMetric struct {
+	Id                 string
+	DeploymentCommitId string
+	CicdDeploymentId   string
+	PrCycleTime        *int64
+	PrDeployedDate     *time.Time
+}
+
+type serviceIncident struct {
+	Id           string
+	Component    string
+	CreatedDate  *time.Time
+	DeploymentId string
+}
+
+// CalculateServiceMetrics assigns the production deployments, the pull requests with change lead time and the
+// incidents of the project to the services of the dora options, so the metrics could be calculated by service
+func CalculateServiceMetrics(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	logger := taskCtx.GetLogger()
+	data := taskCtx.GetData().(*DoraTaskData)
+	projectName := data.Options.ProjectName
+	// Clear previous results from the project
+	for _, table := range []dal.Tabler{
+		&crossdomain.ProjectServiceDeployment{},
+		&crossdomain.ProjectServicePrMetric{},
+		&crossdomain.ProjectServiceIncident{},
+	} {
+		err := db.Delete(table, dal.Where("project_name = ?", projectName))
+		if err != nil {
+			return errors.Default.Wrap(err, "error deleting previous "+table.TableName())
+		}
+	}
+	if len(data.Services) == 0 {
+		logger.Info("no services defined, skip")
+		return nil
+	}
+	params, e := json.Marshal(DoraApiParams{ProjectName: projectName})
+	if e != nil {
+		return errors.Convert(e)
+	}
+
+	deployments, err := assignServiceDeployments(db, data.Services, projectName, string(params))
+	if err != nil {
+		return err
+	}
+	incidents, err := assignServiceIncidents(db, data.Services, deployments, projectName, string(params))
+	if err != nil {
+		return err
+	}
+	prMetrics, err := assignServicePrMetrics(db, data.Services, deployments, projectName, string(params))
+	if err != nil {
+		return err
+	}
+
+	if err = saveServiceRows(taskCtx, incidents); err != nil {
+		return err
+	}
+	if err = saveServiceRows(taskCtx, prMetrics); err != nil {
+		return err
+	}
+	// a deployment fails a service only if it causes incidents of the service
+	failed := make(map[[2]string]bool)
+	for _, incident := range incidents {
+		failed[[2]string{incident.ServiceName, incident.DeploymentId}] = true
+	}
+	var serviceDeployments []*crossdomain.ProjectServiceDeployment
+	for _, deployment := range deployments {
+		for service := range deployment.services {
+			row := *deployment.ProjectServiceDeployment
+			row.ServiceName = service
+			row.IsFailed = failed[[2]string{service, row.Id}]
+			serviceDeployments = append(serviceDeployments, &row)
+		}
+	}
+	logger.Info(
+		"assigned %d deployments, %d pull requests and %d incidents to %d services",
+		len(serviceDeployments), len(prMetrics), len(incidents), len(data.Services),
+	)
+	return saveServiceRows(taskCtx, serviceDeployments)
+}
+
+// assignServiceDeployments matches the production deployments of the project against the cicd scopes, the names
+// and the files changed since the previous deployment
+func assignServiceDeployments(db dal.Dal, services []*ServiceRule, projectName, params string) (map[string]*serviceDeployment, errors.Error) {
+	var deploymentCommits []*serviceDeploymentCommit
+	err := db.All(
+		&deploymentCommits,
+		dal.Select("cdc.id, cdc.cicd_deployment_id, cdc.cicd_scope_id, cdc.name, cdc.finished_date"),
+		dal.From("cicd_deployment_commits cdc"),
+		dal.Join("LEFT JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = cdc.cicd_scope_id)"),
+		dal.Where(
+			"pm.project_name = ? AND cdc.result = ? AND cdc.environment = ? AND cdc.finished_date IS NOT NULL",
+			projectName, devops.RESULT_SUCCESS, devops.PRODUCTION,
+		),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error loading deployment commits")
+	}
+	var deployedPaths map[string][]string
+	if hasPathRule(services) {
+		deployedPaths, err = loadDeployedPaths(db, projectName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	deployments := make(map[string]*serviceDeployment)
+	for _, commit := range deploymentCommits {
+		deployment := deployments[commit.CicdDeploymentId]
+		if deployment == nil {
+			deployment = &serviceDeployment{
+				ProjectServiceDeployment: &crossdomain.ProjectServiceDeployment{
+					DomainEntity: domainlayer.DomainEntity{Id: commit.CicdDeploymentId},
+					ProjectName:  projectName,
+					CicdScopeId:  commit.CicdScopeId,
+				},
+				services: make(map[string]bool),
+			}
+			deployment.RawDataTable = "cicd_deployment_commits"
+			deployment.RawDataParams = params
+			deployments[commit.CicdDeploymentId] = deployment
+		}
+		if deployment.Name == "" {
+			deployment.Name = commit.Name
+		}
+		if deployment.FinishedDate == nil || commit.FinishedDate.After(*deployment.FinishedDate) {
+			deployment.FinishedDate = commit.FinishedDate
+		}
+		for _, service := range services {
+			if deployment.services[service.Name] {
+				continue
+			}
+			matched := service.CicdScopeIds[commit.CicdScopeId] ||
+				(service.DeploymentRegexp != nil && service.DeploymentRegexp.MatchString(commit.Name))
+			if !matched && service.PathRegexp != nil {
+				matched = matchAnyPath(service.PathRegexp, deployedPaths[commit.Id])
+			}
+			if matched {
+				deployment.services[service.Name] = true
+			}
+		}
+	}
+	return deployments, nil
+}
+
+// assignServiceIncidents matches the incidents of the project against the components, the incidents of the
+// unclaimed components go with the deployments causing them. An incident claimed by a component is attributed to
+// its deployment only if the deployment deployed the service, or to the latest deployment of the service before it
+func assignServiceIncidents(
+	db dal.Dal,
+	services []*ServiceRule,
+	deployments map[string]*serviceDeployment,
+	projectName, params string,
+) ([]*crossdomain.ProjectServiceIncident, errors.Error) {
+	var incidents []*serviceIncident
+	err := db.All(
+		&incidents,
+		dal.Select("DISTINCT i.id, i.component, i.created_date, COALESCE(pidr.deployment_id, '') AS deployment_id"),
+		dal.From("incidents i"),
+		dal.Join("JOIN project_mapping pm ON (pm.row_id = i.scope_id AND pm.table = i.table)"),
+		dal.Join("LEFT JOIN project_incident_deployment_relationships pidr ON (pidr.id = i.id AND pidr.project_name = pm.project_name)"),
+		dal.Where("pm.project_name = ?", projectName),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error loading incidents")
+	}
+	var rows []*crossdomain.ProjectServiceIncident
+	for _, incident := range incidents {
+		claimed := false
+		for _, service := range services {
+			if !service.Components[incident.Component] {
+				continue
+			}
+			claimed = true
+			deploymentId := incident.DeploymentId
+			if deployment := deployments[deploymentId]; deployment == nil || !deployment.services[service.Name] {
+				deploymentId = latestServiceDeploymentId(deployments, service.Name, incident.CreatedDate)
+			}
+			rows = append(rows, newServiceIncident(incident, service.Name, deploymentId, projectName, params))
+		}
+		if claimed {
+			continue
+		}
+		if deployment := deployments[incident.DeploymentId]; deployment != nil {
+			for _, service := range services {
+				if deployment.services[service.Name] {
+					rows = append(rows, newServiceIncident(incident, service.Name, incident.DeploymentId, projectName, params))
+				}
+			}
+		}
+	}
+	return rows, nil
+}
+
+// latestServiceDeploymentId returns the id of the latest deployment of the service finished before the date
+func latestServiceDeploymentId(deployments map[string]*serviceDeployment, serviceName string, before *time.Time) string {
+	if before == nil {
+		return ""
+	}
+	var latest *serviceDeployment
+	for _, deployment := range deployments {
+		if !deployment.services[serviceName] || deployment.FinishedDate.After(*before) {
+			continue
+		}
+		if latest == nil || deployment.FinishedDate.After(*latest.FinishedDate) ||
+			(deployment.FinishedDate.Equal(*latest.FinishedDate) && deployment.Id > latest.Id) {
+			latest = deployment
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Id
+}
+
+func newServiceIncident(incident *serviceIncident, serviceName, deploymentId, projectName, params string) *crossdomain.ProjectServiceIncident {
+	row := &crossdomain.ProjectServiceIncident{
+		DomainEntity: domainlayer.DomainEntity{Id: incident.Id},
+		ProjectName:  projectName,
+		ServiceName:  serviceName,
+		DeploymentId: deploymentId,
+	}
+	row.RawDataTable = "incidents"
+	row.RawDataParams = params
+	return row
+}
+
+// assignServicePrMetrics matches the pull requests with change lead time against the paths of their commits, or
+// goes with the deployments for the services without path pattern
+func assignServicePrMetrics(
+	db dal.Dal,
+	services []*ServiceRule,
+	deployments map[string]*serviceDeployment,
+	projectName, params string,
+) ([]*crossdomain.ProjectServicePrMetric, errors.Error) {
+	var prMetrics []*servicePrMetric
+	err := db.All(
+		&prMetrics,
+		dal.Select("ppm.id, ppm.deployment_commit_id, COALESCE(cdc.cicd_deployment_id, '') AS cicd_deployment_id, ppm.pr_cycle_time, ppm.pr_deployed_date"),
+		dal.From("project_pr_metrics ppm"),
+		dal.Join("LEFT JOIN cicd_deployment_commits cdc ON (cdc.id = ppm.deployment_commit_id)"),
+		dal.Where("ppm.project_name = ?", projectName),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error loading project_pr_metrics")
+	}
+	var prPaths map[string][]string
+	if hasPathRule(services) {
+		prPaths, err = loadServicePaths(
+			db,
+			dal.Select("prc.pull_request_id AS owner_id, cf.file_path"),
+			dal.From("project_pr_metrics ppm"),
+			dal.Join("JOIN pull_request_commits prc ON (prc.pull_request_id = ppm.id)"),
+			dal.Join("JOIN commit_files cf ON (cf.commit_sha = prc.commit_sha)"),
+			dal.Where("ppm.project_name = ?", projectName),
+		)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, "error loading files of pull requests")
+		}
+	}
+	var rows []*crossdomain.ProjectServicePrMetric
+	for _, prMetric := range prMetrics {
+		deployment := deployments[prMetric.CicdDeploymentId]
+		for _, service := range services {
+			var matched bool
+			if service.PathRegexp != nil {
+				matched = matchAnyPath(service.PathRegexp, prPaths[prMetric.Id])
+			} else {
+				matched = deployment != nil && deployment.services[service.Name]
+			}
+			if !matched {
+				continue
+			}
+			row := &crossdomain.ProjectServicePrMetric{
+				DomainEntity:       domainlayer.DomainEntity{Id: prMetric.Id},
+				ProjectName:        projectName,
+				ServiceName:        service.Name,
+				DeploymentCommitId: prMetric.DeploymentCommitId,
+				PrCycleTime:        prMetric.PrCycleTime,
+				PrDeployedDate:     prMetric.PrDeployedDate,
+			}
+			row.RawDataTable = "project_pr_metrics"
+			row.RawDataParams = params
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// loadDeployedPaths returns the files changed by the commits deployed by the production deployment commits of the
+// project, keyed by the deployment commit id. The first deployment of a scope has no previous commit, its diff is
+// stored against an empty commit sha if calculated at all, and the files of the deployed commit itself are taken
+// otherwise
+func loadDeployedPaths(db dal.Dal, projectName string) (map[string][]string, errors.Error) {
+	deploymentClauses := []dal.Clause{
+		dal.From("cicd_deployment_commits cdc"),
+		dal.Join("LEFT JOIN cicd_deployment_commits p ON (p.id = cdc.prev_success_deployment_commit_id)"),
+		dal.Join("LEFT JOIN project_mapping pm ON (pm.table = 'cicd_scopes' AND pm.row_id = cdc.cicd_scope_id)"),
+		dal.Where(
+			"pm.project_name = ? AND cdc.result = ? AND cdc.environment = ? AND cdc.finished_date IS NOT NULL",
+			projectName, devops.RESULT_SUCCESS, devops.PRODUCTION,
+		),
+	}
+	paths, err := loadServicePaths(db, append(
+		deploymentClauses,
+		dal.Select("cdc.id AS owner_id, cf.file_path"),
+		dal.Join("JOIN commits_diffs cd ON (cd.new_commit_sha = cdc.commit_sha AND COALESCE(cd.old_commit_sha, '') = COALESCE(p.commit_sha, ''))"),
+		dal.Join("JOIN commit_files cf ON (cf.commit_sha = cd.commit_sha)"),
+	)...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error loading files of deployment commits")
+	}
+	firstPaths, err := loadServicePaths(db, append(
+		deploymentClauses,
+		dal.Select("cdc.id AS owner_id, cf.file_path"),
+		dal.Join("JOIN commit_files cf ON (cf.commit_sha = cdc.commit_sha)"),
+		dal.Where("COALESCE(p.commit_sha, '') = ''"),
+	)...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error loading files of first deployment commits")
+	}
+	for id, files := range firstPaths {
+		if len(paths[id]) == 0 {
+			paths[id] = files
+		}
+	}
+	return paths, nil
+}
+
+// loadServicePaths returns the file paths selected by the clauses, keyed by the owner id
+func loadServicePaths(db dal.Dal, clauses ...dal.Clause) (map[string][]string, errors.Error) {
+	var rows []*servicePath
+	err := db.All(&rows, clauses...)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string][]string)
+	for _, row := range rows {
+		paths[row.OwnerId] = append(paths[row.OwnerId], row.FilePath)
+	}
+	return paths, nil
+}
+
+func hasPathRule(services []*ServiceRule) bool {
+	for _, service := range services {
+		if service.PathRegexp != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAnyPath(pattern *regexp.Regexp, paths []string) bool {
+	for _, path := range paths {
+		if pattern.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func saveServiceRows[T any](taskCtx plugin.SubTaskContext, rows []*T) errors.Error {
+	batch, err := api.NewBatchSave(taskCtx, reflect.TypeOf(new(T)), 500)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err = batch.Add(row); err != nil {
+			return err
+		}
+	}
+	return batch.Close()
+}
@@ -18,7 +18,11 @@ limitations under the License.
 package tasks
 
 import (
+	"fmt"
+	"regexp"
+
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 )
 
@@ -31,11 +35,59 @@ type DoraOptions struct {
 	Since       string
 	ProjectName string  `json:"projectName"`
 	ScopeId     *string `json:"scopeId,omitempty"`
+	// HotfixPattern and RollbackPattern match the names of the deployments fixing or rolling back failed deployments
+	HotfixPattern   string `json:"hotfixPattern,omitempty" mapstructure:"hotfixPattern,omitempty"`
+	RollbackPattern string `json:"rollbackPattern,omitempty" mapstructure:"rollbackPattern,omitempty"`
+	// Services group the deployments, pull requests and incidents of the project by service
+	Services []*DoraService `json:"services,omitempty" mapstructure:"services,omitempty"`
+	// IncidentAttributionStrategies are tried in order to find the deployment causing an incident, the first
+	// strategy finding a deployment wins
+	IncidentAttributionStrategies []string `json:"incidentAttributionStrategies,omitempty" mapstructure:"incidentAttributionStrategies,omitempty"`
+	// IncidentAttributionWindowHours is the time window of the TIME_WINDOW strategy
+	IncidentAttributionWindowHours int `json:"incidentAttributionWindowHours,omitempty" mapstructure:"incidentAttributionWindowHours,omitempty"`
+}
+
+// DoraService tells which deployments, pull requests and incidents belong to a service, a deployment belongs to
+// the service if it matches any of the rules. A pull request belongs to the service if its files match the
+// PathPattern, or if it was deployed by a deployment of the service when there is no PathPattern. An incident
+// belongs to the service if its component is one of the Components, or if it was caused by a deployment of
+// the service when its component is not claimed by any service.
+type DoraService struct {
+	Name              string   `json:"name" mapstructure:"name"`
+	CicdScopeIds      []string `json:"cicdScopeIds,omitempty" mapstructure:"cicdScopeIds,omitempty"`
+	DeploymentPattern string   `json:"deploymentPattern,omitempty" mapstructure:"deploymentPattern,omitempty"`
+	PathPattern       string   `json:"pathPattern,omitempty" mapstructure:"pathPattern,omitempty"`
+	Components        []string `json:"components,omitempty" mapstructure:"components,omitempty"`
+}
+
+// ServiceRule is the compiled DoraService
+type ServiceRule struct {
+	Name             string
+	CicdScopeIds     map[string]bool
+	DeploymentRegexp *regexp.Regexp
+	PathRegexp       *regexp.Regexp
+	Components       map[string]bool
 }
 
 type DoraTaskData struct {
 	Options                         *DoraOptions
 	DisableIssueToIncidentGenerator bool
+	HotfixRegexp                    *regexp.Regexp
+	RollbackRegexp                  *regexp.Regexp
+	Services                        []*ServiceRule
+}
+
+const (
+	DEFAULT_HOTFIX_PATTERN   = "(?i)hotfix"
+	DEFAULT_ROLLBACK_PATTERN = "(?i)rollback|revert"
+
+	DEFAULT_INCIDENT_ATTRIBUTION_WINDOW_HOURS = 24
+)
+
+// DEFAULT_INCIDENT_ATTRIBUTION_STRATEGIES attributes an incident to the latest deployment of the project before it,
+// as it was before the strategies were introduced
+var DEFAULT_INCIDENT_ATTRIBUTION_STRATEGIES = []string{
+	crossdomain.ATTRIBUTION_STRATEGY_LATEST,
 }
 
 func DecodeAndValidateTaskOptions(options map[string]interface{}) (*DoraOptions, errors.Error) {
@@ -44,6 +96,79 @@ func DecodeAndValidateTaskOptions(options map[string]interface{}) (*DoraOptions,
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "error decoding DORA task options")
 	}
+	if op.HotfixPattern == "" {
+		op.HotfixPattern = DEFAULT_HOTFIX_PATTERN
+	}
+	if op.RollbackPattern == "" {
+		op.RollbackPattern = DEFAULT_ROLLBACK_PATTERN
+	}
+	if _, e := regexp.Compile(op.HotfixPattern); e != nil {
+		return nil, errors.BadInput.Wrap(e, "invalid hotfixPattern")
+	}
+	if _, e := regexp.Compile(op.RollbackPattern); e != nil {
+		return nil, errors.BadInput.Wrap(e, "invalid rollbackPattern")
+	}
+	if _, err = CompileServices(op.Services); err != nil {
+		return nil, err
+	}
+	if len(op.IncidentAttributionStrategies) == 0 {
+		op.IncidentAttributionStrategies = DEFAULT_INCIDENT_ATTRIBUTION_STRATEGIES
+	}
+	for _, strategy := range op.IncidentAttributionStrategies {
+		switch strategy {
+		case crossdomain.ATTRIBUTION_STRATEGY_REFERENCE,
+			crossdomain.ATTRIBUTION_STRATEGY_SERVICE,
+			crossdomain.ATTRIBUTION_STRATEGY_TIME_WINDOW,
+			crossdomain.ATTRIBUTION_STRATEGY_LATEST:
+		default:
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown incident attribution strategy %s", strategy))
+		}
+	}
+	if op.IncidentAttributionWindowHours < 0 {
+		return nil, errors.BadInput.New("incidentAttributionWindowHours must not be negative")
+	}
+	if op.IncidentAttributionWindowHours == 0 {
+		op.IncidentAttributionWindowHours = DEFAULT_INCIDENT_ATTRIBUTION_WINDOW_HOURS
+	}
 
 	return &op, nil
 }
+
+// CompileServices validates the services and compiles their patterns
+func CompileServices(services []*DoraService) ([]*ServiceRule, errors.Error) {
+	var rules []*ServiceRule
+	names := make(map[string]bool)
+	for _, service := range services {
+		if service.Name == "" {
+			return nil, errors.BadInput.New("name of the service is required")
+		}
+		if names[service.Name] {
+			return nil, errors.BadInput.New(fmt.Sprintf("duplicated service %s", service.Name))
+		}
+		names[service.Name] = true
+		rule := &ServiceRule{
+			Name:         service.Name,
+			CicdScopeIds: make(map[string]bool),
+			Components:   make(map[string]bool),
+		}
+		for _, scopeId := range service.CicdScopeIds {
+			rule.CicdScopeIds[scopeId] = true
+		}
+		for _, component := range service.Components {
+			rule.Components[component] = true
+		}
+		var e error
+		if service.DeploymentPattern != "" {
+			if rule.DeploymentRegexp, e = regexp.Compile(service.DeploymentPattern); e != nil {
+				return nil, errors.BadInput.Wrap(e, fmt.Sprintf("invalid deploymentPattern of the service %s", service.Name))
+			}
+		}
+		if service.PathPattern != "" {
+			if rule.PathRegexp, e = regexp.Compile(service.PathPattern); e != nil {
+				return nil, errors.BadInput.Wrap(e, fmt.Sprintf("invalid pathPattern of the service %s", service.Name))
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
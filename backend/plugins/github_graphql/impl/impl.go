@@ -206,6 +206,12 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 		}
 	}
 
+	if cassette := helper.CassetteFromContext(taskCtx.GetContext()); cassette != nil {
+		// oauth2 sends requests with the http.Client carried by the context
+		baseClient, _ := oauthContext.Value(oauth2.HTTPClient).(*http.Client)
+		oauthContext = context.WithValue(oauthContext, oauth2.HTTPClient, cassette.WrapHttpClient(baseClient))
+	}
+
 	httpClient := oauth2.NewClient(oauthContext, src)
 	endpoint, err := errors.Convert01(url.Parse(connection.Endpoint))
 	if err != nil {
@@ -0,0 +1,74 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package impl
+
+import (
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/domainlayerhelper"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/snapshot/tasks"
+)
+
+type Snapshot struct{}
+
+// make sure interface is implemented
+var _ interface {
+	plugin.PluginMeta
+	plugin.PluginTask
+	plugin.PluginModel
+} = (*Snapshot)(nil)
+
+func (p Snapshot) SubTaskMetas() []plugin.SubTaskMeta {
+	return []plugin.SubTaskMeta{
+		tasks.ExportDomainSnapshotMeta,
+	}
+}
+
+func (p Snapshot) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]interface{}) (interface{}, errors.Error) {
+	var op tasks.SnapshotOptions
+	err := helper.Decode(options, &op, nil)
+	if err != nil {
+		return nil, err
+	}
+	if op.ProjectName == "" {
+		return nil, errors.BadInput.New("projectName is required")
+	}
+	op.Format, err = domainlayerhelper.ValidateSnapshotFormat(op.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &tasks.SnapshotTaskData{Options: &op}, nil
+}
+
+func (p Snapshot) GetTablesInfo() []dal.Tabler {
+	return []dal.Tabler{}
+}
+
+func (p Snapshot) Description() string {
+	return "Export the domain layer tables of a project to parquet or csv files"
+}
+
+func (p Snapshot) Name() string {
+	return "snapshot"
+}
+
+func (p Snapshot) RootPkgPath() string {
+	return "github.com/apache/incubator-devlake/plugins/snapshot"
+}
@@ -0,0 +1,69 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/domainlayerhelper"
+)
+
+// SNAPSHOT_DIR_ENV is the env of the directory to which snapshots are written
+const SNAPSHOT_DIR_ENV = "DOMAIN_SNAPSHOT_DIR"
+
+var ExportDomainSnapshotMeta = plugin.SubTaskMeta{
+	Name:             "exportDomainSnapshot",
+	EntryPoint:       ExportDomainSnapshot,
+	EnabledByDefault: true,
+	Description:      "Export rows of the domain layer tables belonging to the project as parquet or csv files",
+}
+
+// ExportDomainSnapshot writes the snapshot archive of the project into the `DOMAIN_SNAPSHOT_DIR`
+func ExportDomainSnapshot(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*SnapshotTaskData)
+	logger := taskCtx.GetLogger()
+	dir := taskCtx.GetConfig(SNAPSHOT_DIR_ENV)
+	if dir == "" {
+		dir = "snapshots"
+	}
+	if e := os.MkdirAll(dir, 0755); e != nil {
+		return errors.Default.Wrap(e, fmt.Sprintf("failed to create snapshot directory %s", dir))
+	}
+	archive := filepath.Join(dir, fmt.Sprintf(
+		"%s-%s.tar.gz",
+		domainlayerhelper.SnapshotFileName(data.Options.ProjectName),
+		time.Now().UTC().Format("20060102150405"),
+	))
+	manifest, err := domainlayerhelper.ExportProjectSnapshot(
+		taskCtx.GetDal(),
+		logger,
+		data.Options.ProjectName,
+		data.Options.Format,
+		archive,
+	)
+	if err != nil {
+		return err
+	}
+	logger.Info("exported %d tables of project %s to %s", len(manifest.Tables), data.Options.ProjectName, archive)
+	return nil
+}
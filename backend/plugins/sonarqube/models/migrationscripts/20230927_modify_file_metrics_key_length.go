@@ -18,8 +18,6 @@ limitations under the License.
 package migrationscripts
 
 import (
-	"net/url"
-
 	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
@@ -41,22 +39,8 @@ func (fileMetricsKey20230927) TableName() string {
 
 func (script *modifyFileMetricsKeyLength) Up(basicRes context.BasicRes) errors.Error {
 	db := basicRes.GetDal()
-	dbUrl := basicRes.GetConfig("DB_URL")
-	if dbUrl == "" {
-		return errors.BadInput.New("DB_URL is required")
-	}
-	u, err1 := url.Parse(dbUrl)
-	if err1 != nil {
-		return errors.Convert(err1)
-	}
-	if u.Scheme == "mysql" {
-		if err := db.Exec("ALTER TABLE _tool_sonarqube_file_metrics DROP PRIMARY KEY"); err != nil {
-			return err
-		}
-	} else {
-		if err := db.Exec("ALTER TABLE _tool_sonarqube_file_metrics DROP CONSTRAINT _tool_sonarqube_file_metrics_pkey"); err != nil {
-			return err
-		}
+	if err := migrationhelper.DropPrimaryKey(db, fileMetricsKey20230927{}.TableName()); err != nil {
+		return err
 	}
 
 	err := migrationhelper.ChangeColumnsType[fileMetricsKey20230927](
@@ -77,10 +61,7 @@ func (script *modifyFileMetricsKeyLength) Up(basicRes context.BasicRes) errors.E
 		return err
 	}
 
-	if err := db.Exec("ALTER TABLE _tool_sonarqube_file_metrics ADD PRIMARY KEY (connection_id, file_metrics_key)"); err != nil {
-		return err
-	}
-	return nil
+	return migrationhelper.AddPrimaryKey(db, fileMetricsKey20230927{}.TableName(), "connection_id", "file_metrics_key")
 }
 
 func (*modifyFileMetricsKeyLength) Version() uint64 {
@@ -59,9 +59,13 @@ func GetTablesByDomainLayer(domainLayer string) []string {
 			"board_repos",
 			"issue_commits",
 			"issue_repo_commits",
+			"project_deployment_metrics",
 			"project_incident_deployment_relationships",
 			"project_mapping",
 			"project_pr_metrics",
+			"project_service_deployments",
+			"project_service_incidents",
+			"project_service_pr_metrics",
 			"pull_request_issues",
 			"refs_issues_diffs",
 			"team_users",
@@ -48,6 +48,7 @@ import (
 	q_dev "github.com/apache/incubator-devlake/plugins/q_dev/impl"
 	refdiff "github.com/apache/incubator-devlake/plugins/refdiff/impl"
 	slack "github.com/apache/incubator-devlake/plugins/slack/impl"
+	snapshot "github.com/apache/incubator-devlake/plugins/snapshot/impl"
 	sonarqube "github.com/apache/incubator-devlake/plugins/sonarqube/impl"
 	starrocks "github.com/apache/incubator-devlake/plugins/starrocks/impl"
 	tapd "github.com/apache/incubator-devlake/plugins/tapd/impl"
@@ -85,6 +86,7 @@ func Test_GetPluginTablesInfo(t *testing.T) {
 	checker.FeedIn("pagerduty/models", pagerduty.PagerDuty{}.GetTablesInfo)
 	checker.FeedIn("refdiff/models", refdiff.RefDiff{}.GetTablesInfo)
 	checker.FeedIn("slack/models", slack.Slack{}.GetTablesInfo)
+	checker.FeedIn("snapshot", snapshot.Snapshot{}.GetTablesInfo)
 	checker.FeedIn("sonarqube/models", sonarqube.Sonarqube{}.GetTablesInfo)
 	checker.FeedIn("starrocks", starrocks.StarRocks{}.GetTablesInfo)
 	checker.FeedIn("tapd/models", tapd.Tapd{}.GetTablesInfo)
@@ -226,15 +226,28 @@ func postDeployments(input *plugin.ApiResourceInput, connection *models.WebhookC
 	if err != nil {
 		return nil, errors.BadInput.Wrap(vld.Struct(request), `input json error`)
 	}
+	if err = saveDeployment(connection, request); err != nil {
+		return nil, err
+	}
+	// run the blueprints triggered by new deployments now that they are committed
+	if err := plugin.PublishPluginEvent(pluginName, "deployment", connection.ID); err != nil {
+		logger.Error(err, "trigger blueprints on deployment")
+	}
+
+	return &plugin.ApiResourceOutput{Body: nil, Status: http.StatusOK}, nil
+}
+
+// saveDeployment creates the deployment and its deployment commits in a transaction, which is committed on return
+// unless an error occurred
+func saveDeployment(connection *models.WebhookConnection, request *WebhookDeploymentReq) (err errors.Error) {
 	txHelper := dbhelper.NewTxHelper(basicRes, &err)
 	defer txHelper.End()
 	tx := txHelper.Begin()
-	if err := CreateDeploymentAndDeploymentCommits(connection, request, tx, logger); err != nil {
+	err = CreateDeploymentAndDeploymentCommits(connection, request, tx, logger)
+	if err != nil {
 		logger.Error(err, "create deployments")
-		return nil, err
 	}
-
-	return &plugin.ApiResourceOutput{Body: nil, Status: http.StatusOK}, nil
+	return err
 }
 
 func CreateDeploymentAndDeploymentCommits(connection *models.WebhookConnection, request *WebhookDeploymentReq, tx dal.Transaction, logger log.Logger) errors.Error {
@@ -18,12 +18,17 @@ limitations under the License.
 package api
 
 import (
+	gocontext "context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	// "github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -172,10 +177,27 @@ func RunApiServer(router *gin.Engine) {
 	}
 
 	// Start the server
-	err = router.Run(fmt.Sprintf(":%d", portNum))
-	if err != nil {
-		panic(err)
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", portNum),
+		Handler: router,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+
+	// Wait for the termination signal and shut down gracefully
+	ctx, stop := signal.NotifyContext(gocontext.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	shutdownCtx, cancel := gocontext.WithTimeout(gocontext.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logruslog.Global.Error(err, "failed to shut down the api server")
 	}
+	services.Shutdown(shutdownCtx)
 }
 
 func registerExtraOpenApiSpecs(router *gin.Engine) {
@@ -0,0 +1,83 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backups
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary create a backup
+// @Description dump the framework tables, the connections, scopes and scope configs of all plugins, and optionally
+// @Description the domain layer tables, as a gzipped tar archive which could be restored onto either MySQL or PostgreSQL.
+// @Description Encrypted columns are re-encrypted by `targetEncryptionSecret` if specified.
+// @Tags framework/backups
+// @Accept application/json
+// @Param backup body services.BackupInput false "json"
+// @Success 200
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /backups [post]
+func PostBackup(c *gin.Context) {
+	input := &services.BackupInput{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(input); err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+			return
+		}
+	}
+	archive, err := services.CreateBackup(input)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error creating backup"))
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(archive))
+	c.FileAttachment(archive, filepath.Base(archive))
+}
+
+// @Summary restore a backup
+// @Description restore a backup created by `POST /backups`, rows of the tables in the backup are replaced.
+// @Description The database must be migrated by the same version of DevLake and ENCRYPTION_SECRET must be the target key of the backup.
+// @Tags framework/backups
+// @Accept multipart/form-data
+// @Param file formData file true "the backup, the request body is read as the backup if absent"
+// @Success 200  {object} services.BackupManifest
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /backups/restore [post]
+func PostRestore(c *gin.Context) {
+	var reader io.Reader = c.Request.Body
+	file, _, e := c.Request.FormFile("file")
+	if e == nil {
+		defer file.Close()
+		reader = file
+	}
+	manifest, err := services.RestoreBackup(reader)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error restoring backup"))
+		return
+	}
+	shared.ApiOutputSuccess(c, manifest, http.StatusOK)
+}
@@ -198,6 +198,42 @@ func Trigger(c *gin.Context) {
 	shared.ApiOutputSuccess(c, pipeline, http.StatusOK)
 }
 
+// @Summary preview the plan of a blueprint
+// @Description expand the plan the blueprint would run if it was triggered now without creating a pipeline, including
+// @Description the enabled subtasks of each task, the sync mode (incremental or full) and the time range to work on
+// @Tags framework/blueprints
+// @Accept application/json
+// @Param blueprintId path string true "blueprintId"
+// @Param skipCollectors body models.TriggerSyncPolicy false "json"
+// @Param fullSync body models.TriggerSyncPolicy false "json"
+// @Success 200 {object} services.BlueprintPlanPreview
+// @Failure 400 {object} shared.ApiBody "Bad Request"
+// @Failure 500 {object} shared.ApiBody "Internal Error"
+// @Router /blueprints/{blueprintId}/dry-run [Post]
+func DryRun(c *gin.Context) {
+	blueprintId := c.Param("blueprintId")
+	id, err := strconv.ParseUint(blueprintId, 10, 64)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "bad blueprintID format supplied"))
+		return
+	}
+
+	triggerSyncPolicy := &models.TriggerSyncPolicy{}
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		err = c.ShouldBindJSON(triggerSyncPolicy)
+		if err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, "error binding request body"))
+			return
+		}
+	}
+	preview, err := services.PreviewBlueprintPlan(id, triggerSyncPolicy)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error previewing blueprint plan"))
+		return
+	}
+	shared.ApiOutputSuccess(c, preview, http.StatusOK)
+}
+
 // @Summary get pipelines by blueprint id
 // @Description get pipelines by blueprint id
 // @Tags framework/blueprints
@@ -0,0 +1,76 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domainlayer
+
+import (
+	"net/http"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary list domain layer tables
+// @Description list domain layer tables available for querying along with their columns, an api key is required
+// @Description and the api should be accessed by `/rest/domainlayer/tables`
+// @Tags framework/domainlayer
+// @Success 200  {object} []services.DomainTable
+// @Failure 401  {object} shared.ApiBody "Unauthorized"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /domainlayer/tables [get]
+func GetTables(c *gin.Context) {
+	tables, err := services.GetDomainTables()
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting domain layer tables"))
+		return
+	}
+	shared.ApiOutputSuccess(c, tables, http.StatusOK)
+}
+
+// @Summary query a domain layer table
+// @Description query rows of a domain layer table, an api key is required and the api should be accessed by
+// @Description `/rest/domainlayer/tables/{table}`. Query parameters other than the listed ones are filters in the
+// @Description form of `<column>=<value>` or `<column>.<operator>=<value>`, operators are eq, ne, gt, gte, lt, lte,
+// @Description in (comma separated values), like and null (true or false), i.e. `status=DONE&created_date.gte=2024-01-01`
+// @Tags framework/domainlayer
+// @Param table path string true "table name, i.e. issues"
+// @Param fields query string false "comma separated columns to return, all columns by default"
+// @Param sort query string false "column to sort by, prefixed with - for the descending order, the primary key by default"
+// @Param limit query int false "page size, 100 by default and 1000 at most"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Param project query string false "project name to limit the rows to the scopes of the project"
+// @Success 200  {object} services.DomainQueryResult
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 401  {object} shared.ApiBody "Unauthorized"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /domainlayer/tables/{table} [get]
+func QueryTable(c *gin.Context) {
+	query, err := services.ParseDomainQuery(c.Param("table"), c.Request.URL.Query())
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	result, err := services.QueryDomainTable(query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error querying domain layer table"))
+		return
+	}
+	shared.ApiOutputSuccess(c, result, http.StatusOK)
+}
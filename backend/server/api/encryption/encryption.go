@@ -0,0 +1,44 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"net/http"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary rotate the encryption secret
+// @Description re-encrypt all values encrypted by the secrets in ENCRYPTION_SECRET_PREVIOUS with the ENCRYPTION_SECRET
+// @Description in one transaction, ENCRYPTION_SECRET_PREVIOUS could be removed afterward
+// @Tags framework/encryption
+// @Success 200  {object} services.EncryptionRotationReport
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /encryption-secret/rotate [post]
+func PostRotate(c *gin.Context) {
+	report, err := services.RotateEncryptionSecret()
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error rotating encryption secret"))
+		return
+	}
+	shared.ApiOutputSuccess(c, report, http.StatusOK)
+}
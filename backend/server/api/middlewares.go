@@ -18,6 +18,7 @@ limitations under the License.
 package api
 
 import (
+	gocontext "context"
 	"encoding/base64"
 	"fmt"
 	"github.com/apache/incubator-devlake/core/log"
@@ -101,6 +102,22 @@ type apiBody struct {
 	Message string `json:"message"`
 }
 
+// apiKeyVerified marks the requests authenticated by RestAuthentication, it is kept in the context of the
+// request since the keys of gin.Context get reset when the request is routed again
+type apiKeyVerified struct{}
+
+// RequireApiKey rejects the requests that were not sent to `/rest/...` with a valid api key
+func RequireApiKey(c *gin.Context) {
+	if verified, _ := c.Request.Context().Value(apiKeyVerified{}).(bool); verified {
+		c.Next()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusUnauthorized, &apiBody{
+		Success: false,
+		Message: "the api is only accessible by /rest with an api key",
+	})
+}
+
 func RestAuthentication(router *gin.Engine, basicRes context.BasicRes) gin.HandlerFunc {
 
 	db := basicRes.GetDal()
@@ -124,6 +141,7 @@ func RestAuthentication(router *gin.Engine, basicRes context.BasicRes) gin.Handl
 			c.Abort()
 			return
 		} else {
+			c.Request = c.Request.WithContext(gocontext.WithValue(c.Request.Context(), apiKeyVerified{}, true))
 			router.HandleContext(c)
 			c.Abort()
 			return
@@ -0,0 +1,167 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifications
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PaginatedNotificationSubscriptions struct {
+	Subscriptions []*models.NotificationSubscription `json:"subscriptions"`
+	Count         int64                              `json:"count"`
+}
+
+func getSubscriptionId(c *gin.Context) (uint64, errors.Error) {
+	id, err := strconv.ParseUint(c.Param("subscriptionId"), 10, 64)
+	if err != nil {
+		return 0, errors.BadInput.Wrap(err, "bad subscriptionId format supplied")
+	}
+	return id, nil
+}
+
+// @Summary Get list of notification subscriptions
+// @Description GET /notification-subscriptions?projectName=xxx&blueprintId=1&page=1&pageSize=10
+// @Tags framework/notifications
+// @Param projectName query string false "project name"
+// @Param blueprintId query int false "blueprint id"
+// @Param page query int false "query"
+// @Param pageSize query int false "query"
+// @Success 200  {object} PaginatedNotificationSubscriptions
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notification-subscriptions [get]
+func GetSubscriptions(c *gin.Context) {
+	var query services.NotificationSubscriptionQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	subscriptions, count, err := services.GetNotificationSubscriptions(&query)
+	if err != nil {
+		shared.ApiOutputAbort(c, errors.Default.Wrap(err, "error getting notification subscriptions"))
+		return
+	}
+	shared.ApiOutputSuccess(c, PaginatedNotificationSubscriptions{
+		Subscriptions: subscriptions,
+		Count:         count,
+	}, http.StatusOK)
+}
+
+// @Summary Get a notification subscription
+// @Description Get a notification subscription
+// @Tags framework/notifications
+// @Param subscriptionId path int true "subscriptionId"
+// @Success 200  {object} models.NotificationSubscription
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notification-subscriptions/{subscriptionId} [get]
+func GetSubscription(c *gin.Context) {
+	id, err := getSubscriptionId(c)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	subscription, err := services.GetNotificationSubscription(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, subscription, http.StatusOK)
+}
+
+// @Summary Create a notification subscription
+// @Description Subscribe a WEBHOOK, SLACK or EMAIL channel to the notifications of a project or a blueprint
+// @Tags framework/notifications
+// @Accept application/json
+// @Param subscription body models.NotificationSubscription true "json"
+// @Success 201  {object} models.NotificationSubscription
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notification-subscriptions [post]
+func PostSubscription(c *gin.Context) {
+	subscription := &models.NotificationSubscription{}
+	if e := c.ShouldBind(subscription); e != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(e, shared.BadRequestBody))
+		return
+	}
+	subscription, err := services.CreateNotificationSubscription(subscription)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error creating notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, subscription, http.StatusCreated)
+}
+
+// @Summary Patch a notification subscription
+// @Description Patch a notification subscription, the secret is kept unless a new one is given
+// @Tags framework/notifications
+// @Accept application/json
+// @Param subscriptionId path int true "subscriptionId"
+// @Param subscription body models.NotificationSubscription true "json"
+// @Success 200  {object} models.NotificationSubscription
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notification-subscriptions/{subscriptionId} [patch]
+func PatchSubscription(c *gin.Context) {
+	id, err := getSubscriptionId(c)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	var body map[string]interface{}
+	if e := c.ShouldBind(&body); e != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(e, shared.BadRequestBody))
+		return
+	}
+	subscription, err := services.PatchNotificationSubscription(id, body)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error patching notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, subscription, http.StatusOK)
+}
+
+// @Summary Delete a notification subscription
+// @Description Delete a notification subscription
+// @Tags framework/notifications
+// @Param subscriptionId path int true "subscriptionId"
+// @Success 200
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /notification-subscriptions/{subscriptionId} [delete]
+func DeleteSubscription(c *gin.Context) {
+	id, err := getSubscriptionId(c)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	err = services.DeleteNotificationSubscription(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error deleting notification subscription"))
+		return
+	}
+	shared.ApiOutputSuccess(c, nil, http.StatusOK)
+}
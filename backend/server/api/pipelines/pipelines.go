@@ -26,6 +26,9 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
+
+	"golang.org/x/exp/slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -200,3 +203,77 @@ func PostRerun(c *gin.Context) {
 	}
 	shared.ApiOutputSuccess(c, rerunTasks, http.StatusOK)
 }
+
+// @Summary Stream the progress of a pipeline
+// @Description GET /pipelines/:pipelineId/events
+// @Description Server-sent events of the pipeline, the event name is one of pipeline, task, subtask, progress and log
+// @Description with a services.PipelineEvent as data. The stream starts with the current status of the pipeline and is
+// @Description closed once the pipeline is finished. Tasks executed by other nodes in the cluster mode are not streamed,
+// @Description their status would be reflected by the pipeline event only.
+// @Tags framework/pipelines
+// @Produce text/event-stream
+// @Param pipelineId path int true "pipeline ID"
+// @Success 200  {object} services.PipelineEvent
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Pipeline not found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /pipelines/{pipelineId}/events [get]
+func Events(c *gin.Context) {
+	pipelineId := c.Param("pipelineId")
+	id, err := strconv.ParseUint(pipelineId, 10, 64)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "bad pipelineID format supplied"))
+		return
+	}
+	// subscribe before loading the pipeline, so no event would be missed in between
+	events, unsubscribe := services.SubscribePipelineEvents(id)
+	defer unsubscribe()
+	pipeline, err := services.GetDbPipeline(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting pipeline"))
+		return
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	send := func(event *services.PipelineEvent) {
+		c.SSEvent(event.Type, event)
+		c.Writer.Flush()
+	}
+	sendStatus := func(pipeline *models.Pipeline) bool {
+		send(&services.PipelineEvent{
+			Type:       services.PIPELINE_EVENT_PIPELINE,
+			PipelineId: pipeline.ID,
+			Status:     pipeline.Status,
+			Message:    pipeline.Message,
+			Time:       time.Now(),
+		})
+		return slices.Contains(models.FinishedTaskStatus, pipeline.Status)
+	}
+	if sendStatus(pipeline) {
+		return
+	}
+	// the pipeline might be run by another node, check its status periodically, which keeps the connection alive as well
+	ticker := time.NewTicker(services.PipelineEventsHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event := <-events:
+			send(event)
+			if event.Type == services.PIPELINE_EVENT_PIPELINE && slices.Contains(models.FinishedTaskStatus, event.Status) {
+				return
+			}
+		case <-ticker.C:
+			pipeline, err = services.GetDbPipeline(id)
+			if err != nil {
+				return
+			}
+			if sendStatus(pipeline) {
+				return
+			}
+		}
+	}
+}
@@ -19,6 +19,8 @@ package project
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
 
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models"
@@ -53,6 +55,29 @@ func GetProject(c *gin.Context) {
 	shared.ApiOutputSuccess(c, projectOutput, http.StatusOK)
 }
 
+// @Summary Export a snapshot of the project
+// @Description Export rows of the domain layer tables belonging to the project as a gzipped tar archive,
+// @Description which contains a parquet or csv file per table along with `manifest.json` describing the schema
+// @Tags framework/projects
+// @Param projectName path string true "project name"
+// @Param format query string false "parquet (default) or csv"
+// @Success 200
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /projects/{projectName}/snapshot [get]
+func GetProjectSnapshot(c *gin.Context) {
+	projectName := c.Param("projectName")
+
+	archive, err := services.ExportProjectSnapshot(projectName, c.Query("format"))
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error exporting project snapshot"))
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(archive))
+	c.FileAttachment(archive, filepath.Base(archive))
+}
+
 // @Summary Get project exist check
 // @Description Get project exist check
 // @Tags framework/projects
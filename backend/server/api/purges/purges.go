@@ -0,0 +1,118 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purges
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PaginatedPurges struct {
+	Purges []*models.Purge `json:"purges"`
+	Count  int64           `json:"count"`
+}
+
+// @Summary Purge the data of a project, a connection or a scope
+// @Description Delete the rows collected for a project, a connection or a scope across the raw, tool and domain layers.
+// @Description With `dryRun`, the rows to be deleted are reported by table without deleting anything, otherwise the rows
+// @Description are deleted in batches in the background and the progress could be tracked by `GET /purges/{purgeId}`.
+// @Description Scopes of a project mapped to other projects are kept.
+// @Tags framework/purges
+// @Accept application/json
+// @Param purge body services.PurgeInput true "projectName, or plugin with connectionId and an optional scopeId"
+// @Success 200  {object} services.PurgeReport "dry run"
+// @Success 201  {object} models.Purge
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 409  {string} errcode.Error "Conflict"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /purges [post]
+func PostPurge(c *gin.Context) {
+	input := &services.PurgeInput{}
+	if err := c.ShouldBindJSON(input); err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	if input.DryRun {
+		report, err := services.PurgeDryRun(input)
+		if err != nil {
+			shared.ApiOutputError(c, errors.Default.Wrap(err, "error planning purge"))
+			return
+		}
+		shared.ApiOutputSuccess(c, report, http.StatusOK)
+		return
+	}
+	purge, err := services.CreatePurge(input)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error creating purge"))
+		return
+	}
+	shared.ApiOutputSuccess(c, purge, http.StatusCreated)
+}
+
+// @Summary Get list of purges
+// @Description GET /purges?status=TASK_RUNNING&page=1&pageSize=10
+// @Tags framework/purges
+// @Param status query string false "status"
+// @Param page query int false "query"
+// @Param pageSize query int false "query"
+// @Success 200  {object} PaginatedPurges
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /purges [get]
+func GetPurges(c *gin.Context) {
+	var query services.PurgeQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	purges, count, err := services.GetPurges(&query)
+	if err != nil {
+		shared.ApiOutputAbort(c, errors.Default.Wrap(err, "error getting purges"))
+		return
+	}
+	shared.ApiOutputSuccess(c, PaginatedPurges{Purges: purges, Count: count}, http.StatusOK)
+}
+
+// @Summary Get a purge
+// @Description Get a purge along with its progress by table
+// @Tags framework/purges
+// @Param purgeId path int true "purgeId"
+// @Success 200  {object} models.Purge
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 404  {string} errcode.Error "Not Found"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /purges/{purgeId} [get]
+func GetPurge(c *gin.Context) {
+	id, e := strconv.ParseUint(c.Param("purgeId"), 10, 64)
+	if e != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(e, "bad purgeId format supplied"))
+		return
+	}
+	purge, err := services.GetPurge(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting purge"))
+		return
+	}
+	shared.ApiOutputSuccess(c, purge, http.StatusOK)
+}
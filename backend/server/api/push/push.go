@@ -19,10 +19,12 @@ package push
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"github.com/apache/incubator-devlake/server/services"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,34 +33,34 @@ import (
 	POST /push/:tableName
 	[
 		{
-			"id": 1,
-			"sha": "osidjfoawehfwh08"
+			"id": "github:GithubPullRequest:1:1",
+			"title": "fix a bug"
 		}
 	]
 */
 // @Summary POST /push/:tableName
-// @Description POST /push/:tableName
+// @Description Upsert rows into a domain layer table by primary key, rows are validated against the model of the table.
+// @Description The body is a json array of rows, or rows separated by newlines with the `application/x-ndjson` content type.
+// @Description Columns absent from a row are left untouched when the row exists, invalid rows are reported in `errors`.
 // @Tags framework/push
 // @Accept application/json
+// @Accept application/x-ndjson
 // @Param tableName path string true "table name"
 // @Param data body string true "data"
-// @Success 200  {object} gin.H "{"rowsAffected": rowsAffected}"
+// @Success 200  {object} services.PushResult
 // @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 413  {string} errcode.Error "Request Entity Too Large"
 // @Failure 500  {string} errcode.Error "Internal Error"
 // @Router /push/{tableName} [post]
 func Post(c *gin.Context) {
-	var err error
 	tableName := c.Param("tableName")
-	var rowsToInsert []map[string]interface{}
-	err = c.ShouldBindJSON(&rowsToInsert)
-	if err != nil {
-		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
-		return
-	}
-	rowsAffected, err := services.InsertRow(tableName, rowsToInsert)
+	contentType := c.ContentType()
+	ndjson := strings.HasSuffix(contentType, "ndjson") || strings.HasSuffix(contentType, "jsonl")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.PushMaxBodySize())
+	result, err := services.PushRows(tableName, c.Request.Body, ndjson)
 	if err != nil {
-		shared.ApiOutputError(c, errors.Default.Wrap(err, fmt.Sprintf("error inserting request body into table %s", tableName)))
+		shared.ApiOutputError(c, errors.Default.Wrap(err, fmt.Sprintf("error pushing request body into table %s", tableName)))
 		return
 	}
-	shared.ApiOutputSuccess(c, gin.H{"rowsAffected": rowsAffected}, http.StatusOK)
+	shared.ApiOutputSuccess(c, result, http.StatusOK)
 }
@@ -0,0 +1,84 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rawbundles
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary export raw data bundle
+// @Description export the raw rows of the given plugin and scope as a gzipped tar archive, which could be imported
+// @Description into another DevLake instance for offline reprocessing
+// @Tags framework/raw-bundles
+// @Param plugin query string true "plugin name, i.e. github"
+// @Param connectionId query int false "connection id"
+// @Param scope query string false "any raw params value other than the connection id, i.e. the repo name or board id"
+// @Param params query string false "exact raw params, takes precedence over connectionId and scope"
+// @Success 200
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /raw-bundles [get]
+func Export(c *gin.Context) {
+	var query services.RawBundleQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	archive, err := services.ExportRawBundle(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error exporting raw bundle"))
+		return
+	}
+	defer os.RemoveAll(filepath.Dir(archive))
+	c.FileAttachment(archive, filepath.Base(archive))
+}
+
+// @Summary import raw data bundle
+// @Description import a raw bundle exported by `GET /raw-bundles`, raw rows with the same params are replaced.
+// @Description Run a pipeline with `skipCollectors` afterward to regenerate the tool and domain layers.
+// @Tags framework/raw-bundles
+// @Accept multipart/form-data
+// @Param file formData file true "the bundle, the request body is read as the bundle if absent"
+// @Success 200  {object} services.RawBundleManifest
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /raw-bundles [post]
+func Import(c *gin.Context) {
+	var reader io.Reader = c.Request.Body
+	file, _, e := c.Request.FormFile("file")
+	if e == nil {
+		defer file.Close()
+		reader = file
+	}
+	manifest, err := services.ImportRawBundle(reader)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error importing raw bundle"))
+		return
+	}
+	shared.ApiOutputSuccess(c, manifest, http.StatusOK)
+}
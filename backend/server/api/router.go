@@ -24,17 +24,23 @@ import (
 
 	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/helpers/metricshelper"
 	"github.com/apache/incubator-devlake/impls/logruslog"
 	"github.com/apache/incubator-devlake/server/api/apikeys"
 	"github.com/apache/incubator-devlake/server/api/store"
 
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/server/api/backups"
 	"github.com/apache/incubator-devlake/server/api/blueprints"
 	"github.com/apache/incubator-devlake/server/api/domainlayer"
+	"github.com/apache/incubator-devlake/server/api/encryption"
+	"github.com/apache/incubator-devlake/server/api/notifications"
 	"github.com/apache/incubator-devlake/server/api/pipelines"
 	"github.com/apache/incubator-devlake/server/api/plugininfo"
 	"github.com/apache/incubator-devlake/server/api/project"
+	"github.com/apache/incubator-devlake/server/api/purges"
 	"github.com/apache/incubator-devlake/server/api/push"
+	"github.com/apache/incubator-devlake/server/api/rawbundles"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"github.com/apache/incubator-devlake/server/api/task"
 	"github.com/apache/incubator-devlake/server/services"
@@ -51,6 +57,7 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	r.GET("/pipelines/:pipelineId/subtasks", task.GetSubtaskByPipeline)
 	r.POST("/pipelines/:pipelineId/rerun", pipelines.PostRerun)
 	r.GET("/pipelines/:pipelineId/logging.tar.gz", pipelines.DownloadLogs)
+	r.GET("/pipelines/:pipelineId/events", pipelines.Events)
 
 	r.GET("/blueprints", blueprints.Index)
 	r.POST("/blueprints", blueprints.Post)
@@ -58,12 +65,30 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	r.DELETE("/blueprints/:blueprintId", blueprints.Delete)
 	r.GET("/blueprints/:blueprintId", blueprints.Get)
 	r.POST("/blueprints/:blueprintId/trigger", blueprints.Trigger)
+	r.POST("/blueprints/:blueprintId/dry-run", blueprints.DryRun)
 	r.GET("/blueprints/:blueprintId/pipelines", blueprints.GetBlueprintPipelines)
 
 	r.POST("/tasks/:taskId/rerun", task.PostRerun)
 
 	r.POST("/push/:tableName", push.Post)
+
+	// raw bundle api
+	r.GET("/raw-bundles", rawbundles.Export)
+	r.POST("/raw-bundles", rawbundles.Import)
+	r.POST("/purges", purges.PostPurge)
+	r.GET("/purges", purges.GetPurges)
+	r.GET("/purges/:purgeId", purges.GetPurge)
+
+	// backup api
+	r.POST("/backups", backups.PostBackup)
+	r.POST("/backups/restore", backups.PostRestore)
+	r.POST("/encryption-secret/rotate", encryption.PostRotate)
+
 	r.GET("/domainlayer/repos", domainlayer.ReposIndex)
+	// domain layer tables are only accessible by /rest with an api key
+	domainTables := r.Group("/domainlayer/tables", RequireApiKey)
+	domainTables.GET("", domainlayer.GetTables)
+	domainTables.GET("/:table", domainlayer.QueryTable)
 
 	// plugin api
 	r.GET("/plugininfo", plugininfo.Get)
@@ -72,6 +97,7 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	// project api
 	r.GET("/projects/:projectName", project.GetProject)
 	r.GET("/projects/:projectName/check", project.GetProjectCheck)
+	r.GET("/projects/:projectName/snapshot", project.GetProjectSnapshot)
 	r.PATCH("/projects/:projectName", project.PatchProject)
 	r.DELETE("/projects/:projectName", project.DeleteProject)
 	r.POST("/projects", project.PostProject)
@@ -86,6 +112,16 @@ func RegisterRouter(r *gin.Engine, basicRes context.BasicRes) {
 	r.PUT("/api-keys/:apiKeyId", apikeys.PutApiKey)
 	r.DELETE("/api-keys/:apiKeyId", apikeys.DeleteApiKey)
 
+	// notification subscriptions api
+	r.GET("/notification-subscriptions", notifications.GetSubscriptions)
+	r.POST("/notification-subscriptions", notifications.PostSubscription)
+	r.GET("/notification-subscriptions/:subscriptionId", notifications.GetSubscription)
+	r.PATCH("/notification-subscriptions/:subscriptionId", notifications.PatchSubscription)
+	r.DELETE("/notification-subscriptions/:subscriptionId", notifications.DeleteSubscription)
+
+	// prometheus metrics
+	r.GET("/metrics", gin.WrapH(metricshelper.Handler()))
+
 	// mount all api resources for all plugins
 	resources, err := services.GetPluginsApiResources()
 	if err != nil {
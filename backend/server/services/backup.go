@@ -0,0 +1,461 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/migration"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/version"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	BACKUP_VERSION      = 1
+	backupManifest      = "manifest.json"
+	backupKeyCheckText  = "devlake-backup"
+	backupBatchSize     = 100
+	backupFileExtension = ".ndjson"
+)
+
+// the framework tables to back up, runtime states of the instance like workers and locks are left out, so are the
+// collector states, subtask states and raw data syncs as the raw and tool layers are not backed up, an incremental
+// collection or extraction after the restore would skip the data otherwise
+var backupFrameworkModels = []dal.Tabler{
+	&models.ApiKey{},
+	&models.Blueprint{},
+	&models.BlueprintConnection{},
+	&models.BlueprintLabel{},
+	&models.BlueprintScope{},
+	&models.BlueprintTrigger{},
+	&models.DbPipelineLabel{},
+	&models.Notification{},
+	&models.NotificationSubscription{},
+	&models.Pipeline{},
+	&models.Project{},
+	&models.ProjectMetricSetting{},
+	&models.Purge{},
+	&models.Store{},
+	&models.Subtask{},
+	&models.Task{},
+}
+
+// BackupInput specifies what to back up
+type BackupInput struct {
+	// IncludeDomain includes the domain layer tables, which could be regenerated by pipelines otherwise
+	IncludeDomain bool `json:"includeDomain"`
+	// TargetEncryptionSecret re-encrypts the encrypted columns for the instance to restore into,
+	// the current ENCRYPTION_SECRET is kept if empty
+	TargetEncryptionSecret string `json:"targetEncryptionSecret"`
+}
+
+type BackupTable struct {
+	Name             string   `json:"name"`
+	Rows             int64    `json:"rows"`
+	EncryptedColumns []string `json:"encryptedColumns,omitempty"`
+}
+
+// BackupManifest describes a backup, the rows of each table are stored in `<table>.ndjson`
+type BackupManifest struct {
+	Version          int       `json:"version"`
+	DevlakeVersion   string    `json:"devlakeVersion"`
+	MigrationVersion uint64    `json:"migrationVersion"`
+	Dialect          string    `json:"dialect"`
+	CreatedAt        time.Time `json:"createdAt"`
+	// KeyCheck is a known text encrypted by the target key, the restore verifies its key against it
+	KeyCheck string         `json:"keyCheck"`
+	Tables   []*BackupTable `json:"tables"`
+}
+
+// CreateBackup dumps the state of DevLake into a gzipped tar archive and returns its path, the caller
+// is responsible for removing the directory of the archive
+func CreateBackup(input *BackupInput) (string, errors.Error) {
+	sourceKeys := acceptedEncryptionSecrets()
+	targetKey := input.TargetEncryptionSecret
+	if targetKey == "" {
+		targetKey = sourceKeys[0]
+	}
+	keyCheck, err := plugin.Encrypt(targetKey, backupKeyCheckText)
+	if err != nil {
+		return "", err
+	}
+	migrationVersion, err := getMigrationVersion(db)
+	if err != nil {
+		return "", err
+	}
+	tables := findBackupTables(input.IncludeDomain)
+	encryptedTables, err := findEncryptedTables()
+	if err != nil {
+		return "", err
+	}
+	dir, e := os.MkdirTemp("", "devlake-backup-")
+	if e != nil {
+		return "", errors.Convert(e)
+	}
+	defer os.RemoveAll(dir)
+	manifest := &BackupManifest{
+		Version:          BACKUP_VERSION,
+		DevlakeVersion:   version.Version,
+		MigrationVersion: migrationVersion,
+		Dialect:          db.Dialect(),
+		CreatedAt:        time.Now(),
+		KeyCheck:         keyCheck,
+	}
+	var files []string
+	for _, table := range tables {
+		backupTable := &BackupTable{Name: table}
+		if encrypted, ok := encryptedTables[table]; ok {
+			backupTable.EncryptedColumns = encrypted.columns
+		}
+		path := filepath.Join(dir, table+backupFileExtension)
+		backupTable.Rows, err = dumpBackupTable(backupTable, path, sourceKeys, targetKey)
+		if err != nil {
+			return "", errors.Default.Wrap(err, fmt.Sprintf("failed to back up %s", table))
+		}
+		manifest.Tables = append(manifest.Tables, backupTable)
+		files = append(files, path)
+	}
+	archive := filepath.Join(os.TempDir(), uuid.New().String(), fmt.Sprintf("devlake-backup-%s.tar.gz", manifest.CreatedAt.Format("20060102150405")))
+	if err = writeManifestArchive(archive, backupManifest, manifest, manifest.CreatedAt, files); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
+// findBackupTables returns the framework tables, the connections, scopes and scope configs of all plugins,
+// and optionally the domain layer tables, which exist in the database
+func findBackupTables(includeDomain bool) []string {
+	var tables []string
+	add := func(table string) {
+		if !slices.Contains(tables, table) && db.HasTable(table) {
+			tables = append(tables, table)
+		}
+	}
+	for _, tabler := range backupFrameworkModels {
+		add(tabler.TableName())
+	}
+	var pluginTables []string
+	for _, pluginMeta := range plugin.AllPlugins() {
+		pluginSrc, ok := pluginMeta.(plugin.PluginSource)
+		if !ok {
+			continue
+		}
+		for _, tabler := range []dal.Tabler{pluginSrc.Connection(), pluginSrc.Scope(), pluginSrc.ScopeConfig()} {
+			if tabler != nil && !isNilTabler(tabler) {
+				pluginTables = append(pluginTables, tabler.TableName())
+			}
+		}
+	}
+	sort.Strings(pluginTables)
+	for _, table := range pluginTables {
+		add(table)
+	}
+	if includeDomain {
+		for _, tabler := range domaininfo.GetDomainTablesInfo() {
+			add(tabler.TableName())
+		}
+	}
+	return tables
+}
+
+func isNilTabler(tabler dal.Tabler) (isNil bool) {
+	defer func() {
+		if recover() != nil {
+			isNil = true
+		}
+	}()
+	_ = tabler.TableName()
+	return false
+}
+
+func getMigrationVersion(d dal.Dal) (uint64, errors.Error) {
+	var versions []uint64
+	err := d.Pluck("MAX(script_version)", &versions, dal.From(&migration.MigrationHistory{}))
+	if err != nil || len(versions) == 0 {
+		return 0, err
+	}
+	return versions[0], nil
+}
+
+func dumpBackupTable(table *BackupTable, path string, sourceKeys []string, targetKey string) (int64, errors.Error) {
+	keepEncrypted := len(sourceKeys) == 1 && sourceKeys[0] == targetKey
+	file, e := os.Create(path)
+	if e != nil {
+		return 0, errors.Convert(e)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	cursor, err := db.Cursor(dal.From(table.Name))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close()
+	columns, e := cursor.Columns()
+	if e != nil {
+		return 0, errors.Convert(e)
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	encoder := json.NewEncoder(writer)
+	var rows int64
+	for cursor.Next() {
+		if e = cursor.Scan(dest...); e != nil {
+			return 0, errors.Convert(e)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			if s, ok := value.(string); ok && s != "" && !keepEncrypted && slices.Contains(table.EncryptedColumns, column) {
+				if value, err = reencrypt(s, sourceKeys, targetKey); err != nil {
+					return 0, errors.Default.Wrap(err, fmt.Sprintf("failed to re-encrypt %s", column))
+				}
+			}
+			row[column] = value
+		}
+		if e = encoder.Encode(row); e != nil {
+			return 0, errors.Convert(e)
+		}
+		rows++
+	}
+	return rows, errors.Convert(writer.Flush())
+}
+
+func reencrypt(encrypted string, fromKeys []string, toKey string) (string, errors.Error) {
+	plainText, _, err := plugin.DecryptWithAny(fromKeys, encrypted)
+	if err != nil {
+		return "", err
+	}
+	return plugin.Encrypt(toKey, plainText)
+}
+
+// RestoreBackup restores a backup created by CreateBackup, rows of the tables in the backup are replaced.
+// The database must be migrated to the same version as the backup, namely by the same version of DevLake,
+// and the ENCRYPTION_SECRET must be the target key of the backup.
+func RestoreBackup(reader io.Reader) (manifest *BackupManifest, err errors.Error) {
+	gzipReader, e := gzip.NewReader(reader)
+	if e != nil {
+		return nil, errors.BadInput.Wrap(e, "the backup is not a gzipped tar archive")
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	header, e := tarReader.Next()
+	if e != nil || header.Name != backupManifest {
+		return nil, errors.BadInput.New(fmt.Sprintf("the backup must start with %s", backupManifest))
+	}
+	manifest = &BackupManifest{}
+	if e = json.NewDecoder(tarReader).Decode(manifest); e != nil {
+		return nil, errors.BadInput.Wrap(e, fmt.Sprintf("failed to parse %s", backupManifest))
+	}
+	if err = verifyBackupManifest(manifest); err != nil {
+		return nil, err
+	}
+	restorable := findBackupTables(true)
+	tables := make(map[string]*BackupTable)
+	for _, table := range manifest.Tables {
+		if !slices.Contains(restorable, table.Name) {
+			return nil, errors.BadInput.New(fmt.Sprintf("table %s could not be restored", table.Name))
+		}
+		tables[table.Name+backupFileExtension] = table
+	}
+
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	for {
+		header, e = tarReader.Next()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return nil, errors.BadInput.Wrap(e, "failed to read the backup")
+		}
+		table, ok := tables[header.Name]
+		if !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unexpected file %s in the backup", header.Name))
+		}
+		rows, err := restoreBackupTable(tx, table.Name, tarReader)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to restore %s", table.Name))
+		}
+		if rows != table.Rows {
+			return nil, errors.BadInput.New(fmt.Sprintf("%s has %d rows while %d are declared", table.Name, rows, table.Rows))
+		}
+		delete(tables, header.Name)
+	}
+	for name := range tables {
+		return nil, errors.BadInput.New(fmt.Sprintf("%s is missing in the backup", name))
+	}
+	return manifest, nil
+}
+
+func verifyBackupManifest(manifest *BackupManifest) errors.Error {
+	if manifest.Version != BACKUP_VERSION {
+		return errors.BadInput.New(fmt.Sprintf("unsupported backup version %d", manifest.Version))
+	}
+	if text, err := plugin.Decrypt(cfg.GetString(plugin.EncodeKeyEnvStr), manifest.KeyCheck); err != nil || text != backupKeyCheckText {
+		return errors.BadInput.New("the backup is encrypted by another key, ENCRYPTION_SECRET must be the target key of the backup")
+	}
+	migrationVersion, err := getMigrationVersion(db)
+	if err != nil {
+		return err
+	}
+	if migrationVersion != manifest.MigrationVersion {
+		return errors.BadInput.New(fmt.Sprintf(
+			"the backup was made at migration version %d while the database is at %d, please restore with the same version of DevLake",
+			manifest.MigrationVersion, migrationVersion,
+		))
+	}
+	return nil
+}
+
+func restoreBackupTable(tx dal.Transaction, table string, reader io.Reader) (int64, errors.Error) {
+	columnMetas, err := tx.GetColumns(&dal.DefaultTabler{Name: table}, nil)
+	if err != nil {
+		return 0, err
+	}
+	columnTypes := make(map[string]string, len(columnMetas))
+	for _, columnMeta := range columnMetas {
+		columnTypes[columnMeta.Name()] = strings.ToUpper(columnMeta.DatabaseTypeName())
+	}
+	if err = tx.Exec(fmt.Sprintf("DELETE FROM %s", table)); err != nil {
+		return 0, err
+	}
+	var count int64
+	batch := make([]map[string]interface{}, 0, backupBatchSize)
+	flush := func() errors.Error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := tx.Create(&batch, dal.From(table)); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+	lines := bufio.NewReader(reader)
+	for {
+		line, e := lines.ReadBytes('\n')
+		if e != nil && e != io.EOF {
+			return 0, errors.Convert(e)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.UseNumber()
+			var row map[string]interface{}
+			if e := decoder.Decode(&row); e != nil {
+				return 0, errors.BadInput.Wrap(e, fmt.Sprintf("malformed row %d", count))
+			}
+			for column, value := range row {
+				columnType, ok := columnTypes[column]
+				if !ok {
+					return 0, errors.BadInput.New(fmt.Sprintf("column %s does not exist", column))
+				}
+				if row[column], err = convertBackupValue(columnType, value); err != nil {
+					return 0, errors.BadInput.Wrap(err, fmt.Sprintf("invalid value of %s in row %d", column, count))
+				}
+			}
+			batch = append(batch, row)
+			count++
+			if len(batch) >= backupBatchSize {
+				if err = flush(); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if e == io.EOF {
+			break
+		}
+	}
+	if err = flush(); err != nil {
+		return 0, err
+	}
+	if tx.Dialect() == "postgres" && strings.Contains(columnTypes["id"], "INT") {
+		// the sequence of the serial id is left behind the ids inserted explicitly
+		err = tx.Exec(
+			fmt.Sprintf("SELECT setval(pg_get_serial_sequence(?, 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM %s), false) WHERE pg_get_serial_sequence(?, 'id') IS NOT NULL", table),
+			table, table,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// convertBackupValue converts the json value to the type of the column, which might be of another dialect
+// than the one of the backup, i.e. booleans are integers in mysql
+func convertBackupValue(columnType string, value interface{}) (interface{}, errors.Error) {
+	if value == nil {
+		return nil, nil
+	}
+	text := fmt.Sprint(value)
+	switch {
+	case strings.Contains(columnType, "BOOL"):
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		b, e := strconv.ParseBool(text)
+		return b, errors.Convert(e)
+	case strings.Contains(columnType, "INT") || strings.Contains(columnType, "SERIAL"):
+		if b, ok := value.(bool); ok {
+			if b {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		i, e := strconv.ParseInt(text, 10, 64)
+		return i, errors.Convert(e)
+	case strings.Contains(columnType, "FLOAT"), strings.Contains(columnType, "DOUBLE"), strings.Contains(columnType, "REAL"),
+		strings.Contains(columnType, "DECIMAL"), strings.Contains(columnType, "NUMERIC"):
+		f, e := strconv.ParseFloat(text, 64)
+		return f, errors.Convert(e)
+	case strings.Contains(columnType, "DATE"), strings.Contains(columnType, "TIME"):
+		t, e := common.ConvertStringToTime(text)
+		return t, errors.Convert(e)
+	}
+	if number, ok := value.(json.Number); ok {
+		return number.String(), nil
+	}
+	return value, nil
+}
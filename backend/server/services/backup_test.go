@@ -0,0 +1,101 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertBackupValue(t *testing.T) {
+	testCases := []struct {
+		name       string
+		columnType string
+		value      interface{}
+		expected   interface{}
+	}{
+		{"null", "BIGINT", nil, nil},
+		{"mysql bool to postgres", "BOOL", json.Number("1"), true},
+		{"postgres bool to mysql", "TINYINT", true, 1},
+		{"bool", "BOOLEAN", false, false},
+		{"integer", "BIGINT UNSIGNED", json.Number("42"), int64(42)},
+		{"float", "DOUBLE PRECISION", json.Number("1.5"), 1.5},
+		{"numeric text", "VARCHAR", json.Number("7"), "7"},
+		{"text", "LONGTEXT", "hello", "hello"},
+		{"timestamp", "TIMESTAMPTZ", "2023-03-01T12:00:00Z", time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := convertBackupValue(tc.columnType, tc.value)
+			assert.Nil(t, err)
+			if expectedTime, ok := tc.expected.(time.Time); ok {
+				assert.True(t, expectedTime.Equal(actual.(time.Time)))
+				return
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+
+	_, err := convertBackupValue("INT", "abc")
+	assert.NotNil(t, err)
+}
+
+func TestReencrypt(t *testing.T) {
+	encrypted, err := plugin.Encrypt("old", "token")
+	assert.Nil(t, err)
+	reencrypted, err := reencrypt(encrypted, []string{"new", "old"}, "new")
+	assert.Nil(t, err)
+	plainText, err := plugin.Decrypt("new", reencrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", plainText)
+
+	_, err = reencrypt(encrypted, []string{"wrong"}, "new")
+	assert.NotNil(t, err)
+}
+
+func TestEncryptedTableOf(t *testing.T) {
+	dalgorm.Init("secret")
+	table, err := encryptedTableOf(&models.Blueprint{})
+	assert.Nil(t, err)
+	assert.Equal(t, "_devlake_blueprints", table.name)
+	assert.Equal(t, []string{"id"}, table.primaryKeys)
+	assert.ElementsMatch(t, []string{"plan", "before_plan", "after_plan"}, table.columns)
+
+	table, err = encryptedTableOf(&models.Project{})
+	assert.Nil(t, err)
+	assert.Empty(t, table.columns)
+}
+
+func TestFindBackupTables(t *testing.T) {
+	setupTestDb(t)
+	tables := findBackupTables(false)
+	assert.Contains(t, tables, models.Blueprint{}.TableName())
+	assert.Contains(t, tables, models.Project{}.TableName())
+	// the raw and tool layers they describe are not backed up
+	assert.NotContains(t, tables, models.CollectorLatestState{}.TableName())
+	assert.NotContains(t, tables, models.RawDataSync{}.TableName())
+	assert.NotContains(t, tables, models.SubtaskState{}.TableName())
+	assert.NotContains(t, tables, "issues")
+	assert.Contains(t, findBackupTables(true), "issues")
+}
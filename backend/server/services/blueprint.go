@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
 
@@ -53,7 +54,19 @@ type BlueprintJob struct {
 }
 
 func (bj BlueprintJob) Run() {
-	blueprint := bj.Blueprint
+	// all instances schedule the blueprints but only the leader triggers them in the cluster mode
+	if !isLeader() {
+		return
+	}
+	// the blueprint might have been modified through the api of other instances since it was scheduled
+	blueprint, err := GetBlueprint(bj.Blueprint.ID, false)
+	if err != nil {
+		blueprintLog.Error(err, fmt.Sprintf("failed to load blueprint:[%d] for cron job", bj.Blueprint.ID))
+		return
+	}
+	if !blueprint.Enable || blueprint.IsManual || blueprint.CronConfig != bj.Blueprint.CronConfig {
+		return
+	}
 	pipeline, err := createPipelineByBlueprint(blueprint, &blueprint.SyncPolicy)
 	if err == ErrEmptyPlan {
 		blueprintLog.Info("Empty plan, blueprint id:[%d] blueprint name:[%s]", blueprint.ID, blueprint.Name)
@@ -155,6 +168,10 @@ func validateBlueprintAndMakePlan(blueprint *models.Blueprint) errors.Error {
 		}
 	}
 
+	if err := validateBlueprintTriggers(blueprint); err != nil {
+		return err
+	}
+
 	if strings.ToLower(blueprint.CronConfig) == "manual" {
 		blueprint.IsManual = true
 	}
@@ -257,9 +274,44 @@ func DeleteBlueprint(id uint64) errors.Error {
 
 var blueprintReloadLock sync.Mutex
 var bpCronIdMap map[uint64]cron.EntryID
+var bpVersion string
+
+// getBlueprintsVersion changes whenever a blueprint is created, updated or deleted
+func getBlueprintsVersion() (string, errors.Error) {
+	count, err := db.Count(dal.From(&models.Blueprint{}))
+	if err != nil {
+		return "", err
+	}
+	var updatedAts []time.Time
+	err = db.Pluck("updated_at", &updatedAts, dal.From(&models.Blueprint{}), dal.Orderby("updated_at DESC"), dal.Limit(1))
+	if err != nil {
+		return "", err
+	}
+	version := fmt.Sprintf("%d", count)
+	if len(updatedAts) > 0 {
+		version += "@" + updatedAts[0].UTC().Format(time.RFC3339Nano)
+	}
+	return version, nil
+}
+
+// reloadBlueprintsIfChanged reloads the cronjobs if the blueprints were modified since the last reload
+func reloadBlueprintsIfChanged() errors.Error {
+	version, err := getBlueprintsVersion()
+	if err != nil {
+		return err
+	}
+	if version == bpVersion {
+		return nil
+	}
+	return ReloadBlueprints()
+}
 
 // ReloadBlueprints reloades cronjobs based on blueprints
 func ReloadBlueprints() (err errors.Error) {
+	bpVersion, err = getBlueprintsVersion()
+	if err != nil {
+		return err
+	}
 	enable := true
 	isManual := false
 	blueprints, _, err := bpManager.GetDbBlueprints(&services.GetBlueprintQuery{
@@ -0,0 +1,154 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// sources of the stages in the BlueprintPlanPreview
+const (
+	PLAN_SOURCE_BEFORE_PLAN = "beforePlan"
+	PLAN_SOURCE_PLAN        = "plan"
+	PLAN_SOURCE_AFTER_PLAN  = "afterPlan"
+)
+
+// BlueprintPlanPreview is the plan a blueprint would run if it was triggered now
+type BlueprintPlanPreview struct {
+	BlueprintId uint64                  `json:"blueprintId"`
+	Mode        string                  `json:"mode"`
+	SyncPolicy  models.SyncPolicy       `json:"syncPolicy"`
+	Plan        models.PipelinePlan     `json:"plan"`
+	Stages      []*PipelineStagePreview `json:"stages"`
+}
+
+// PipelineStagePreview is a stage of the plan along with where it comes from
+type PipelineStagePreview struct {
+	Source string                 `json:"source"`
+	Tasks  []*PipelineTaskPreview `json:"tasks"`
+}
+
+// PipelineTaskPreview lists all subtasks of the plugin and how they would run
+type PipelineTaskPreview struct {
+	Plugin   string                 `json:"plugin"`
+	Options  map[string]interface{} `json:"options"`
+	Subtasks []*SubtaskPreview      `json:"subtasks"`
+}
+
+// SubtaskPreview tells whether the subtask is enabled, and if so, in which mode and time range it would run
+type SubtaskPreview struct {
+	Name     string `json:"name"`
+	Enabled  bool   `json:"enabled"`
+	Required bool   `json:"required"`
+	*api.SyncPreview
+}
+
+// PreviewBlueprintPlan expands the plan of the blueprint the same way as TriggerBlueprint does without creating a pipeline
+func PreviewBlueprintPlan(id uint64, triggerSyncPolicy *models.TriggerSyncPolicy) (*BlueprintPlanPreview, errors.Error) {
+	blueprint, err := GetBlueprint(id, false)
+	if err != nil {
+		return nil, err
+	}
+	blueprint.SkipCollectors = triggerSyncPolicy.SkipCollectors
+	blueprint.FullSync = triggerSyncPolicy.FullSync
+	syncPolicy := blueprint.SyncPolicy
+	preview := &BlueprintPlanPreview{
+		BlueprintId: blueprint.ID,
+		Mode:        blueprint.Mode,
+		SyncPolicy:  syncPolicy,
+	}
+	sources := make([]string, 0)
+	if blueprint.Mode == models.BLUEPRINT_MODE_NORMAL {
+		preview.Plan, err = MakePlanForBlueprint(blueprint, &syncPolicy)
+		if err != nil {
+			return nil, err
+		}
+		for range blueprint.BeforePlan {
+			sources = append(sources, PLAN_SOURCE_BEFORE_PLAN)
+		}
+		for len(sources) < len(preview.Plan)-len(blueprint.AfterPlan) {
+			sources = append(sources, PLAN_SOURCE_PLAN)
+		}
+		for range blueprint.AfterPlan {
+			sources = append(sources, PLAN_SOURCE_AFTER_PLAN)
+		}
+	} else {
+		preview.Plan = blueprint.Plan
+		for range preview.Plan {
+			sources = append(sources, PLAN_SOURCE_PLAN)
+		}
+	}
+	for i, stage := range preview.Plan {
+		stagePreview := &PipelineStagePreview{Source: sources[i]}
+		for _, task := range stage {
+			taskPreview, err := previewPipelineTask(task, &syncPolicy)
+			if err != nil {
+				return nil, err
+			}
+			stagePreview.Tasks = append(stagePreview.Tasks, taskPreview)
+		}
+		preview.Stages = append(preview.Stages, stagePreview)
+	}
+	// sanitize only after the options being used for matching the states
+	if err := SanitizePipeline(&models.Pipeline{Plan: preview.Plan}); err != nil {
+		return nil, errors.Convert(err)
+	}
+	return preview, nil
+}
+
+func previewPipelineTask(task *models.PipelineTask, syncPolicy *models.SyncPolicy) (*PipelineTaskPreview, errors.Error) {
+	taskPreview := &PipelineTaskPreview{
+		Plugin:   task.Plugin,
+		Options:  task.Options,
+		Subtasks: make([]*SubtaskPreview, 0),
+	}
+	pluginMeta, err := plugin.GetPlugin(task.Plugin)
+	if err != nil {
+		return nil, err
+	}
+	pluginTask, ok := pluginMeta.(plugin.PluginTask)
+	if !ok {
+		return nil, errors.Default.New(fmt.Sprintf("plugin %s is not a PluginTask", task.Plugin))
+	}
+	subtaskMetas := pluginTask.SubTaskMetas()
+	enabled, err := runner.EnabledSubtasks(subtaskMetas, task.Subtasks, syncPolicy)
+	if err != nil {
+		return nil, err
+	}
+	for _, subtaskMeta := range subtaskMetas {
+		subtaskPreview := &SubtaskPreview{
+			Name:     subtaskMeta.Name,
+			Enabled:  enabled[subtaskMeta.Name],
+			Required: subtaskMeta.Required,
+		}
+		if subtaskPreview.Enabled {
+			subtaskPreview.SyncPreview, err = api.PreviewSubtaskSync(db, syncPolicy, task.Plugin, subtaskMeta.Name, task.Options)
+			if err != nil {
+				return nil, err
+			}
+		}
+		taskPreview.Subtasks = append(taskPreview.Subtasks, subtaskPreview)
+	}
+	return taskPreview, nil
+}
@@ -19,6 +19,7 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	coreModels "github.com/apache/incubator-devlake/core/models"
 	"github.com/stretchr/testify/assert"
@@ -147,3 +148,27 @@ func TestRemoveCollectorTasks(t *testing.T) {
 		},
 	}, removeCollectorTasks(plan1))
 }
+
+func TestGetBlueprintsVersion(t *testing.T) {
+	setupTestDb(t)
+	version, err := getBlueprintsVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, "0", version)
+
+	blueprint := &coreModels.Blueprint{Name: "test", CronConfig: "0 0 * * *"}
+	assert.Nil(t, db.Create(blueprint))
+	created, err := getBlueprintsVersion()
+	assert.Nil(t, err)
+	assert.NotEqual(t, version, created)
+
+	time.Sleep(10 * time.Millisecond)
+	blueprint.CronConfig = "0 1 * * *"
+	assert.Nil(t, db.Update(blueprint))
+	updated, err := getBlueprintsVersion()
+	assert.Nil(t, err)
+	assert.NotEqual(t, created, updated)
+
+	unchanged, err := getBlueprintsVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, updated, unchanged)
+}
@@ -0,0 +1,286 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// validateBlueprintTriggers makes sure the triggers are complete and would not trigger the blueprint itself
+func validateBlueprintTriggers(blueprint *models.Blueprint) errors.Error {
+	for _, trigger := range blueprint.Triggers {
+		if err := vld.Struct(trigger); err != nil {
+			return errors.BadInput.Wrap(err, "invalid blueprint trigger")
+		}
+		switch trigger.Type {
+		case models.BLUEPRINT_TRIGGER_BLUEPRINT:
+			if trigger.UpstreamBlueprintId == 0 {
+				return errors.BadInput.New("upstreamBlueprintId is required for BLUEPRINT triggers")
+			}
+			if _, err := bpManager.GetDbBlueprint(trigger.UpstreamBlueprintId); err != nil {
+				return errors.BadInput.Wrap(err, fmt.Sprintf("invalid upstream blueprint %d", trigger.UpstreamBlueprintId))
+			}
+			if blueprint.ID != 0 && isUpstreamBlueprint(blueprint.ID, trigger.UpstreamBlueprintId, map[uint64]bool{}) {
+				return errors.BadInput.New(fmt.Sprintf("blueprint %d would be triggered by itself", blueprint.ID))
+			}
+		case models.BLUEPRINT_TRIGGER_PLUGIN_EVENT:
+			if trigger.PluginName == "" || trigger.EventName == "" {
+				return errors.BadInput.New("pluginName and eventName are required for PLUGIN_EVENT triggers")
+			}
+		}
+	}
+	return nil
+}
+
+// isUpstreamBlueprint tells whether `target` is `blueprintId` itself or one of its upstream blueprints
+func isUpstreamBlueprint(target uint64, blueprintId uint64, visited map[uint64]bool) bool {
+	if target == blueprintId {
+		return true
+	}
+	if visited[blueprintId] {
+		return false
+	}
+	visited[blueprintId] = true
+	var upstreamIds []uint64
+	errors.Must(db.Pluck(
+		"upstream_blueprint_id", &upstreamIds,
+		dal.From(&models.BlueprintTrigger{}),
+		dal.Where("blueprint_id = ? AND type = ?", blueprintId, models.BLUEPRINT_TRIGGER_BLUEPRINT),
+	))
+	for _, upstreamId := range upstreamIds {
+		if isUpstreamBlueprint(target, upstreamId, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// debounceBlueprint runs the blueprint once no more triggering events arrived within its debounce window,
+// so a burst of events yields a single pipeline. The window is persisted, check runDueBlueprints
+func debounceBlueprint(blueprintId uint64, debounce int, reason string) errors.Error {
+	wait := time.Duration(debounce) * time.Second
+	if wait < time.Second {
+		wait = time.Second
+	}
+	err := db.CreateOrUpdate(&models.BlueprintDebounce{
+		BlueprintId: blueprintId,
+		RunAt:       time.Now().Add(wait),
+		Reason:      reason,
+	})
+	if err != nil {
+		return err
+	}
+	blueprintLog.Info("blueprint #%d triggered by %s, would run in %v unless triggered again", blueprintId, reason, wait)
+	return nil
+}
+
+// runDueBlueprints runs the triggered blueprints whose debounce windows passed
+func runDueBlueprints() errors.Error {
+	due := make([]*models.BlueprintDebounce, 0)
+	err := db.All(&due, dal.Where("run_at <= ?", time.Now()), dal.Orderby("run_at"))
+	if err != nil {
+		return err
+	}
+	for _, debounce := range due {
+		err = db.Delete(&models.BlueprintDebounce{}, dal.Where("blueprint_id = ? AND run_at <= ?", debounce.BlueprintId, time.Now()))
+		if err != nil {
+			return err
+		}
+		// the blueprint was triggered again in the meantime, which postponed the run
+		postponed, err := db.Count(dal.From(&models.BlueprintDebounce{}), dal.Where("blueprint_id = ?", debounce.BlueprintId))
+		if err != nil {
+			return err
+		}
+		if postponed > 0 {
+			continue
+		}
+		runTriggeredBlueprint(debounce.BlueprintId, debounce.Reason)
+	}
+	return nil
+}
+
+// runDueBlueprintsInLoop keeps running the due blueprints, only on the leader in the cluster mode
+func runDueBlueprintsInLoop() {
+	for {
+		time.Sleep(time.Second)
+		if !isLeader() {
+			continue
+		}
+		if err := runDueBlueprints(); err != nil {
+			blueprintLog.Error(err, "failed to run the triggered blueprints")
+		}
+	}
+}
+
+func runTriggeredBlueprint(blueprintId uint64, reason string) {
+	blueprint, err := GetBlueprint(blueprintId, false)
+	if err != nil {
+		blueprintLog.Error(err, "failed to load triggered blueprint #%d", blueprintId)
+		return
+	}
+	if !blueprint.Enable {
+		return
+	}
+	// no need to queue another pipeline if there is one waiting to be run
+	queued, err := db.Count(
+		dal.From(&models.Pipeline{}),
+		dal.Where("blueprint_id = ? AND status IN ?", blueprintId, []string{models.TASK_CREATED, models.TASK_RERUN}),
+	)
+	if err != nil {
+		blueprintLog.Error(err, "failed to count queued pipelines of blueprint #%d", blueprintId)
+		return
+	}
+	if queued > 0 {
+		blueprintLog.Info("blueprint #%d already has a pipeline in queue, skip the one triggered by %s", blueprintId, reason)
+		return
+	}
+	pipeline, err := createPipelineByBlueprint(blueprint, &blueprint.SyncPolicy)
+	if err != nil {
+		blueprintLog.Error(err, "failed to run blueprint #%d triggered by %s", blueprintId, reason)
+		return
+	}
+	blueprintLog.Info("blueprint #%d triggered by %s, pipeline #%d created", blueprintId, reason, pipeline.ID)
+}
+
+// triggerDownstreamBlueprints runs the blueprints depending on the blueprint of the finished pipeline,
+// once the latest pipelines of all their upstream blueprints succeeded after the downstream was run last time
+func triggerDownstreamBlueprints(pipeline *models.Pipeline) errors.Error {
+	if pipeline.BlueprintId == 0 || pipeline.Status != models.TASK_COMPLETED {
+		return nil
+	}
+	var downstreamIds []uint64
+	err := db.Pluck(
+		"blueprint_id", &downstreamIds,
+		dal.From(&models.BlueprintTrigger{}),
+		dal.Where("type = ? AND upstream_blueprint_id = ?", models.BLUEPRINT_TRIGGER_BLUEPRINT, pipeline.BlueprintId),
+	)
+	if err != nil {
+		return err
+	}
+	triggered := make(map[uint64]bool)
+	for _, downstreamId := range downstreamIds {
+		if triggered[downstreamId] {
+			continue
+		}
+		triggered[downstreamId] = true
+		downstream, err := bpManager.GetDbBlueprint(downstreamId)
+		if err != nil {
+			return err
+		}
+		if !downstream.Enable {
+			continue
+		}
+		ready, err := isUpstreamReady(downstream)
+		if err != nil {
+			return err
+		}
+		if ready {
+			err = debounceBlueprint(downstreamId, downstream.Debounce, fmt.Sprintf("blueprint #%d", pipeline.BlueprintId))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isUpstreamReady tells whether every upstream blueprint of the downstream has a successful latest pipeline
+// finished after the latest pipeline of the downstream was created, so one round of upstream runs triggers
+// the downstream once
+func isUpstreamReady(downstream *models.Blueprint) (bool, errors.Error) {
+	var since *time.Time
+	last := &models.Pipeline{}
+	err := db.First(last, dal.Where("blueprint_id = ?", downstream.ID), dal.Orderby("id DESC"))
+	if err == nil {
+		since = &last.CreatedAt
+	} else if !db.IsErrorNotFound(err) {
+		return false, err
+	}
+	for _, trigger := range downstream.Triggers {
+		if trigger.Type != models.BLUEPRINT_TRIGGER_BLUEPRINT {
+			continue
+		}
+		succeeded, err := isLatestPipelineSucceeded(trigger.UpstreamBlueprintId, since)
+		if err != nil {
+			return false, err
+		}
+		if !succeeded {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isLatestPipelineSucceeded tells whether the latest finished pipeline of the blueprint succeeded after `since`
+func isLatestPipelineSucceeded(blueprintId uint64, since *time.Time) (bool, errors.Error) {
+	latest := &models.Pipeline{}
+	err := db.First(
+		latest,
+		dal.Where("blueprint_id = ? AND status IN ?", blueprintId, models.FinishedTaskStatus),
+		dal.Orderby("id DESC"),
+	)
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if latest.Status != models.TASK_COMPLETED {
+		return false, nil
+	}
+	return since == nil || (latest.FinishedAt != nil && latest.FinishedAt.After(*since)), nil
+}
+
+// HandlePluginEvent runs the blueprints subscribing to the event published by a plugin,
+// e.g. a new deployment posted to the webhook plugin
+func HandlePluginEvent(pluginName string, eventName string, connectionId uint64) errors.Error {
+	triggers := make([]*models.BlueprintTrigger, 0)
+	err := db.All(
+		&triggers,
+		dal.Where(
+			"type = ? AND plugin_name = ? AND event_name = ? AND connection_id IN ?",
+			models.BLUEPRINT_TRIGGER_PLUGIN_EVENT, pluginName, eventName, []uint64{0, connectionId},
+		),
+	)
+	if err != nil {
+		return err
+	}
+	triggered := make(map[uint64]bool)
+	for _, trigger := range triggers {
+		if triggered[trigger.BlueprintId] {
+			continue
+		}
+		triggered[trigger.BlueprintId] = true
+		blueprint, err := bpManager.GetDbBlueprint(trigger.BlueprintId)
+		if err != nil {
+			return err
+		}
+		if blueprint.Enable {
+			err = debounceBlueprint(blueprint.ID, blueprint.Debounce, fmt.Sprintf("%s %s event of connection #%d", pluginName, eventName, connectionId))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
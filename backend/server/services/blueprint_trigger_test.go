@@ -0,0 +1,133 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestBlueprint(t *testing.T, name string, upstreamIds ...uint64) *models.Blueprint {
+	blueprint := &models.Blueprint{
+		Name:       name,
+		Mode:       models.BLUEPRINT_MODE_ADVANCED,
+		Enable:     true,
+		IsManual:   true,
+		CronConfig: "manual",
+		Plan:       models.PipelinePlan{{{Plugin: "test"}}},
+	}
+	require.Nil(t, db.Create(blueprint))
+	for _, upstreamId := range upstreamIds {
+		require.Nil(t, db.Create(&models.BlueprintTrigger{
+			BlueprintId:         blueprint.ID,
+			Type:                models.BLUEPRINT_TRIGGER_BLUEPRINT,
+			UpstreamBlueprintId: upstreamId,
+		}))
+	}
+	return blueprint
+}
+
+func finishTestPipeline(t *testing.T, blueprintId uint64, status string) *models.Pipeline {
+	now := time.Now()
+	pipeline := &models.Pipeline{Name: "test", BlueprintId: blueprintId, Status: status, FinishedAt: &now}
+	require.Nil(t, db.Create(pipeline))
+	return pipeline
+}
+
+func getTestDebounce(t *testing.T, blueprintId uint64) *models.BlueprintDebounce {
+	debounce := &models.BlueprintDebounce{}
+	err := db.First(debounce, dal.Where("blueprint_id = ?", blueprintId))
+	if db.IsErrorNotFound(err) {
+		return nil
+	}
+	require.Nil(t, err)
+	return debounce
+}
+
+func TestTriggerDownstreamBlueprints(t *testing.T) {
+	setupTestDb(t)
+	upstream1 := createTestBlueprint(t, "upstream1")
+	upstream2 := createTestBlueprint(t, "upstream2")
+	downstream := createTestBlueprint(t, "downstream", upstream1.ID, upstream2.ID)
+
+	// upstream2 never ran
+	assert.Nil(t, triggerDownstreamBlueprints(finishTestPipeline(t, upstream1.ID, models.TASK_COMPLETED)))
+	assert.Nil(t, getTestDebounce(t, downstream.ID))
+
+	// both upstreams succeeded
+	assert.Nil(t, triggerDownstreamBlueprints(finishTestPipeline(t, upstream2.ID, models.TASK_COMPLETED)))
+	assert.NotNil(t, getTestDebounce(t, downstream.ID))
+
+	// the downstream ran, a new round of both upstreams is required
+	require.Nil(t, db.Delete(&models.BlueprintDebounce{}, dal.Where("blueprint_id = ?", downstream.ID)))
+	time.Sleep(10 * time.Millisecond)
+	finishTestPipeline(t, downstream.ID, models.TASK_COMPLETED)
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, triggerDownstreamBlueprints(finishTestPipeline(t, upstream1.ID, models.TASK_COMPLETED)))
+	assert.Nil(t, getTestDebounce(t, downstream.ID))
+
+	// the latest pipeline of upstream2 failed
+	finishTestPipeline(t, upstream2.ID, models.TASK_FAILED)
+	assert.Nil(t, triggerDownstreamBlueprints(finishTestPipeline(t, upstream1.ID, models.TASK_COMPLETED)))
+	assert.Nil(t, getTestDebounce(t, downstream.ID))
+
+	assert.Nil(t, triggerDownstreamBlueprints(finishTestPipeline(t, upstream2.ID, models.TASK_COMPLETED)))
+	assert.NotNil(t, getTestDebounce(t, downstream.ID))
+
+	// failed pipelines trigger nothing
+	require.Nil(t, db.Delete(&models.BlueprintDebounce{}, dal.Where("blueprint_id = ?", downstream.ID)))
+	assert.Nil(t, triggerDownstreamBlueprints(finishTestPipeline(t, upstream2.ID, models.TASK_FAILED)))
+	assert.Nil(t, getTestDebounce(t, downstream.ID))
+}
+
+func TestDebounceBlueprint(t *testing.T) {
+	setupTestDb(t)
+	blueprint := createTestBlueprint(t, "debounced")
+
+	assert.Nil(t, debounceBlueprint(blueprint.ID, 60, "first event"))
+	first := getTestDebounce(t, blueprint.ID)
+	require.NotNil(t, first)
+	assert.Equal(t, "first event", first.Reason)
+	// triggered again within the window, the run is postponed
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, debounceBlueprint(blueprint.ID, 60, "second event"))
+	second := getTestDebounce(t, blueprint.ID)
+	require.NotNil(t, second)
+	assert.True(t, second.RunAt.After(first.RunAt))
+	assert.Equal(t, "second event", second.Reason)
+
+	// not due yet
+	assert.Nil(t, runDueBlueprints())
+	assert.NotNil(t, getTestDebounce(t, blueprint.ID))
+	count, err := db.Count(dal.From(&models.Pipeline{}), dal.Where("blueprint_id = ?", blueprint.ID))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// the debounce window passed, possibly while the instance was down
+	require.Nil(t, db.UpdateColumn(&models.BlueprintDebounce{}, "run_at", time.Now().Add(-time.Second), dal.Where("blueprint_id = ?", blueprint.ID)))
+	assert.Nil(t, runDueBlueprints())
+	assert.Nil(t, getTestDebounce(t, blueprint.ID))
+	count, err = db.Count(dal.From(&models.Pipeline{}), dal.Where("blueprint_id = ?", blueprint.ID))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
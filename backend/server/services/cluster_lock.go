@@ -0,0 +1,144 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+const leaderLockName = "leader"
+
+var leader atomic.Bool
+
+// runningLocks holds the names of the locks taken by runWithLock in the current instance
+var runningLocks sync.Map
+
+// isLeader tells whether the current instance should run the jobs that must not be run by more than one instance,
+// which is always the case in the standalone mode since the database is locked by a single instance
+func isLeader() bool {
+	return !clusterMode || leader.Load()
+}
+
+// tryLock takes the named lock for the current worker if nobody else is holding it or renews it if the current
+// worker is already holding it, the lock is always granted in the standalone mode
+func tryLock(name string) (bool, errors.Error) {
+	if !clusterMode {
+		return true, nil
+	}
+	now := time.Now()
+	expiresAt := now.Add(leaseDuration)
+	err := db.UpdateColumns(&models.ClusterLock{}, []dal.DalSet{
+		{ColumnName: "worker_id", Value: workerId},
+		{ColumnName: "lease_expires_at", Value: expiresAt},
+	}, dal.Where("name = ? AND (worker_id = ? OR lease_expires_at IS NULL OR lease_expires_at < ?)", name, workerId, now))
+	if err != nil {
+		return false, err
+	}
+	lock := &models.ClusterLock{}
+	err = db.First(lock, dal.Where("name = ?", name))
+	if db.IsErrorNotFound(err) {
+		err = db.Create(&models.ClusterLock{
+			Name:  name,
+			Lease: models.Lease{WorkerId: workerId, LeaseExpiresAt: &expiresAt},
+		})
+		if err != nil && db.IsDuplicationError(err) {
+			// another worker created the lock first
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	return lock.WorkerId == workerId, nil
+}
+
+// unlock lets the lock held by the current worker expire immediately
+func unlock(name string) {
+	if !clusterMode {
+		return
+	}
+	err := db.UpdateColumn(&models.ClusterLock{}, "lease_expires_at", nil, dal.Where("name = ? AND worker_id = ?", name, workerId))
+	if err != nil {
+		globalPipelineLog.Error(err, "worker %s failed to release lock %s", workerId, name)
+	}
+}
+
+// runWithLock runs `fn` only if the named lock could be taken, the lock is kept alive while `fn` is running.
+// It excludes the other goroutines of the current instance as well since tryLock is granted to the holder.
+func runWithLock(name string, fn func() errors.Error) (bool, errors.Error) {
+	if _, running := runningLocks.LoadOrStore(name, true); running {
+		return false, nil
+	}
+	defer runningLocks.Delete(name)
+	locked, err := tryLock(name)
+	if err != nil || !locked {
+		return false, err
+	}
+	defer unlock(name)
+	done := make(chan struct{})
+	defer close(done)
+	if clusterMode {
+		go func() {
+			ticker := time.NewTicker(leaseDuration / 3)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					locked, err := tryLock(name)
+					if err != nil {
+						globalPipelineLog.Error(err, "worker %s failed to renew lock %s", workerId, name)
+					} else if !locked {
+						globalPipelineLog.Warn(nil, "worker %s lost lock %s", workerId, name)
+					}
+				}
+			}
+		}()
+	}
+	return true, fn()
+}
+
+// leaderElectionInLoop keeps trying to become or to stay the leader of the cluster, the leader triggers the
+// blueprints on schedule, resends the pending notifications and enforces the raw data retention policy
+func leaderElectionInLoop() {
+	for {
+		elected, err := tryLock(leaderLockName)
+		if err != nil {
+			globalPipelineLog.Error(err, "worker %s failed to run for the leader", workerId)
+		}
+		if elected != leader.Load() {
+			globalPipelineLog.Info("worker %s is the leader: %v", workerId, elected)
+		}
+		leader.Store(elected)
+		if elected {
+			// the blueprints might be modified through the api of other instances
+			if err = reloadBlueprintsIfChanged(); err != nil {
+				globalPipelineLog.Error(err, "leader %s failed to reload blueprints", workerId)
+			}
+		}
+		time.Sleep(leaseDuration / 3)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryLock(t *testing.T) {
+	setupWorkerTest(t, "worker1")
+	locked, err := tryLock("test")
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	// renewing by the holder
+	locked, err = tryLock("test")
+	assert.Nil(t, err)
+	assert.True(t, locked)
+
+	workerId = "worker2"
+	locked, err = tryLock("test")
+	assert.Nil(t, err)
+	assert.False(t, locked)
+	// other locks are independent
+	locked, err = tryLock("other")
+	assert.Nil(t, err)
+	assert.True(t, locked)
+
+	// the lock of worker1 expired
+	require.Nil(t, db.UpdateColumn(&models.ClusterLock{}, "lease_expires_at", time.Now().Add(-time.Second), dal.Where("name = ?", "test")))
+	locked, err = tryLock("test")
+	assert.Nil(t, err)
+	assert.True(t, locked)
+
+	// released by worker2
+	unlock("test")
+	workerId = "worker1"
+	locked, err = tryLock("test")
+	assert.Nil(t, err)
+	assert.True(t, locked)
+}
+
+func TestRunWithLock(t *testing.T) {
+	setupWorkerTest(t, "worker1")
+	var ran bool
+	locked, err := runWithLock("test", func() errors.Error {
+		ran = true
+		// the lock is held while running
+		workerId = "worker2"
+		defer func() { workerId = "worker1" }()
+		locked, err := tryLock("test")
+		assert.Nil(t, err)
+		assert.False(t, locked)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	assert.True(t, ran)
+
+	// released once done
+	workerId = "worker2"
+	locked, err = tryLock("test")
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	ran = false
+	workerId = "worker1"
+	locked, err = runWithLock("test", func() errors.Error {
+		ran = true
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.False(t, locked)
+	assert.False(t, ran)
+}
+
+func TestIsLeader(t *testing.T) {
+	assert.True(t, isLeader())
+	setupWorkerTest(t, "worker1")
+	defer leader.Store(false)
+	assert.False(t, isLeader())
+	leader.Store(true)
+	assert.True(t, isLeader())
+}
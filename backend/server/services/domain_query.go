@@ -0,0 +1,477 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"github.com/apache/incubator-devlake/helpers/domainlayerhelper"
+)
+
+const (
+	defaultDomainQueryLimit = 100
+	maxDomainQueryLimit     = 1000
+)
+
+var domainQueryReservedParams = map[string]bool{
+	"fields":  true,
+	"sort":    true,
+	"limit":   true,
+	"cursor":  true,
+	"project": true,
+}
+
+var domainFilterOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"gt":   ">",
+	"gte":  ">=",
+	"lt":   "<",
+	"lte":  "<=",
+	"in":   "IN",
+	"like": "LIKE",
+	"null": "IS NULL",
+}
+
+var domainTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// DomainColumn describes a column of a domain layer table
+type DomainColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	PrimaryKey bool   `json:"primaryKey"`
+}
+
+// DomainTable describes a domain layer table available for querying
+type DomainTable struct {
+	Name    string         `json:"name"`
+	Columns []DomainColumn `json:"columns"`
+}
+
+// DomainFilter filters rows by `Column Operator Value`, values of the `in` operator are comma separated,
+// and the `null` operator takes `true` or `false`
+type DomainFilter struct {
+	Column   string
+	Operator string
+	Value    string
+}
+
+// DomainQuery queries rows of a domain layer table
+type DomainQuery struct {
+	Table   string
+	Fields  []string
+	Filters []DomainFilter
+	// Sort is the column to sort by, prefixed with `-` for the descending order, rows are sorted by the primary key if empty
+	Sort   string
+	Limit  int
+	Cursor string
+	// Project limits the rows to the scopes of the project
+	Project string
+}
+
+// DomainQueryResult is a page of the rows, NextCursor is empty on the last page
+type DomainQueryResult struct {
+	Rows       []map[string]interface{} `json:"rows"`
+	NextCursor string                   `json:"nextCursor,omitempty"`
+}
+
+// domainCursor points to the last row of the previous page
+type domainCursor struct {
+	Sort string        `json:"o"`
+	Last interface{}   `json:"s"`
+	Keys []interface{} `json:"k"`
+}
+
+// ParseDomainQuery parses the query from url query parameters, parameters other than `fields`, `sort`, `limit`,
+// `cursor` and `project` are filters in the form of `<column>=<value>` or `<column>.<operator>=<value>`
+func ParseDomainQuery(table string, values url.Values) (*DomainQuery, errors.Error) {
+	query := &DomainQuery{
+		Table:   table,
+		Sort:    values.Get("sort"),
+		Cursor:  values.Get("cursor"),
+		Project: values.Get("project"),
+		Limit:   defaultDomainQueryLimit,
+	}
+	if fields := values.Get("fields"); fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			query.Fields = append(query.Fields, strings.TrimSpace(field))
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 || n > maxDomainQueryLimit {
+			return nil, errors.BadInput.New(fmt.Sprintf("limit must be between 1 and %d", maxDomainQueryLimit))
+		}
+		query.Limit = n
+	}
+	for key, vals := range values {
+		if domainQueryReservedParams[key] {
+			continue
+		}
+		column, operator, found := strings.Cut(key, ".")
+		if !found {
+			operator = "eq"
+		}
+		if _, ok := domainFilterOperators[operator]; !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown operator %s of %s", operator, key))
+		}
+		for _, value := range vals {
+			query.Filters = append(query.Filters, DomainFilter{Column: column, Operator: operator, Value: value})
+		}
+	}
+	// keep the filters in a stable order for the sql to be cached
+	sort.SliceStable(query.Filters, func(i, j int) bool {
+		return query.Filters[i].Column < query.Filters[j].Column
+	})
+	return query, nil
+}
+
+func findDomainTable(table string) dal.Tabler {
+	for _, tabler := range domaininfo.GetDomainTablesInfo() {
+		if tabler.TableName() == table {
+			return tabler
+		}
+	}
+	return nil
+}
+
+func getDomainColumns(tabler dal.Tabler) ([]DomainColumn, errors.Error) {
+	columnMetas, err := db.GetColumns(tabler, nil)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]DomainColumn, 0, len(columnMetas))
+	for _, columnMeta := range columnMetas {
+		isPrimaryKey, _ := columnMeta.PrimaryKey()
+		columns = append(columns, DomainColumn{
+			Name:       columnMeta.Name(),
+			Type:       strings.ToLower(columnMeta.DatabaseTypeName()),
+			PrimaryKey: isPrimaryKey,
+		})
+	}
+	return columns, nil
+}
+
+// GetDomainTables returns all domain layer tables available for querying
+func GetDomainTables() ([]DomainTable, errors.Error) {
+	var tables []DomainTable
+	for _, tabler := range domaininfo.GetDomainTablesInfo() {
+		columns, err := getDomainColumns(tabler)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, DomainTable{Name: tabler.TableName(), Columns: columns})
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+	return tables, nil
+}
+
+// QueryDomainTable returns a page of rows of the domain layer table, pages are navigated by the NextCursor
+// which is stable even if rows are inserted or deleted in between
+func QueryDomainTable(query *DomainQuery) (*DomainQueryResult, errors.Error) {
+	tabler := findDomainTable(query.Table)
+	if tabler == nil {
+		return nil, errors.NotFound.New(fmt.Sprintf("domain layer table %s not found", query.Table))
+	}
+	columns, err := getDomainColumns(tabler)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make(map[string]string, len(columns))
+	var primaryKeys []string
+	for _, column := range columns {
+		columnTypes[column.Name] = column.Type
+		if column.PrimaryKey {
+			primaryKeys = append(primaryKeys, column.Name)
+		}
+	}
+	if len(primaryKeys) == 0 {
+		return nil, errors.Default.New(fmt.Sprintf("domain layer table %s has no primary key", query.Table))
+	}
+	table := query.Table
+	// columns are always qualified by the table name in case of reserved identifiers
+	qualify := func(column string) string {
+		return fmt.Sprintf("%s.%s", table, column)
+	}
+
+	// fields, the primary keys and the sort column are always selected for the cursor
+	fields := query.Fields
+	if len(fields) == 0 {
+		for _, column := range columns {
+			fields = append(fields, column.Name)
+		}
+	}
+	sortColumn := strings.TrimPrefix(query.Sort, "-")
+	descending := sortColumn != query.Sort
+	if sortColumn != "" {
+		if _, ok := columnTypes[sortColumn]; !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown sort column %s", sortColumn))
+		}
+	}
+	selected := make(map[string]bool)
+	var selects []string
+	for _, field := range append(append(fields, primaryKeys...), sortColumn) {
+		if field == "" || selected[field] {
+			continue
+		}
+		if _, ok := columnTypes[field]; !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown field %s", field))
+		}
+		selected[field] = true
+		selects = append(selects, qualify(field))
+	}
+	clauses := []dal.Clause{dal.Select(strings.Join(selects, ", ")), dal.From(table)}
+
+	// filters
+	for _, filter := range query.Filters {
+		columnType, ok := columnTypes[filter.Column]
+		if !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown filter column %s", filter.Column))
+		}
+		clause, err := domainFilterClause(qualify(filter.Column), columnType, filter)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, clause)
+	}
+
+	// project
+	if query.Project != "" {
+		projectFilter, err := domainlayerhelper.NewProjectFilter(db, query.Project)
+		if err != nil {
+			return nil, err
+		}
+		existing := make(map[string]bool, len(columnTypes))
+		for column := range columnTypes {
+			existing[column] = true
+		}
+		clause, ok := projectFilter.Clause(table, existing)
+		if !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("%s could not be filtered by project", table))
+		}
+		clauses = append(clauses, clause)
+	}
+
+	// cursor
+	keyColumns := make([]string, len(primaryKeys))
+	keyPlaceholders := make([]string, len(primaryKeys))
+	for i, pk := range primaryKeys {
+		keyColumns[i] = qualify(pk)
+		keyPlaceholders[i] = "?"
+	}
+	keys := fmt.Sprintf("(%s)", strings.Join(keyColumns, ", "))
+	keyParams := fmt.Sprintf("(%s)", strings.Join(keyPlaceholders, ", "))
+	comparator, direction := ">", "ASC"
+	if descending {
+		comparator, direction = "<", "DESC"
+	}
+	if query.Cursor != "" {
+		cursor, err := decodeDomainCursor(query.Cursor, query.Sort, primaryKeys, columnTypes)
+		if err != nil {
+			return nil, err
+		}
+		if sortColumn == "" {
+			clauses = append(clauses, dal.Where(fmt.Sprintf("%s %s %s", keys, comparator, keyParams), cursor.Keys...))
+		} else if cursor.Last == nil {
+			// rows with null values come last
+			clauses = append(clauses, dal.Where(
+				fmt.Sprintf("%s IS NULL AND %s %s %s", qualify(sortColumn), keys, comparator, keyParams),
+				cursor.Keys...,
+			))
+		} else {
+			s := qualify(sortColumn)
+			params := append([]interface{}{cursor.Last, cursor.Last}, cursor.Keys...)
+			clauses = append(clauses, dal.Where(
+				fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s %s) OR %s IS NULL)", s, comparator, s, keys, comparator, keyParams, s),
+				params...,
+			))
+		}
+	}
+	orders := make([]string, 0, len(keyColumns)+2)
+	if sortColumn != "" {
+		s := qualify(sortColumn)
+		orders = append(orders, fmt.Sprintf("CASE WHEN %s IS NULL THEN 1 ELSE 0 END", s), fmt.Sprintf("%s %s", s, direction))
+	}
+	for _, keyColumn := range keyColumns {
+		orders = append(orders, fmt.Sprintf("%s %s", keyColumn, direction))
+	}
+	clauses = append(clauses, dal.Orderby(strings.Join(orders, ", ")), dal.Limit(query.Limit+1))
+
+	var rows []map[string]interface{}
+	err = db.All(&rows, clauses...)
+	if err != nil {
+		return nil, err
+	}
+	result := &DomainQueryResult{Rows: rows}
+	if len(rows) > query.Limit {
+		result.Rows = rows[:query.Limit]
+		last := result.Rows[query.Limit-1]
+		cursor := &domainCursor{Sort: query.Sort}
+		if sortColumn != "" {
+			cursor.Last = normalizeDomainValue(last[sortColumn])
+		}
+		for _, pk := range primaryKeys {
+			cursor.Keys = append(cursor.Keys, normalizeDomainValue(last[pk]))
+		}
+		if result.NextCursor, err = encodeDomainCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+	requested := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		requested[field] = true
+	}
+	for _, row := range result.Rows {
+		for column, value := range row {
+			if requested[column] {
+				row[column] = normalizeDomainValue(value)
+			} else {
+				delete(row, column)
+			}
+		}
+	}
+	return result, nil
+}
+
+func domainFilterClause(column string, columnType string, filter DomainFilter) (dal.Clause, errors.Error) {
+	operator := domainFilterOperators[filter.Operator]
+	switch filter.Operator {
+	case "null":
+		isNull, err := strconv.ParseBool(filter.Value)
+		if err != nil {
+			return dal.Clause{}, errors.BadInput.New(fmt.Sprintf("%s.null takes true or false", filter.Column))
+		}
+		if !isNull {
+			operator = "IS NOT NULL"
+		}
+		return dal.Where(fmt.Sprintf("%s %s", column, operator)), nil
+	case "in":
+		var values []interface{}
+		for _, text := range strings.Split(filter.Value, ",") {
+			value, err := convertDomainValue(columnType, text)
+			if err != nil {
+				return dal.Clause{}, errors.BadInput.Wrap(err, fmt.Sprintf("invalid value of %s", filter.Column))
+			}
+			values = append(values, value)
+		}
+		return dal.Where(fmt.Sprintf("%s IN ?", column), values), nil
+	case "like":
+		return dal.Where(fmt.Sprintf("%s LIKE ?", column), filter.Value), nil
+	}
+	value, err := convertDomainValue(columnType, filter.Value)
+	if err != nil {
+		return dal.Clause{}, errors.BadInput.Wrap(err, fmt.Sprintf("invalid value of %s", filter.Column))
+	}
+	return dal.Where(fmt.Sprintf("%s %s ?", column, operator), value), nil
+}
+
+// convertDomainValue converts the text to the type of the column
+func convertDomainValue(columnType string, text string) (interface{}, error) {
+	columnType = strings.ToUpper(columnType)
+	switch {
+	case strings.Contains(columnType, "BOOL"):
+		return strconv.ParseBool(text)
+	case strings.Contains(columnType, "INT") && !strings.Contains(columnType, "POINT") && !strings.Contains(columnType, "INTERVAL"):
+		if b, err := strconv.ParseBool(text); err == nil && columnType == "TINYINT" {
+			return b, nil
+		}
+		return strconv.ParseInt(text, 10, 64)
+	case strings.Contains(columnType, "FLOAT"), strings.Contains(columnType, "DOUBLE"),
+		strings.Contains(columnType, "REAL"), strings.Contains(columnType, "DECIMAL"), strings.Contains(columnType, "NUMERIC"):
+		return strconv.ParseFloat(text, 64)
+	case strings.Contains(columnType, "DATE"), strings.Contains(columnType, "TIME"):
+		for _, layout := range domainTimeLayouts {
+			if t, err := time.Parse(layout, text); err == nil {
+				return t, nil
+			}
+		}
+		return nil, errors.BadInput.New(fmt.Sprintf("%s is not a valid time, expecting RFC3339 or YYYY-MM-DD", text))
+	}
+	return text, nil
+}
+
+// normalizeDomainValue converts the scanned value to a json friendly one
+func normalizeDomainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case driver.Valuer:
+		if value, err := v.Value(); err == nil {
+			return normalizeDomainValue(value)
+		}
+	}
+	return value
+}
+
+func encodeDomainCursor(cursor *domainCursor) (string, errors.Error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", errors.Convert(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeDomainCursor(text string, sortBy string, primaryKeys []string, columnTypes map[string]string) (*domainCursor, errors.Error) {
+	invalid := errors.BadInput.New("invalid cursor")
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, invalid
+	}
+	cursor := &domainCursor{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if decoder.Decode(cursor) != nil || cursor.Sort != sortBy || len(cursor.Keys) != len(primaryKeys) {
+		return nil, invalid
+	}
+	// values are converted back to the column types since json doesn't tell numbers from times
+	convert := func(column string, value interface{}) (interface{}, errors.Error) {
+		if value == nil {
+			return nil, nil
+		}
+		converted, err := convertDomainValue(columnTypes[column], fmt.Sprint(value))
+		if err != nil {
+			return nil, invalid
+		}
+		return converted, nil
+	}
+	if sortColumn := strings.TrimPrefix(sortBy, "-"); sortColumn != "" {
+		if cursor.Last, err = convert(sortColumn, cursor.Last); err != nil {
+			return nil, invalid
+		}
+	}
+	for i, pk := range primaryKeys {
+		if cursor.Keys[i], err = convert(pk, cursor.Keys[i]); err != nil {
+			return nil, invalid
+		}
+	}
+	return cursor, nil
+}
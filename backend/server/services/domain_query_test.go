@@ -0,0 +1,79 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDomainQuery(t *testing.T) {
+	values, _ := url.ParseQuery("fields=id,title&sort=-created_date&limit=10&project=p&status=DONE&type.in=BUG,INCIDENT&story_point.gte=3")
+	query, err := ParseDomainQuery("issues", values)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id", "title"}, query.Fields)
+	assert.Equal(t, "-created_date", query.Sort)
+	assert.Equal(t, 10, query.Limit)
+	assert.Equal(t, "p", query.Project)
+	assert.Equal(t, []DomainFilter{
+		{Column: "status", Operator: "eq", Value: "DONE"},
+		{Column: "story_point", Operator: "gte", Value: "3"},
+		{Column: "type", Operator: "in", Value: "BUG,INCIDENT"},
+	}, query.Filters)
+
+	for _, qs := range []string{"limit=0", "limit=1001", "status.regexp=DONE"} {
+		values, _ = url.ParseQuery(qs)
+		_, err = ParseDomainQuery("issues", values)
+		assert.NotNil(t, err, qs)
+	}
+}
+
+func TestConvertDomainValue(t *testing.T) {
+	value, err := convertDomainValue("bigint", "42")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), value)
+	value, err = convertDomainValue("datetime", "2024-01-02")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), value)
+	value, err = convertDomainValue("varchar", "DONE")
+	assert.Nil(t, err)
+	assert.Equal(t, "DONE", value)
+	_, err = convertDomainValue("int8", "abc")
+	assert.NotNil(t, err)
+}
+
+func TestDomainCursor(t *testing.T) {
+	columnTypes := map[string]string{"id": "bigint", "created_date": "datetime"}
+	last := time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	text, err := encodeDomainCursor(&domainCursor{Sort: "-created_date", Last: last, Keys: []interface{}{int64(12345678901)}})
+	assert.Nil(t, err)
+
+	cursor, err := decodeDomainCursor(text, "-created_date", []string{"id"}, columnTypes)
+	assert.Nil(t, err)
+	assert.Equal(t, last, cursor.Last)
+	assert.Equal(t, []interface{}{int64(12345678901)}, cursor.Keys)
+
+	// cursors are bound to the sort
+	_, err = decodeDomainCursor(text, "created_date", []string{"id"}, columnTypes)
+	assert.NotNil(t, err)
+	_, err = decodeDomainCursor("not a cursor", "", []string{"id"}, columnTypes)
+	assert.NotNil(t, err)
+}
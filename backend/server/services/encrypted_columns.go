@@ -0,0 +1,108 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm/schema"
+)
+
+// frameworkEncryptedModels are the framework models having fields with the `encdec` serializer
+var frameworkEncryptedModels = []dal.Tabler{
+	&models.Blueprint{},
+	&models.Pipeline{},
+	&models.Task{},
+	&models.NotificationSubscription{},
+}
+
+// acceptedEncryptionSecrets returns the ENCRYPTION_SECRET followed by the previous secrets still accepted for decryption
+func acceptedEncryptionSecrets() []string {
+	return append(
+		[]string{cfg.GetString(plugin.EncodeKeyEnvStr)},
+		plugin.SplitEncryptionSecrets(cfg.GetString(plugin.PreviousEncodeKeysEnvStr))...,
+	)
+}
+
+// encryptedTable holds the columns encrypted by the `encdec` serializer of a table
+type encryptedTable struct {
+	name        string
+	primaryKeys []string
+	columns     []string
+}
+
+// findEncryptedTables returns the tables having columns encrypted by the `encdec` serializer, the models of
+// the framework and all plugins are inspected
+func findEncryptedTables() (map[string]*encryptedTable, errors.Error) {
+	tablers := append([]dal.Tabler{}, frameworkEncryptedModels...)
+	for _, pluginMeta := range plugin.AllPlugins() {
+		if pluginModel, ok := pluginMeta.(plugin.PluginModel); ok {
+			tablers = append(tablers, pluginModel.GetTablesInfo()...)
+		}
+		if pluginSrc, ok := pluginMeta.(plugin.PluginSource); ok {
+			if connection := pluginSrc.Connection(); connection != nil {
+				tablers = append(tablers, connection)
+			}
+		}
+	}
+	encrypted := make(map[string]*encryptedTable)
+	for _, tabler := range tablers {
+		table, err := encryptedTableOf(tabler)
+		if err != nil {
+			return nil, err
+		}
+		if len(table.columns) == 0 {
+			continue
+		}
+		if existing, ok := encrypted[table.name]; ok {
+			for _, column := range table.columns {
+				if !slices.Contains(existing.columns, column) {
+					existing.columns = append(existing.columns, column)
+				}
+			}
+			table = existing
+		}
+		sort.Strings(table.columns)
+		encrypted[table.name] = table
+	}
+	return encrypted, nil
+}
+
+func encryptedTableOf(tabler dal.Tabler) (*encryptedTable, errors.Error) {
+	s, err := schema.Parse(models.UnwrapObject(tabler), &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to parse the model of %s", tabler.TableName()))
+	}
+	table := &encryptedTable{name: tabler.TableName()}
+	for _, field := range s.PrimaryFields {
+		table.primaryKeys = append(table.primaryKeys, field.DBName)
+	}
+	for _, field := range s.Fields {
+		if field.DBName != "" && field.TagSettings["SERIALIZER"] == "encdec" {
+			table.columns = append(table.columns, field.DBName)
+		}
+	}
+	return table, nil
+}
@@ -0,0 +1,185 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	"golang.org/x/exp/slices"
+)
+
+// EncryptionRotationTable tells how many rows of a table were re-encrypted
+type EncryptionRotationTable struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// EncryptionRotationReport lists the tables rewritten by the rotation, tables in which all values were
+// encrypted by the ENCRYPTION_SECRET already are left untouched
+type EncryptionRotationReport struct {
+	ScannedTables   []string                   `json:"scannedTables"`
+	RewrittenTables []*EncryptionRotationTable `json:"rewrittenTables"`
+	TotalRows       int64                      `json:"totalRows"`
+}
+
+type encryptionRotationRow struct {
+	keys   []interface{}
+	values map[string]string
+}
+
+// RotateEncryptionSecret re-encrypts all values encrypted by the secrets in ENCRYPTION_SECRET_PREVIOUS with
+// the ENCRYPTION_SECRET in one transaction. To rotate the secret:
+//  1. set ENCRYPTION_SECRET to the new secret and ENCRYPTION_SECRET_PREVIOUS to the old one, then restart
+//     DevLake, values encrypted by either of them could be read from now on
+//  2. call the rotation
+//  3. remove ENCRYPTION_SECRET_PREVIOUS and restart DevLake
+func RotateEncryptionSecret() (report *EncryptionRotationReport, err errors.Error) {
+	secrets := acceptedEncryptionSecrets()
+	if secrets[0] == "" {
+		return nil, errors.BadInput.New(fmt.Sprintf("%s is required", plugin.EncodeKeyEnvStr))
+	}
+	if len(secrets) == 1 {
+		return nil, errors.BadInput.New(fmt.Sprintf("%s must be set to the secrets being rotated", plugin.PreviousEncodeKeysEnvStr))
+	}
+	encryptedTables, err := findEncryptedTables()
+	if err != nil {
+		return nil, err
+	}
+	tableNames := make([]string, 0, len(encryptedTables))
+	for name := range encryptedTables {
+		tableNames = append(tableNames, name)
+	}
+	sort.Strings(tableNames)
+
+	report = &EncryptionRotationReport{}
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	for _, name := range tableNames {
+		table := encryptedTables[name]
+		if !tx.HasTable(name) {
+			continue
+		}
+		report.ScannedTables = append(report.ScannedTables, name)
+		rows, err := findRowsToRotate(tx, table, secrets)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to read %s", name))
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		rotated := &EncryptionRotationTable{Table: name}
+		for _, row := range rows {
+			if err = updateRotatedRow(tx, table, row); err != nil {
+				return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to update %s", name))
+			}
+			for column := range row.values {
+				if !slices.Contains(rotated.Columns, column) {
+					rotated.Columns = append(rotated.Columns, column)
+				}
+			}
+		}
+		sort.Strings(rotated.Columns)
+		rotated.Rows = int64(len(rows))
+		report.RewrittenTables = append(report.RewrittenTables, rotated)
+		report.TotalRows += rotated.Rows
+		logger.Info("re-encrypted %d rows of %s", rotated.Rows, name)
+	}
+	return report, nil
+}
+
+// findRowsToRotate returns the rows having values not encrypted by the first secret, re-encrypted by it
+func findRowsToRotate(tx dal.Dal, table *encryptedTable, secrets []string) ([]*encryptionRotationRow, errors.Error) {
+	if len(table.primaryKeys) == 0 {
+		return nil, errors.Default.New("no primary key")
+	}
+	columns := append(append([]string{}, table.primaryKeys...), table.columns...)
+	cursor, err := tx.Cursor(dal.Select(strings.Join(columns, ", ")), dal.From(table.name))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var rows []*encryptionRotationRow
+	for cursor.Next() {
+		if e := cursor.Scan(dest...); e != nil {
+			return nil, errors.Convert(e)
+		}
+		row := &encryptionRotationRow{
+			keys:   append([]interface{}{}, values[:len(table.primaryKeys)]...),
+			values: make(map[string]string),
+		}
+		for i, column := range table.columns {
+			var encrypted string
+			switch v := values[len(table.primaryKeys)+i].(type) {
+			case []byte:
+				encrypted = string(v)
+			case string:
+				encrypted = v
+			}
+			if encrypted == "" {
+				continue
+			}
+			plainText, index, err := plugin.DecryptWithAny(secrets, encrypted)
+			if err != nil {
+				return nil, errors.Default.Wrap(err, fmt.Sprintf("%s of the row %v could not be decrypted by any secret", column, row.keys))
+			}
+			if index == 0 {
+				continue
+			}
+			if row.values[column], err = plugin.Encrypt(secrets[0], plainText); err != nil {
+				return nil, err
+			}
+		}
+		if len(row.values) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func updateRotatedRow(tx dal.Transaction, table *encryptedTable, row *encryptionRotationRow) errors.Error {
+	var sets []string
+	var params []interface{}
+	for _, column := range table.columns {
+		if value, ok := row.values[column]; ok {
+			sets = append(sets, fmt.Sprintf("%s = ?", column))
+			params = append(params, value)
+		}
+	}
+	conditions := make([]string, len(table.primaryKeys))
+	for i, pk := range table.primaryKeys {
+		conditions[i] = fmt.Sprintf("%s = ?", pk)
+	}
+	params = append(params, row.keys...)
+	return tx.Exec(
+		fmt.Sprintf("UPDATE %s SET %s WHERE %s", table.name, strings.Join(sets, ", "), strings.Join(conditions, " AND ")),
+		params...,
+	)
+}
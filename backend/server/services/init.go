@@ -18,6 +18,7 @@ limitations under the License.
 package services
 
 import (
+	gocontext "context"
 	"sync"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/runner"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
+	"github.com/apache/incubator-devlake/helpers/tracinghelper"
 	"github.com/go-playground/validator/v10"
 	"github.com/robfig/cron/v3"
 )
@@ -63,8 +65,12 @@ func InitResources() {
 	basicRes = runner.CreateAppBasicRes()
 	cfg = basicRes.GetConfigReader()
 	logger = basicRes.GetLogger()
+	if err := tracinghelper.Init(cfg, logger); err != nil {
+		logger.Error(err, "failed to initialize tracing")
+	}
 	db = basicRes.GetDal()
 	bpManager = services.NewBlueprintManager(db)
+	plugin.RegisterPluginEventHandler(HandlePluginEvent)
 	// initialize db migrator
 	migrator, err = runner.InitMigrator(basicRes)
 	if err != nil {
@@ -116,8 +122,11 @@ func InitExecuteMigration() {
 func Init() {
 	InitResources()
 
-	// lock the database to avoid multiple devlake instances from sharing the same one
-	lockDatabase()
+	// lock the database to avoid multiple devlake instances from sharing the same one, unless they coordinate
+	// with each other through leases in the cluster mode, where the migrations are serialized by lockMigration
+	if !cfg.GetBool("CLUSTER_MODE") {
+		lockDatabase()
+	}
 
 	// now, load the plugins
 	errors.Must(runner.LoadPlugins(basicRes))
@@ -151,8 +160,18 @@ func ExecuteMigration() errors.Error {
 	}
 	serviceStatus = SERVICE_STATUS_MIGRATING
 	statusLock.Unlock() // unlock to allow other API requests to check the status
-	// apply all pending migration scripts
-	err := migrator.Execute()
+	// apply all pending migration scripts, one instance at a time in the cluster mode
+	var err errors.Error
+	if cfg.GetBool("CLUSTER_MODE") {
+		var unlockMigration func()
+		unlockMigration, err = lockMigration()
+		if err == nil {
+			err = migrator.Execute()
+			unlockMigration()
+		}
+	} else {
+		err = migrator.Execute()
+	}
 	if err != nil {
 		logger.Error(err, "failed to execute migration")
 		return err
@@ -164,12 +183,19 @@ func ExecuteMigration() errors.Error {
 
 	// initialize pipeline server, mainly to start the pipeline consuming process
 	pipelineServiceInit()
+	rawDataRetentionInit()
+	purgeInit()
 	statusLock.Lock()
 	serviceStatus = SERVICE_STATUS_READY
 	statusLock.Unlock()
 	return nil
 }
 
+// Shutdown releases the resources held by the services before the server exits
+func Shutdown(ctx gocontext.Context) {
+	tracinghelper.Shutdown(ctx)
+}
+
 func CurrentStatus() string {
 	return serviceStatus
 }
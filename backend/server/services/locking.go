@@ -43,6 +43,12 @@ func lockDatabase() {
 		Version:  version.Version,
 	}
 	errors.Must(db.Create(lockingHistory))
+	if db.Dialect() == "sqlite" {
+		// a sqlite transaction locks the whole database, so a never released one would block everything else
+		lockingHistory.Succeeded = true
+		errors.Must(db.Update(lockingHistory))
+		return
+	}
 	// 2. obtain the lock: using a never released transaction
 	// This prevents multiple devlake instances from sharing the same database by locking the migration history table
 	// However, it would not work if any older devlake instances were already using the database.
@@ -64,3 +70,31 @@ func lockDatabase() {
 		panic(fmt.Errorf("locking _devlake_locking_stub timeout, the database might be locked by another devlake instance"))
 	}
 }
+
+// lockMigration makes the instances sharing the same database in the cluster mode take turns to migrate it,
+// it blocks until the other instances are done with their migrations, call the returned function to unlock
+func lockMigration() (func(), errors.Error) {
+	db := basicRes.GetDal()
+	if db.Dialect() == "sqlite" {
+		// a sqlite transaction locks the whole database already
+		return func() {}, nil
+	}
+	err := db.AutoMigrate(&models.LockingStub{})
+	if err != nil {
+		return nil, err
+	}
+	tx := db.Begin()
+	err = tx.LockTables(dal.LockTables{{Table: models.LockingStub{}.TableName(), Exclusive: true}})
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	return func() {
+		if err := tx.UnlockTables(); err != nil {
+			logger.Error(err, "failed to unlock %s", models.LockingStub{}.TableName())
+		}
+		if err := tx.Commit(); err != nil {
+			logger.Error(err, "failed to release the migration lock")
+		}
+	}, nil
+}
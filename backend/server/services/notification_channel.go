@@ -0,0 +1,157 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// NotificationTarget is where a notification would be delivered to
+type NotificationTarget struct {
+	Endpoint string
+	Secret   string
+}
+
+// NotificationChannel delivers a persisted notification to its target
+type NotificationChannel interface {
+	Send(target *NotificationTarget, notification *models.Notification) (responseCode int, response string, err errors.Error)
+}
+
+var notificationChannels = map[string]NotificationChannel{
+	models.NOTIFICATION_CHANNEL_WEBHOOK: &webhookNotificationChannel{},
+	models.NOTIFICATION_CHANNEL_SLACK:   &slackNotificationChannel{},
+	models.NOTIFICATION_CHANNEL_EMAIL:   &emailNotificationChannel{},
+}
+
+var notificationHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+func postNotification(url string, contentType string, body []byte) (int, string, errors.Error) {
+	resp, err := notificationHttpClient.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", errors.Convert(err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, "", errors.Convert(err)
+	}
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), errors.HttpStatus(resp.StatusCode).New(fmt.Sprintf("notification endpoint responded with %d", resp.StatusCode))
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// webhookNotificationChannel posts the raw notification data signed with the target secret
+type webhookNotificationChannel struct{}
+
+func (c *webhookNotificationChannel) Send(target *NotificationTarget, notification *models.Notification) (int, string, errors.Error) {
+	nonce := fmt.Sprintf("%d-%s", notification.ID, notification.Nonce)
+	sign := notificationSignature(notification.Data, target.Secret, nonce)
+	url := fmt.Sprintf("%s?nouce=%s&sign=%s", target.Endpoint, nonce, sign)
+	return postNotification(url, "application/json", []byte(notification.Data))
+}
+
+func notificationSignature(input, secret, nouce string) string {
+	sum := sha256.Sum256([]byte(input + secret + nouce))
+	return hex.EncodeToString(sum[:])
+}
+
+// slackNotificationChannel posts a text message to a Slack compatible incoming webhook
+type slackNotificationChannel struct{}
+
+func (c *slackNotificationChannel) Send(target *NotificationTarget, notification *models.Notification) (int, string, errors.Error) {
+	body, err := json.Marshal(map[string]string{"text": notificationText(notification)})
+	if err != nil {
+		return 0, "", errors.Convert(err)
+	}
+	return postNotification(target.Endpoint, "application/json", body)
+}
+
+// emailNotificationChannel sends a plain text email through the SMTP relay configured by SMTP_* variables,
+// the target endpoint is a comma separated list of recipients
+type emailNotificationChannel struct{}
+
+func (c *emailNotificationChannel) Send(target *NotificationTarget, notification *models.Notification) (int, string, errors.Error) {
+	host := cfg.GetString("SMTP_HOST")
+	if host == "" {
+		return 0, "", errors.BadInput.New("SMTP_HOST is required to send email notifications")
+	}
+	port := cfg.GetString("SMTP_PORT")
+	if port == "" {
+		port = "25"
+	}
+	from := cfg.GetString("SMTP_FROM")
+	recipients := make([]string, 0)
+	for _, recipient := range strings.Split(target.Endpoint, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	var auth smtp.Auth
+	if username := cfg.GetString("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, cfg.GetString("SMTP_PASSWORD"), host)
+	}
+	text := notificationText(notification)
+	msg := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, strings.Join(recipients, ", "), strings.SplitN(text, "\n", 2)[0], text,
+	)
+	err := smtp.SendMail(fmt.Sprintf("%s:%s", host, port), auth, from, recipients, []byte(msg))
+	if err != nil {
+		return 0, "", errors.Convert(err)
+	}
+	return http.StatusOK, "", nil
+}
+
+// notificationText renders a human readable message out of the notification data
+func notificationText(notification *models.Notification) string {
+	data := make(map[string]interface{})
+	decoder := json.NewDecoder(strings.NewReader(notification.Data))
+	decoder.UseNumber()
+	_ = decoder.Decode(&data)
+	var sb strings.Builder
+	_, _ = sb.WriteString(fmt.Sprintf("[DevLake] %s", notification.Type))
+	if projectName, ok := data["ProjectName"].(string); ok && projectName != "" {
+		_, _ = sb.WriteString(fmt.Sprintf(" in project %s", projectName))
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if data[key] == nil || key == "ProjectName" {
+			continue
+		}
+		_, _ = sb.WriteString(fmt.Sprintf("\n%s: %v", key, data[key]))
+	}
+	return sb.String()
+}
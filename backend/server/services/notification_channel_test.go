@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotificationChannel(t *testing.T) {
+	notification := &models.Notification{
+		Model: common.Model{ID: 3},
+		Nonce: "abc",
+		Data:  `{"PipelineID":1,"Status":"TASK_FAILED"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, notification.Data, string(body))
+		assert.Equal(t, "3-abc", r.URL.Query().Get("nouce"))
+		assert.Equal(t, notificationSignature(notification.Data, "secret", "3-abc"), r.URL.Query().Get("sign"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	channel := &webhookNotificationChannel{}
+	code, _, err := channel.Send(&NotificationTarget{Endpoint: server.URL, Secret: "secret"}, notification)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+}
+
+func TestSlackNotificationChannel(t *testing.T) {
+	notification := &models.Notification{
+		Type: models.NotificationPipelineFailed,
+		Data: `{"ProjectName":"devlake","PipelineID":1234567,"Status":"TASK_FAILED","BeganAt":null}`,
+	}
+	statusCode := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := make(map[string]string)
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, "[DevLake] PipelineFailed in project devlake\nPipelineID: 1234567\nStatus: TASK_FAILED", payload["text"])
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	channel := &slackNotificationChannel{}
+	_, _, err := channel.Send(&NotificationTarget{Endpoint: server.URL}, notification)
+	assert.Nil(t, err)
+
+	statusCode = http.StatusInternalServerError
+	code, _, err := channel.Send(&NotificationTarget{Endpoint: server.URL}, notification)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
+
+func TestNotificationBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, notificationBackoff(time.Minute, 1))
+	assert.Equal(t, 4*time.Minute, notificationBackoff(time.Minute, 3))
+	assert.Equal(t, 512*time.Minute, notificationBackoff(time.Minute, 20))
+}
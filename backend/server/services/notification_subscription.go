@@ -0,0 +1,133 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// NotificationSubscriptionQuery used to query notification subscriptions as the api input
+type NotificationSubscriptionQuery struct {
+	Pagination
+	ProjectName string `form:"projectName"`
+	BlueprintId uint64 `form:"blueprintId"`
+}
+
+// GetNotificationSubscriptions returns a paginated list of notification subscriptions based on `query`
+func GetNotificationSubscriptions(query *NotificationSubscriptionQuery) ([]*models.NotificationSubscription, int64, errors.Error) {
+	clauses := []dal.Clause{
+		dal.From(&models.NotificationSubscription{}),
+	}
+	if query.ProjectName != "" {
+		clauses = append(clauses, dal.Where("project_name = ?", query.ProjectName))
+	}
+	if query.BlueprintId != 0 {
+		clauses = append(clauses, dal.Where("blueprint_id = ?", query.BlueprintId))
+	}
+	count, err := db.Count(clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error getting DB count of notification subscriptions")
+	}
+	clauses = append(clauses,
+		dal.Orderby("id DESC"),
+		dal.Offset(query.GetSkip()),
+		dal.Limit(query.GetPageSize()),
+	)
+	subscriptions := make([]*models.NotificationSubscription, 0)
+	err = db.All(&subscriptions, clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error finding DB notification subscriptions")
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, count, nil
+}
+
+// GetNotificationSubscription returns the notification subscription with the secret removed
+func GetNotificationSubscription(id uint64) (*models.NotificationSubscription, errors.Error) {
+	subscription, err := getDbNotificationSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func getDbNotificationSubscription(id uint64) (*models.NotificationSubscription, errors.Error) {
+	subscription := &models.NotificationSubscription{}
+	err := db.First(subscription, dal.Where("id = ?", id))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.New(fmt.Sprintf("notification subscription %d not found", id))
+		}
+		return nil, errors.Default.Wrap(err, "error getting the notification subscription from database")
+	}
+	return subscription, nil
+}
+
+// CreateNotificationSubscription accepts a notification subscription and insert it to database
+func CreateNotificationSubscription(subscription *models.NotificationSubscription) (*models.NotificationSubscription, errors.Error) {
+	subscription.ID = 0
+	if err := VerifyStruct(subscription); err != nil {
+		return nil, err
+	}
+	if err := db.Create(subscription); err != nil {
+		return nil, errors.Default.Wrap(err, "error creating the notification subscription")
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// PatchNotificationSubscription updates the notification subscription, the secret is kept unless a new one is given
+func PatchNotificationSubscription(id uint64, body map[string]interface{}) (*models.NotificationSubscription, errors.Error) {
+	subscription, err := getDbNotificationSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	secret := subscription.Secret
+	err = helper.DecodeMapStruct(body, subscription, true)
+	if err != nil {
+		return nil, err
+	}
+	subscription.ID = id
+	if subscription.Secret == "" {
+		subscription.Secret = secret
+	}
+	if err = VerifyStruct(subscription); err != nil {
+		return nil, err
+	}
+	if err = db.Update(subscription); err != nil {
+		return nil, errors.Default.Wrap(err, "error updating the notification subscription")
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// DeleteNotificationSubscription deletes the notification subscription
+func DeleteNotificationSubscription(id uint64) errors.Error {
+	if _, err := getDbNotificationSubscription(id); err != nil {
+		return err
+	}
+	return db.Delete(&models.NotificationSubscription{}, dal.Where("id = ?", id))
+}
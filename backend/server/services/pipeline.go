@@ -37,6 +37,7 @@ import (
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/utils"
 	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	"github.com/apache/incubator-devlake/helpers/metricshelper"
 	"github.com/apache/incubator-devlake/impls/logruslog"
 	"github.com/google/uuid"
 	"golang.org/x/sync/semaphore"
@@ -85,19 +86,34 @@ func pipelineServiceInit() {
 	// notification
 	var notificationEndpoint = cfg.GetString("NOTIFICATION_ENDPOINT")
 	var notificationSecret = cfg.GetString("NOTIFICATION_SECRET")
-	if strings.TrimSpace(notificationEndpoint) != "" {
-		defaultNotificationService = NewDefaultPipelineNotificationService(notificationEndpoint, notificationSecret)
+	defaultNotificationService = NewDefaultPipelineNotificationService(strings.TrimSpace(notificationEndpoint), notificationSecret)
+	if maxAttempts := cfg.GetInt("NOTIFICATION_MAX_ATTEMPTS"); maxAttempts > 0 {
+		defaultNotificationService.MaxAttempts = maxAttempts
 	}
+	if retryInterval := cfg.GetDuration("NOTIFICATION_RETRY_INTERVAL"); retryInterval > 0 {
+		defaultNotificationService.RetryInterval = retryInterval
+	}
+
+	// cluster mode: pipelines and tasks of dead workers are taken over once their leases expired
+	workerServiceInit()
+	go defaultNotificationService.retryPendingNotificationsInLoop()
 
 	// standalone mode: reset pipeline status
-	if cfg.GetBool("RESUME_PIPELINES") {
-		markInterruptedPipelineAs(models.TASK_RESUME)
-	} else {
-		markInterruptedPipelineAs(models.TASK_FAILED)
+	if !clusterMode {
+		if cfg.GetBool("RESUME_PIPELINES") {
+			markInterruptedPipelineAs(models.TASK_RESUME)
+		} else {
+			markInterruptedPipelineAs(models.TASK_FAILED)
+		}
 	}
 
-	// load cronjobs for blueprints
+	// load cronjobs for blueprints, they are only triggered by the leader in the cluster mode
 	errors.Must(ReloadBlueprints())
+	if clusterMode {
+		go leaderElectionInLoop()
+	}
+	// triggered blueprints wait for their debounce windows
+	go runDueBlueprintsInLoop()
 
 	var pipelineMaxParallel = cfg.GetInt64("PIPELINE_MAX_PARALLEL")
 	if pipelineMaxParallel < 0 {
@@ -265,10 +281,22 @@ func dequeuePipeline(runningParallelLabels []string) (pipeline *models.Pipeline,
 	top_priority := 0
 	var top_priorities []int
 	where_status := dal.Where("status IN ?", []string{models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME})
+	if clusterMode {
+		// take over the running pipelines abandoned by dead workers as well
+		where_status = dal.Where(
+			"(status IN ? OR (status = ? AND lease_expires_at < ?))",
+			[]string{models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME}, models.TASK_RUNNING, time.Now(),
+		)
+	}
 	err = tx.Pluck("priority", &top_priorities, dal.From(pipeline), where_status, dal.Orderby("priority DESC"), dal.Limit(1))
 	if err != nil {
 		panic(err)
 	}
+	queueDepth, err := tx.Count(dal.From(pipeline), where_status)
+	if err != nil {
+		panic(err)
+	}
+	metricshelper.SetPipelineQueueDepth(queueDepth)
 	if len(top_priorities) > 0 {
 		top_priority = top_priorities[0]
 	}
@@ -297,11 +325,20 @@ func dequeuePipeline(runningParallelLabels []string) (pipeline *models.Pipeline,
 			globalPipelineLog.Info("resumed pipeline #%d", pipeline.ID)
 		}
 		errors.Must(tx.LockTables(dal.LockTables{{Table: "_devlake_pipelines", Exclusive: true}}))
-		err = tx.UpdateColumns(&models.Pipeline{}, []dal.DalSet{
+		sets := []dal.DalSet{
 			{ColumnName: "status", Value: models.TASK_RUNNING},
 			{ColumnName: "message", Value: ""},
 			{ColumnName: "began_at", Value: pipeline.BeganAt},
-		}, dal.Where("id = ?", pipeline.ID))
+			// the time budget of the pipeline starts over for reruns and resumes
+			{ColumnName: "run_began_at", Value: time.Now()},
+		}
+		if clusterMode {
+			sets = append(sets,
+				dal.DalSet{ColumnName: "worker_id", Value: workerId},
+				dal.DalSet{ColumnName: "lease_expires_at", Value: time.Now().Add(leaseDuration)},
+			)
+		}
+		err = tx.UpdateColumns(&models.Pipeline{}, sets, dal.Where("id = ?", pipeline.ID))
 		if err != nil {
 			panic(err)
 		}
@@ -363,6 +400,11 @@ func RunPipelineInQueue(pipelineMaxParallel int64) {
 				globalPipelineLog.Info("finish pipeline #%d, now runningParallelLabels is %s", pipelineId, runningParallelLabels)
 			}()
 			globalPipelineLog.Info("run pipeline, %d, now running runningParallelLabels are %s", pipelineId, runningParallelLabels)
+			if clusterMode {
+				done := make(chan struct{})
+				defer close(done)
+				go renewLeaseInLoop(&models.Pipeline{}, pipelineId, done, nil)
+			}
 			// Notify that the pipeline has started
 			err = NotifyExternal(pipelineId)
 			if err != nil {
@@ -411,14 +453,20 @@ func NotifyExternal(pipelineId uint64) errors.Error {
 	if err != nil {
 		return err
 	}
+	recovered, err := isPipelineRecovered(pipeline)
+	if err != nil {
+		return err
+	}
 	err = notification.PipelineStatusChanged(PipelineNotificationParam{
 		ProjectName: projectName,
+		BlueprintID: pipeline.BlueprintId,
 		PipelineID:  pipeline.ID,
 		CreatedAt:   pipeline.CreatedAt,
 		UpdatedAt:   pipeline.UpdatedAt,
 		BeganAt:     pipeline.BeganAt,
 		FinishedAt:  pipeline.FinishedAt,
 		Status:      pipeline.Status,
+		Recovered:   recovered,
 	})
 	if err != nil {
 		globalPipelineLog.Error(err, "failed to send notification: %v", err)
@@ -427,6 +475,62 @@ func NotifyExternal(pipelineId uint64) errors.Error {
 	return nil
 }
 
+// isPipelineRecovered tells whether the pipeline completed right after a failed one of the same blueprint
+func isPipelineRecovered(pipeline *models.Pipeline) (bool, errors.Error) {
+	if pipeline.BlueprintId == 0 || pipeline.Status != models.TASK_COMPLETED {
+		return false, nil
+	}
+	previous := &models.Pipeline{}
+	err := db.First(
+		previous,
+		dal.Where(
+			"blueprint_id = ? AND id < ? AND status IN ?",
+			pipeline.BlueprintId, pipeline.ID, []string{models.TASK_COMPLETED, models.TASK_FAILED, models.TASK_PARTIAL, models.TASK_TIMEOUT},
+		),
+		dal.Orderby("id DESC"),
+	)
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return previous.Status != models.TASK_COMPLETED, nil
+}
+
+// NotifyTaskFailed sends the task failed notification if the task ended up failed
+func NotifyTaskFailed(taskId uint64) errors.Error {
+	notification, ok := GetPipelineNotificationService().(TaskNotificationService)
+	if !ok {
+		return nil
+	}
+	task, err := GetTask(taskId)
+	if err != nil {
+		return err
+	}
+	if task.Status != models.TASK_FAILED && task.Status != models.TASK_TIMEOUT {
+		return nil
+	}
+	pipeline, err := GetDbPipeline(task.PipelineId)
+	if err != nil {
+		return err
+	}
+	projectName, err := getProjectName(pipeline)
+	if err != nil {
+		return err
+	}
+	return notification.TaskFailed(TaskNotificationParam{
+		ProjectName:   projectName,
+		BlueprintID:   pipeline.BlueprintId,
+		PipelineID:    pipeline.ID,
+		TaskID:        task.ID,
+		Plugin:        task.Plugin,
+		FailedSubTask: task.FailedSubTask,
+		Message:       task.Message,
+		Status:        task.Status,
+	})
+}
+
 // CancelPipeline FIXME ...
 func CancelPipeline(pipelineId uint64) errors.Error {
 	// prevent RunPipelineInQueue from consuming pending pipelines
@@ -550,6 +654,7 @@ func RerunPipeline(pipelineId uint64, task *models.Task) (tasks []*models.Task,
 				Plugin:   t.Plugin,
 				Subtasks: t.Subtasks,
 				Options:  t.Options,
+				Timeout:  t.Timeout,
 			},
 			PipelineId:  t.PipelineId,
 			PipelineRow: t.PipelineRow,
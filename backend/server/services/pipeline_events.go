@@ -0,0 +1,224 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+// types of the PipelineEvent
+const (
+	PIPELINE_EVENT_PIPELINE = "pipeline"
+	PIPELINE_EVENT_TASK     = "task"
+	PIPELINE_EVENT_SUBTASK  = "subtask"
+	PIPELINE_EVENT_PROGRESS = "progress"
+	PIPELINE_EVENT_LOG      = "log"
+)
+
+// pipelineEventBufferSize is the number of events buffered for each subscriber, events would be dropped
+// for the subscribers not keeping up
+const pipelineEventBufferSize = 256
+
+// PipelineEventsHeartbeat is the interval for the subscribers to check the pipeline status on their own
+var PipelineEventsHeartbeat = 15 * time.Second
+
+// progressEventInterval throttles the record level progress events, which could be fired for every record
+const progressEventInterval = time.Second
+
+// PipelineEvent is pushed to the subscribers of a pipeline while it is running
+type PipelineEvent struct {
+	Type          string                     `json:"type"`
+	PipelineId    uint64                     `json:"pipelineId"`
+	TaskId        uint64                     `json:"taskId,omitempty"`
+	Status        string                     `json:"status,omitempty"`
+	Subtask       string                     `json:"subtask,omitempty"`
+	SubtaskNumber int                        `json:"subtaskNumber,omitempty"`
+	Progress      *models.TaskProgressDetail `json:"progress,omitempty"`
+	Message       string                     `json:"message,omitempty"`
+	Time          time.Time                  `json:"time"`
+}
+
+type pipelineEventHub struct {
+	mu          sync.RWMutex
+	subscribers map[uint64]map[chan *PipelineEvent]struct{}
+}
+
+var pipelineEvents = &pipelineEventHub{
+	subscribers: make(map[uint64]map[chan *PipelineEvent]struct{}),
+}
+
+// SubscribePipelineEvents returns a channel receiving the events of the specified pipeline fired by this
+// process, the returned function must be called to unsubscribe once the caller is no longer interested.
+func SubscribePipelineEvents(pipelineId uint64) (<-chan *PipelineEvent, func()) {
+	return pipelineEvents.subscribe(pipelineId)
+}
+
+func (h *pipelineEventHub) subscribe(pipelineId uint64) (<-chan *PipelineEvent, func()) {
+	ch := make(chan *PipelineEvent, pipelineEventBufferSize)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[pipelineId] == nil {
+		h.subscribers[pipelineId] = make(map[chan *PipelineEvent]struct{})
+	}
+	h.subscribers[pipelineId][ch] = struct{}{}
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers[pipelineId], ch)
+			if len(h.subscribers[pipelineId]) == 0 {
+				delete(h.subscribers, pipelineId)
+			}
+			close(ch)
+		})
+	}
+}
+
+func (h *pipelineEventHub) hasSubscribers(pipelineId uint64) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers[pipelineId]) > 0
+}
+
+// publish never blocks, the event is dropped for the subscribers whose buffer is full
+func (h *pipelineEventHub) publish(event *PipelineEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subscribers[event.PipelineId] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// pipelineLogWriter forwards the log lines of a pipeline to its subscribers
+type pipelineLogWriter struct {
+	pipelineId uint64
+}
+
+func (w *pipelineLogWriter) Write(p []byte) (int, error) {
+	if pipelineEvents.hasSubscribers(w.pipelineId) {
+		for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+			pipelineEvents.publish(&PipelineEvent{
+				Type:       PIPELINE_EVENT_LOG,
+				PipelineId: w.pipelineId,
+				Message:    line,
+			})
+		}
+	}
+	return len(p), nil
+}
+
+// taskEventPublisher turns the progress updates of a running task into events
+type taskEventPublisher struct {
+	pipelineId      uint64
+	taskId          uint64
+	subtask         string
+	subtaskNumber   int
+	lastProgressAt  time.Time
+	pendingProgress bool
+}
+
+func (p *taskEventPublisher) publishStatus(status string, message string) {
+	pipelineEvents.publish(&PipelineEvent{
+		Type:       PIPELINE_EVENT_TASK,
+		PipelineId: p.pipelineId,
+		TaskId:     p.taskId,
+		Status:     status,
+		Message:    message,
+	})
+}
+
+// publishProgress is called with the progress detail which was just updated by the progress `rp`
+func (p *taskEventPublisher) publishProgress(rp *plugin.RunningProgress, detail models.TaskProgressDetail) {
+	if !pipelineEvents.hasSubscribers(p.pipelineId) {
+		return
+	}
+	switch rp.Type {
+	case plugin.SetCurrentSubTask:
+		p.subtask = rp.SubTaskName
+		p.subtaskNumber = rp.SubTaskNumber
+		p.publishSubtask(models.TASK_RUNNING)
+	case plugin.TaskIncProgress:
+		// the task progress is increased once a subtask is done
+		if p.subtask != "" {
+			p.publishSubtask(models.TASK_COMPLETED)
+		}
+	case plugin.SubTaskIncProgress:
+		if time.Since(p.lastProgressAt) < progressEventInterval {
+			p.pendingProgress = true
+			return
+		}
+	}
+	p.lastProgressAt = time.Now()
+	p.pendingProgress = false
+	pipelineEvents.publish(&PipelineEvent{
+		Type:          PIPELINE_EVENT_PROGRESS,
+		PipelineId:    p.pipelineId,
+		TaskId:        p.taskId,
+		Subtask:       p.subtask,
+		SubtaskNumber: p.subtaskNumber,
+		Progress:      &detail,
+	})
+}
+
+// flushProgress publishes the last progress which was throttled
+func (p *taskEventPublisher) flushProgress(detail models.TaskProgressDetail) {
+	if !p.pendingProgress {
+		return
+	}
+	p.pendingProgress = false
+	pipelineEvents.publish(&PipelineEvent{
+		Type:          PIPELINE_EVENT_PROGRESS,
+		PipelineId:    p.pipelineId,
+		TaskId:        p.taskId,
+		Subtask:       p.subtask,
+		SubtaskNumber: p.subtaskNumber,
+		Progress:      &detail,
+	})
+}
+
+func (p *taskEventPublisher) publishSubtask(status string) {
+	pipelineEvents.publish(&PipelineEvent{
+		Type:          PIPELINE_EVENT_SUBTASK,
+		PipelineId:    p.pipelineId,
+		TaskId:        p.taskId,
+		Status:        status,
+		Subtask:       p.subtask,
+		SubtaskNumber: p.subtaskNumber,
+	})
+}
+
+func publishPipelineStatus(pipelineId uint64, status string, message string) {
+	pipelineEvents.publish(&PipelineEvent{
+		Type:       PIPELINE_EVENT_PIPELINE,
+		PipelineId: pipelineId,
+		Status:     status,
+		Message:    message,
+	})
+}
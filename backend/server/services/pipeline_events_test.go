@@ -0,0 +1,76 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineEventHub(t *testing.T) {
+	events, unsubscribe := SubscribePipelineEvents(1)
+	other, unsubscribeOther := SubscribePipelineEvents(2)
+	defer unsubscribeOther()
+
+	publishPipelineStatus(1, models.TASK_RUNNING, "")
+	_, _ = (&pipelineLogWriter{pipelineId: 1}).Write([]byte("line 1\nline 2\n"))
+
+	event := <-events
+	assert.Equal(t, PIPELINE_EVENT_PIPELINE, event.Type)
+	assert.Equal(t, models.TASK_RUNNING, event.Status)
+	assert.False(t, event.Time.IsZero())
+	assert.Equal(t, "line 1", (<-events).Message)
+	assert.Equal(t, "line 2", (<-events).Message)
+	assert.Len(t, other, 0)
+
+	unsubscribe()
+	unsubscribe()
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.False(t, pipelineEvents.hasSubscribers(1))
+}
+
+func TestTaskEventPublisher(t *testing.T) {
+	events, unsubscribe := SubscribePipelineEvents(3)
+	defer unsubscribe()
+	publisher := &taskEventPublisher{pipelineId: 3, taskId: 4}
+
+	publisher.publishProgress(&plugin.RunningProgress{Type: plugin.SetCurrentSubTask, SubTaskName: "collectIssues", SubTaskNumber: 1}, models.TaskProgressDetail{})
+	event := <-events
+	assert.Equal(t, PIPELINE_EVENT_SUBTASK, event.Type)
+	assert.Equal(t, models.TASK_RUNNING, event.Status)
+	assert.Equal(t, "collectIssues", event.Subtask)
+	assert.Equal(t, PIPELINE_EVENT_PROGRESS, (<-events).Type)
+
+	// record level progress is throttled and flushed at the end
+	publisher.publishProgress(&plugin.RunningProgress{Type: plugin.SubTaskIncProgress, Current: 1}, models.TaskProgressDetail{FinishedRecords: 1})
+	assert.Len(t, events, 0)
+	publisher.flushProgress(models.TaskProgressDetail{FinishedRecords: 2})
+	event = <-events
+	assert.Equal(t, PIPELINE_EVENT_PROGRESS, event.Type)
+	assert.Equal(t, 2, event.Progress.FinishedRecords)
+
+	publisher.publishProgress(&plugin.RunningProgress{Type: plugin.TaskIncProgress, Current: 1}, models.TaskProgressDetail{FinishedSubTasks: 1})
+	event = <-events
+	assert.Equal(t, PIPELINE_EVENT_SUBTASK, event.Type)
+	assert.Equal(t, models.TASK_COMPLETED, event.Status)
+	assert.Equal(t, 1, (<-events).Progress.FinishedSubTasks)
+}
@@ -24,18 +24,36 @@ import (
 
 type PipelineNotificationParam struct {
 	ProjectName string // can be an empty string, if pipeline is created and triggered by API
+	BlueprintID uint64
 	PipelineID  uint64
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	BeganAt     *time.Time
 	FinishedAt  *time.Time
 	Status      string
+	Recovered   bool // the pipeline completed after the previous one of the same blueprint failed
+}
+
+type TaskNotificationParam struct {
+	ProjectName   string
+	BlueprintID   uint64
+	PipelineID    uint64
+	TaskID        uint64
+	Plugin        string
+	FailedSubTask string
+	Message       string
+	Status        string
 }
 
 type PipelineNotificationService interface {
 	PipelineStatusChanged(params PipelineNotificationParam) errors.Error
 }
 
+// TaskNotificationService could be implemented by a PipelineNotificationService to get notified about failed tasks
+type TaskNotificationService interface {
+	TaskFailed(params TaskNotificationParam) errors.Error
+}
+
 var customPipelineNotificationService PipelineNotificationService
 
 func GetPipelineNotificationService() PipelineNotificationService {
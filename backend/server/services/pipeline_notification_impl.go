@@ -18,76 +18,236 @@ limitations under the License.
 package services
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/utils"
-	"io"
-	"net/http"
-	"strings"
 )
 
-// DefaultPipelineNotificationService FIXME ...
+const (
+	defaultNotificationMaxAttempts   = 5
+	defaultNotificationRetryInterval = time.Minute
+	notificationRetryCheckInterval   = 30 * time.Second
+)
+
+// DefaultPipelineNotificationService persists notifications into `_devlake_notifications` and delivers them to
+// the global NOTIFICATION_ENDPOINT as well as the matching subscriptions, undelivered ones are retried with backoff
 type DefaultPipelineNotificationService struct {
-	EndPoint string
-	Secret   string
+	EndPoint      string
+	Secret        string
+	MaxAttempts   int
+	RetryInterval time.Duration
 }
 
 // NewDefaultPipelineNotificationService creates a new DefaultPipelineNotificationService
 func NewDefaultPipelineNotificationService(endpoint, secret string) *DefaultPipelineNotificationService {
 	return &DefaultPipelineNotificationService{
-		EndPoint: endpoint,
-		Secret:   secret,
+		EndPoint:      endpoint,
+		Secret:        secret,
+		MaxAttempts:   defaultNotificationMaxAttempts,
+		RetryInterval: defaultNotificationRetryInterval,
 	}
 }
 
-// PipelineStatusChanged FIXME ...
+// PipelineStatusChanged notifies the status change along with the failed/partial/recovered events derived from it
 func (n *DefaultPipelineNotificationService) PipelineStatusChanged(params PipelineNotificationParam) errors.Error {
-	return n.sendNotification(models.NotificationPipelineStatusChanged, params)
+	events := []models.NotificationType{models.NotificationPipelineStatusChanged}
+	switch params.Status {
+	case models.TASK_FAILED, models.TASK_TIMEOUT:
+		events = append(events, models.NotificationPipelineFailed)
+	case models.TASK_PARTIAL:
+		events = append(events, models.NotificationPipelinePartial)
+	case models.TASK_COMPLETED:
+		if params.Recovered {
+			events = append(events, models.NotificationPipelineRecovered)
+		}
+	}
+	return n.dispatch(events, params.ProjectName, params.BlueprintID, params)
 }
 
-func (n *DefaultPipelineNotificationService) sendNotification(notificationType models.NotificationType, data interface{}) errors.Error {
-	var dataJson, err = json.Marshal(data)
+// TaskFailed notifies the failure of a task
+func (n *DefaultPipelineNotificationService) TaskFailed(params TaskNotificationParam) errors.Error {
+	return n.dispatch([]models.NotificationType{models.NotificationTaskFailed}, params.ProjectName, params.BlueprintID, params)
+}
+
+func (n *DefaultPipelineNotificationService) dispatch(events []models.NotificationType, projectName string, blueprintId uint64, data interface{}) errors.Error {
+	dataJson, err := json.Marshal(data)
 	if err != nil {
 		return errors.Convert(err)
 	}
-
-	var notification models.Notification
-	notification.Data = string(dataJson)
-	notification.Type = notificationType
-	notification.Endpoint = n.EndPoint
-	nonce, err1 := utils.RandLetterBytes(16)
-	if err1 != nil {
-		return err1
+	notifications := make([]*models.Notification, 0)
+	// the global endpoint keeps receiving status changes only, as it always did
+	if n.EndPoint != "" && events[0] == models.NotificationPipelineStatusChanged {
+		notifications = append(notifications, &models.Notification{
+			Type:     models.NotificationPipelineStatusChanged,
+			Endpoint: n.EndPoint,
+			Channel:  models.NOTIFICATION_CHANNEL_WEBHOOK,
+		})
+	}
+	subscriptions := make([]*models.NotificationSubscription, 0)
+	e := db.All(&subscriptions, dal.Where("enable = ?", true))
+	if e != nil {
+		return e
+	}
+	for _, subscription := range subscriptions {
+		for _, event := range events {
+			if subscription.Matches(event, projectName, blueprintId) {
+				notifications = append(notifications, &models.Notification{
+					Type:           event,
+					Endpoint:       subscription.Endpoint,
+					Channel:        subscription.Channel,
+					SubscriptionId: subscription.ID,
+				})
+			}
+		}
 	}
-	notification.Nonce = nonce
+	var lastErr errors.Error
+	for _, notification := range notifications {
+		nonce, e := utils.RandLetterBytes(16)
+		if e != nil {
+			return e
+		}
+		notification.Data = string(dataJson)
+		notification.Nonce = nonce
+		notification.Status = models.NOTIFICATION_PENDING
+		// leave enough time for the first attempt before the retry loop picks it up
+		nextRetryAt := time.Now().Add(n.RetryInterval)
+		notification.NextRetryAt = &nextRetryAt
+		if e = db.Create(notification); e != nil {
+			return e
+		}
+		if e = n.deliver(notification); e != nil {
+			lastErr = e
+		}
+	}
+	return lastErr
+}
 
-	err = db.Create(&notification)
+// deliver makes one attempt to send the notification and records the outcome
+func (n *DefaultPipelineNotificationService) deliver(notification *models.Notification) errors.Error {
+	target, err := n.getTarget(notification)
 	if err != nil {
-		return errors.Convert(err)
+		return err
+	}
+	channel, ok := notificationChannels[notification.Channel]
+	if !ok {
+		channel = notificationChannels[models.NOTIFICATION_CHANNEL_WEBHOOK]
 	}
+	var sendErr errors.Error
+	if target != nil {
+		notification.ResponseCode, notification.Response, sendErr = channel.Send(target, notification)
+	} else {
+		sendErr = errors.NotFound.New(fmt.Sprintf("subscription %d of notification %d not found", notification.SubscriptionId, notification.ID))
+	}
+	notification.Attempts++
+	switch {
+	case sendErr == nil:
+		notification.Status = models.NOTIFICATION_SENT
+		notification.NextRetryAt = nil
+	case notification.Attempts >= n.MaxAttempts || target == nil:
+		notification.Status = models.NOTIFICATION_FAILED
+		notification.NextRetryAt = nil
+		notification.Response = sendErr.Error()
+	default:
+		nextRetryAt := time.Now().Add(notificationBackoff(n.RetryInterval, notification.Attempts))
+		notification.NextRetryAt = &nextRetryAt
+		notification.Response = sendErr.Error()
+	}
+	if err = db.Update(notification); err != nil {
+		return err
+	}
+	return sendErr
+}
 
-	sign := n.signature(notification.Data, fmt.Sprintf("%d-%s", notification.ID, nonce))
-	url := fmt.Sprintf("%s?nouce=%d-%s&sign=%s", n.EndPoint, notification.ID, nonce, sign)
+func (n *DefaultPipelineNotificationService) getTarget(notification *models.Notification) (*NotificationTarget, errors.Error) {
+	if notification.SubscriptionId == 0 {
+		return &NotificationTarget{Endpoint: notification.Endpoint, Secret: n.Secret}, nil
+	}
+	subscription := &models.NotificationSubscription{}
+	err := db.First(subscription, dal.Where("id = ?", notification.SubscriptionId))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &NotificationTarget{Endpoint: subscription.Endpoint, Secret: subscription.Secret}, nil
+}
 
-	resp, err := http.Post(url, "application/json", strings.NewReader(notification.Data))
+// RetryPendingNotifications resends the notifications that are due, including the ones left behind by a restart
+func (n *DefaultPipelineNotificationService) RetryPendingNotifications() errors.Error {
+	notifications := make([]*models.Notification, 0)
+	err := db.All(
+		&notifications,
+		dal.Where("status = ? AND (next_retry_at IS NULL OR next_retry_at <= ?)", models.NOTIFICATION_PENDING, time.Now()),
+		dal.Orderby("id"),
+	)
 	if err != nil {
-		return errors.Convert(err)
+		return err
 	}
+	for _, notification := range notifications {
+		claimed, err := n.claimNotification(notification)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if err = n.deliver(notification); err != nil {
+			globalPipelineLog.Warn(err, "failed to resend notification #%d (attempt %d)", notification.ID, notification.Attempts)
+		}
+	}
+	return nil
+}
 
-	notification.ResponseCode = resp.StatusCode
-	respBody, err := io.ReadAll(resp.Body)
+// claimNotification marks the notification as being resent by the current process, the conditional update only
+// succeeds for one of the competing instances since it pushes next_retry_at beyond the due time of the others
+func (n *DefaultPipelineNotificationService) claimNotification(notification *models.Notification) (bool, errors.Error) {
+	claimer, err := utils.RandLetterBytes(16)
 	if err != nil {
-		return errors.Convert(err)
+		return false, err
+	}
+	now := time.Now()
+	err = db.UpdateColumns(&models.Notification{}, []dal.DalSet{
+		{ColumnName: "claimed_by", Value: claimer},
+		{ColumnName: "next_retry_at", Value: now.Add(n.RetryInterval)},
+	}, dal.Where(
+		"id = ? AND status = ? AND (next_retry_at IS NULL OR next_retry_at <= ?)",
+		notification.ID, models.NOTIFICATION_PENDING, now,
+	))
+	if err != nil {
+		return false, err
+	}
+	err = db.First(notification, dal.Where("id = ?", notification.ID))
+	if err != nil {
+		return false, err
+	}
+	return notification.ClaimedBy == claimer, nil
+}
+
+func (n *DefaultPipelineNotificationService) retryPendingNotificationsInLoop() {
+	for {
+		// only the leader resends the notifications in the cluster mode
+		if isLeader() {
+			if err := n.RetryPendingNotifications(); err != nil {
+				globalPipelineLog.Error(err, "failed to retry pending notifications")
+			}
+		}
+		time.Sleep(notificationRetryCheckInterval)
 	}
-	notification.Response = string(respBody)
-	return db.Update(notification)
 }
 
-func (n *DefaultPipelineNotificationService) signature(input, nouce string) string {
-	sum := sha256.Sum256([]byte(input + n.Secret + nouce))
-	return hex.EncodeToString(sum[:])
+// notificationBackoff doubles the interval after each failed attempt
+func notificationBackoff(interval time.Duration, attempts int) time.Duration {
+	if attempts < 1 {
+		return interval
+	}
+	if attempts > 10 {
+		attempts = 10
+	}
+	return interval * time.Duration(1<<(attempts-1))
 }
@@ -25,11 +25,17 @@ import (
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/helpers/metricshelper"
+	"github.com/apache/incubator-devlake/helpers/tracinghelper"
 	"github.com/apache/incubator-devlake/impls/logruslog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"io"
 	"time"
 )
 
 type pipelineRunner struct {
+	ctx      context.Context
 	logger   log.Logger
 	pipeline *models.Pipeline
 }
@@ -39,7 +45,10 @@ func (p *pipelineRunner) runPipelineStandalone() errors.Error {
 		basicRes.ReplaceLogger(p.logger),
 		p.pipeline.ID,
 		func(taskIds []uint64) errors.Error {
-			return RunTasksStandalone(p.logger, taskIds)
+			if clusterMode {
+				return RunTasksDistributed(p.logger, taskIds)
+			}
+			return RunTasksStandalone(p.ctx, p.logger, taskIds)
 		},
 	)
 }
@@ -56,7 +65,7 @@ func GetPipelineLogger(pipeline *models.Pipeline) log.Logger {
 	} else {
 		pipelineLogger.SetStream(&log.LoggerStreamConfig{
 			Path:   loggingPath,
-			Writer: stream,
+			Writer: io.MultiWriter(stream, &pipelineLogWriter{pipelineId: pipeline.ID}),
 		})
 	}
 	return pipelineLogger
@@ -68,12 +77,36 @@ func runPipeline(pipelineId uint64) errors.Error {
 	if err != nil {
 		return err
 	}
+	ctx, span := tracinghelper.Start(context.Background(), "pipeline",
+		attribute.Int64("pipeline.id", int64(ppl.ID)),
+		attribute.Int64("blueprint.id", int64(ppl.BlueprintId)),
+		attribute.String("pipeline.name", ppl.Name),
+	)
+	defer span.End()
 	pipelineRun := pipelineRunner{
+		ctx:      ctx,
 		logger:   GetPipelineLogger(ppl),
 		pipeline: ppl,
 	}
+	metricshelper.PipelineStarted()
+	publishPipelineStatus(ppl.ID, models.TASK_RUNNING, "")
+	defer metricshelper.PipelineStopped()
+	// cancel the running tasks once the pipeline runs out of its time budget
+	var timer *time.Timer
+	if ppl.Timeout > 0 {
+		runBeganAt := time.Now()
+		if ppl.RunBeganAt != nil {
+			runBeganAt = *ppl.RunBeganAt
+		}
+		timer = time.AfterFunc(time.Until(runBeganAt.Add(time.Duration(ppl.Timeout)*time.Second)), func() {
+			timeoutPipeline(ppl)
+		})
+	}
 	// run
 	err = pipelineRun.runPipelineStandalone()
+	if timer != nil {
+		timer.Stop()
+	}
 	isCancelled := errors.Is(err, context.Canceled)
 	if err != nil {
 		err = errors.Default.Wrap(err, fmt.Sprintf("Error running pipeline %d.", pipelineId))
@@ -102,27 +135,55 @@ func runPipeline(pipelineId uint64) errors.Error {
 		globalPipelineLog.Error(err, "update pipeline state failed")
 		return err
 	}
+	publishPipelineStatus(dbPipeline.ID, dbPipeline.Status, dbPipeline.Message)
+	metricshelper.PipelineFinished(dbPipeline.Status, time.Duration(dbPipeline.SpentSeconds)*time.Second)
+	span.SetAttributes(attribute.String("pipeline.status", dbPipeline.Status))
+	if dbPipeline.Status != models.TASK_COMPLETED {
+		span.SetStatus(codes.Error, dbPipeline.Message)
+	}
+	// run the blueprints chained after this one
+	if err = triggerDownstreamBlueprints(dbPipeline); err != nil {
+		globalPipelineLog.Error(err, "failed to trigger downstream blueprints of pipeline #%d", pipelineId)
+	}
 	// notify external webhook
 	return NotifyExternal(pipelineId)
 }
 
+// timeoutPipeline cancels the running tasks of the pipeline with a timeout error as the cause
+func timeoutPipeline(pipeline *models.Pipeline) {
+	cause := errors.Timeout.New(fmt.Sprintf("pipeline #%d timed out after %ds", pipeline.ID, pipeline.Timeout))
+	globalPipelineLog.Warn(cause, "cancelling the running tasks of pipeline #%d", pipeline.ID)
+	tasks, _, err := GetTasks(&TaskQuery{PipelineId: pipeline.ID, Pending: 1, Pagination: Pagination{PageSize: -1}})
+	if err != nil {
+		globalPipelineLog.Error(err, "failed to get the running tasks of pipeline #%d", pipeline.ID)
+		return
+	}
+	for _, task := range tasks {
+		_ = cancelTask(task.ID, cause)
+	}
+}
+
 // ComputePipelineStatus determines pipleline status by its latest(rerun included) tasks statuses
 // 1. TASK_COMPLETED: all tasks were executed sucessfully
-// 2. TASK_FAILED: SkipOnFail=false with failed task(s)
-// 3. TASK_PARTIAL: SkipOnFail=true with failed task(s)
+// 2. TASK_TIMEOUT: the pipeline ran out of its time budget, or SkipOnFail=false with timed out task(s)
+// 3. TASK_FAILED: SkipOnFail=false with failed task(s)
+// 4. TASK_PARTIAL: SkipOnFail=true with failed task(s)
 func ComputePipelineStatus(pipeline *models.Pipeline, isCancelled bool) (string, errors.Error) {
 	tasks, err := GetLatestTasksOfPipeline(pipeline)
 	if err != nil {
 		return "", err
 	}
 
-	succeeded, failed, pending, running := 0, 0, 0, 0
+	succeeded, failed, timedOut, pending, running := 0, 0, 0, 0, 0
 
 	for _, task := range tasks {
 		if task.Status == models.TASK_COMPLETED {
 			succeeded += 1
 		} else if task.Status == models.TASK_FAILED || task.Status == models.TASK_CANCELLED {
 			failed += 1
+		} else if task.Status == models.TASK_TIMEOUT {
+			failed += 1
+			timedOut += 1
 		} else if task.Status == models.TASK_RUNNING {
 			running += 1
 		} else {
@@ -130,16 +191,27 @@ func ComputePipelineStatus(pipeline *models.Pipeline, isCancelled bool) (string,
 		}
 	}
 
-	if running > 0 || (!isCancelled && pipeline.SkipOnFail && pending > 0) {
+	// tasks left behind are expected when the pipeline ran out of its time budget
+	pipelineTimedOut := pipeline.FinishedAt != nil && pipeline.IsTimedOut(*pipeline.FinishedAt)
+	if running > 0 || (!isCancelled && !pipelineTimedOut && pipeline.SkipOnFail && pending > 0) {
 		return "", errors.Default.New("unexpected status, did you call computePipelineStatus at a wrong timing?")
 	}
 
+	if failed == 0 && pending == 0 {
+		return models.TASK_COMPLETED, nil
+	}
+	if pipelineTimedOut {
+		return models.TASK_TIMEOUT, nil
+	}
 	if failed == 0 {
 		return models.TASK_COMPLETED, nil
 	}
 	if pipeline.SkipOnFail && succeeded > 0 {
 		return models.TASK_PARTIAL, nil
 	}
+	if timedOut > 0 {
+		return models.TASK_TIMEOUT, nil
+	}
 	return models.TASK_FAILED, nil
 }
 
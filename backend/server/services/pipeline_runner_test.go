@@ -0,0 +1,77 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputePipelineStatusTimeout(t *testing.T) {
+	setupTestDb(t)
+	beganAt := time.Now().Add(-2 * time.Hour)
+	runBeganAt := beganAt
+	finishedAt := beganAt.Add(time.Hour)
+	pipeline := &models.Pipeline{
+		Name:       "test",
+		TotalTasks: 2,
+		BeganAt:    &beganAt,
+		RunBeganAt: &runBeganAt,
+		FinishedAt: &finishedAt,
+		SyncPolicy: models.SyncPolicy{Timeout: 60},
+	}
+	require.Nil(t, db.Create(pipeline))
+	completed := createTestTask(t, &models.Task{Plugin: "test", PipelineId: pipeline.ID, PipelineRow: 1, Status: models.TASK_COMPLETED})
+	// the task left behind once the pipeline ran out of its time budget
+	pending := createTestTask(t, &models.Task{Plugin: "test", PipelineId: pipeline.ID, PipelineRow: 2, Status: models.TASK_CREATED})
+	status, err := ComputePipelineStatus(pipeline, false)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_TIMEOUT, status)
+
+	// all tasks completed in time
+	pending.Status = models.TASK_COMPLETED
+	require.Nil(t, db.Update(pending))
+	status, err = ComputePipelineStatus(pipeline, false)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_COMPLETED, status)
+
+	// the rerun began long after the first run, and failed within its own time budget
+	runBeganAt = finishedAt.Add(time.Minute)
+	finishedAt = runBeganAt.Add(30 * time.Second)
+	require.Nil(t, db.Create(&models.Task{Plugin: "test", PipelineId: pipeline.ID, PipelineRow: completed.PipelineRow, Status: models.TASK_FAILED}))
+	status, err = ComputePipelineStatus(pipeline, false)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_FAILED, status)
+
+	// the rerun ran out of its time budget
+	finishedAt = runBeganAt.Add(time.Minute)
+	status, err = ComputePipelineStatus(pipeline, false)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_TIMEOUT, status)
+
+	// timed out tasks
+	pipeline.Timeout = 0
+	require.Nil(t, db.Create(&models.Task{Plugin: "test", PipelineId: pipeline.ID, PipelineRow: completed.PipelineRow, Status: models.TASK_TIMEOUT}))
+	status, err = ComputePipelineStatus(pipeline, false)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_TIMEOUT, status)
+}
@@ -246,6 +246,32 @@ func PatchProject(name string, body map[string]interface{}) (*models.ApiOutputPr
 			return nil, err
 		}
 
+		// ProjectDeploymentMetric
+		err = tx.UpdateColumn(
+			&crossdomain.ProjectDeploymentMetric{},
+			"project_name", project.Name,
+			dal.Where("project_name = ?", name),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// ProjectServiceDeployment, ProjectServicePrMetric and ProjectServiceIncident
+		for _, table := range []dal.Tabler{
+			&crossdomain.ProjectServiceDeployment{},
+			&crossdomain.ProjectServicePrMetric{},
+			&crossdomain.ProjectServiceIncident{},
+		} {
+			err = tx.UpdateColumn(
+				table,
+				"project_name", project.Name,
+				dal.Where("project_name = ?", name),
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// ProjectIncidentDeploymentRelationship
 		err = tx.UpdateColumn(
 			&crossdomain.ProjectIncidentDeploymentRelationship{},
@@ -399,6 +425,20 @@ func DeleteProject(name string) errors.Error {
 	if err != nil {
 		return errors.Default.Wrap(err, "error deleting project Issue metric")
 	}
+	err = tx.Delete(&crossdomain.ProjectDeploymentMetric{}, dal.Where("project_name = ?", name))
+	if err != nil {
+		return errors.Default.Wrap(err, "error deleting project deployment metric")
+	}
+	for _, table := range []dal.Tabler{
+		&crossdomain.ProjectServiceDeployment{},
+		&crossdomain.ProjectServicePrMetric{},
+		&crossdomain.ProjectServiceIncident{},
+	} {
+		err = tx.Delete(table, dal.Where("project_name = ?", name))
+		if err != nil {
+			return errors.Default.Wrap(err, "error deleting project service metric")
+		}
+	}
 	return tx.Commit()
 }
 
@@ -0,0 +1,44 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/helpers/domainlayerhelper"
+	"github.com/google/uuid"
+)
+
+// ExportProjectSnapshot exports the domain layer rows of the project and returns the path of the archive,
+// the caller is responsible for removing the directory of the archive
+func ExportProjectSnapshot(projectName string, format string) (string, errors.Error) {
+	dir := filepath.Join(os.TempDir(), uuid.New().String())
+	if e := os.MkdirAll(dir, 0700); e != nil {
+		return "", errors.Convert(e)
+	}
+	archive := filepath.Join(dir, fmt.Sprintf("%s-snapshot.tar.gz", domainlayerhelper.SnapshotFileName(projectName)))
+	_, err := domainlayerhelper.ExportProjectSnapshot(db, logger.Nested("snapshot"), projectName, format, archive)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return archive, nil
+}
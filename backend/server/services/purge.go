@@ -0,0 +1,630 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm/schema"
+)
+
+const purgeBatchSize = 1000
+
+// gitextractor saves rows with `gitextractor` as the raw table and the repo id as the raw params
+const gitextractorPlugin = "gitextractor"
+
+// PurgeInput specifies what to purge, a project by name, a connection by plugin and connection id, or a scope
+// by plugin, connection id and scope id
+type PurgeInput struct {
+	ProjectName  string `json:"projectName"`
+	Plugin       string `json:"plugin"`
+	ConnectionId uint64 `json:"connectionId"`
+	ScopeId      string `json:"scopeId"`
+	DryRun       bool   `json:"dryRun"`
+}
+
+type PurgeQuery struct {
+	Pagination
+	Status string `form:"status"`
+}
+
+// PurgeOrigin is the origin of the rows to be purged, namely the rows collected by the plugin with the raw params
+type PurgeOrigin struct {
+	Plugin string   `json:"plugin"`
+	Params []string `json:"params"`
+}
+
+// PurgeReport lists the rows to be deleted by table
+type PurgeReport struct {
+	Origins []*PurgeOrigin `json:"origins"`
+	// SharedScopes are mapped to other projects as well, their data is kept when purging a project
+	SharedScopes []string             `json:"sharedScopes"`
+	Tables       []*models.PurgeTable `json:"tables"`
+	TotalRows    int64                `json:"totalRows"`
+}
+
+type purgeStep struct {
+	table       *models.PurgeTable
+	primaryKeys []string
+	where       string
+	params      []interface{}
+}
+
+// purgeLockName is the lock held by the instance creating or running a purge
+const purgeLockName = "purge"
+
+// PurgeDryRun reports the rows to be deleted by the purge without deleting anything
+func PurgeDryRun(input *PurgeInput) (*PurgeReport, errors.Error) {
+	report, _, err := planPurge(input)
+	return report, err
+}
+
+// CreatePurge starts deleting the rows in the background, the progress could be tracked by `GetPurge`.
+// The purge lock is held from the creation to the end of the purge so only one purge runs in the cluster.
+func CreatePurge(input *PurgeInput) (*models.Purge, errors.Error) {
+	type creation struct {
+		purge *models.Purge
+		err   errors.Error
+	}
+	created := make(chan creation, 1)
+	go func() {
+		locked, err := runWithLock(purgeLockName, func() errors.Error {
+			purge, steps, err := createPurge(input)
+			created <- creation{purge: purge, err: err}
+			if err == nil {
+				runPurge(purge, steps)
+			}
+			return nil
+		})
+		if err == nil && !locked {
+			err = errors.Conflict.New("another purge is in progress")
+		}
+		if err != nil {
+			created <- creation{err: err}
+		}
+	}()
+	result := <-created
+	return result.purge, result.err
+}
+
+// createPurge saves the purge planned for the input, the caller must hold the purge lock
+func createPurge(input *PurgeInput) (*models.Purge, []*purgeStep, errors.Error) {
+	// nobody else is running a purge while the lock is held
+	if err := markInterruptedPurges(); err != nil {
+		return nil, nil, err
+	}
+	report, steps, err := planPurge(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	purge := &models.Purge{
+		ProjectName:  input.ProjectName,
+		Plugin:       input.Plugin,
+		ConnectionId: input.ConnectionId,
+		ScopeId:      input.ScopeId,
+		Status:       models.TASK_CREATED,
+		TotalRows:    report.TotalRows,
+		Tables:       report.Tables,
+	}
+	if err = db.Create(purge); err != nil {
+		return nil, nil, err
+	}
+	return purge, steps, nil
+}
+
+// GetPurge returns the purge with its progress
+func GetPurge(id uint64) (*models.Purge, errors.Error) {
+	purge := &models.Purge{}
+	err := db.First(purge, dal.Where("id = ?", id))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.Wrap(err, fmt.Sprintf("purge %d not found", id))
+		}
+		return nil, err
+	}
+	return purge, nil
+}
+
+// GetPurges returns the purges, the latest first
+func GetPurges(query *PurgeQuery) ([]*models.Purge, int64, errors.Error) {
+	clauses := []dal.Clause{dal.From(&models.Purge{})}
+	if query.Status != "" {
+		clauses = append(clauses, dal.Where("status = ?", query.Status))
+	}
+	count, err := db.Count(clauses...)
+	if err != nil {
+		return nil, 0, err
+	}
+	clauses = append(clauses, dal.Orderby("id DESC"), dal.Offset(query.GetSkip()), dal.Limit(query.GetPageSize()))
+	purges := make([]*models.Purge, 0)
+	if err = db.All(&purges, clauses...); err != nil {
+		return nil, 0, err
+	}
+	return purges, count, nil
+}
+
+func purgeInit() {
+	// purges run in the background of the server, they are gone with the previous process unless another
+	// instance of the cluster is running them
+	_, err := runWithLock(purgeLockName, markInterruptedPurges)
+	if err != nil {
+		logger.Error(err, "failed to mark interrupted purges")
+	}
+}
+
+// markInterruptedPurges fails the unfinished purges, the caller must hold the purge lock
+func markInterruptedPurges() errors.Error {
+	return db.UpdateColumns(
+		&models.Purge{},
+		[]dal.DalSet{
+			{ColumnName: "status", Value: models.TASK_FAILED},
+			{ColumnName: "message", Value: "interrupted by the stop of the server running it, please start a new purge"},
+			{ColumnName: "finished_at", Value: time.Now()},
+		},
+		dal.Where("status IN ?", []string{models.TASK_CREATED, models.TASK_RUNNING}),
+	)
+}
+
+func planPurge(input *PurgeInput) (*PurgeReport, []*purgeStep, errors.Error) {
+	report := &PurgeReport{SharedScopes: []string{}, Tables: []*models.PurgeTable{}}
+	var origins map[string]map[string]bool
+	var err errors.Error
+	if input.ProjectName != "" {
+		if input.Plugin != "" {
+			return nil, nil, errors.BadInput.New("either projectName or plugin should be specified")
+		}
+		origins, report.SharedScopes, err = resolveProjectPurgeOrigins(input.ProjectName)
+	} else {
+		origins, err = resolvePluginPurgeOrigins(input.Plugin, input.ConnectionId, input.ScopeId)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	plugins := make([]string, 0, len(origins))
+	for pluginName := range origins {
+		plugins = append(plugins, pluginName)
+	}
+	sort.Strings(plugins)
+	for _, pluginName := range plugins {
+		origin := &PurgeOrigin{Plugin: pluginName}
+		for params := range origins[pluginName] {
+			origin.Params = append(origin.Params, params)
+		}
+		sort.Strings(origin.Params)
+		report.Origins = append(report.Origins, origin)
+	}
+	steps, err := makePurgeSteps(report.Origins, input.ProjectName)
+	if err != nil {
+		return nil, nil, err
+	}
+	tables := make(map[string]*models.PurgeTable)
+	var effectiveSteps []*purgeStep
+	for _, step := range steps {
+		count, err := db.Count(dal.From(step.table.Table), dal.Where(step.where, step.params...))
+		if err != nil {
+			return nil, nil, errors.Default.Wrap(err, fmt.Sprintf("failed to count rows of %s", step.table.Table))
+		}
+		if count == 0 {
+			continue
+		}
+		if table, ok := tables[step.table.Table]; ok {
+			step.table = table
+		} else {
+			tables[step.table.Table] = step.table
+			report.Tables = append(report.Tables, step.table)
+		}
+		step.table.Rows += count
+		report.TotalRows += count
+		effectiveSteps = append(effectiveSteps, step)
+	}
+	return report, effectiveSteps, nil
+}
+
+// resolveProjectPurgeOrigins finds the origins of the scopes of the project, scopes mapped to other projects are skipped
+func resolveProjectPurgeOrigins(projectName string) (map[string]map[string]bool, []string, errors.Error) {
+	if _, err := getProjectByName(db, projectName); err != nil {
+		return nil, nil, err
+	}
+	if err := ensureNoUnfinishedPipelines(projectName); err != nil {
+		return nil, nil, err
+	}
+	var mappings []crossdomain.ProjectMapping
+	if err := db.All(&mappings, dal.Where("project_name = ?", projectName)); err != nil {
+		return nil, nil, err
+	}
+	rowIds := make([]string, len(mappings))
+	for i, mapping := range mappings {
+		rowIds[i] = mapping.RowId
+	}
+	var others []crossdomain.ProjectMapping
+	if err := db.All(&others, dal.Where("row_id IN ? AND project_name != ?", rowIds, projectName)); err != nil {
+		return nil, nil, err
+	}
+	sharedWithOthers := make(map[string]bool)
+	for _, other := range others {
+		sharedWithOthers[fmt.Sprintf("%s:%s", other.Table, other.RowId)] = true
+	}
+	origins := make(map[string]map[string]bool)
+	shared := []string{}
+	for _, mapping := range mappings {
+		if scope := fmt.Sprintf("%s:%s", mapping.Table, mapping.RowId); sharedWithOthers[scope] {
+			shared = append(shared, scope)
+			continue
+		}
+		tabler := findDomainTable(mapping.Table)
+		if tabler == nil || !hasPurgeField(tabler, "RawDataParams") {
+			continue
+		}
+		var rows []struct {
+			RawDataTable  string `gorm:"column:_raw_data_table"`
+			RawDataParams string `gorm:"column:_raw_data_params"`
+		}
+		err := db.All(&rows, dal.Select("_raw_data_table, _raw_data_params"), dal.From(mapping.Table), dal.Where("id = ?", mapping.RowId))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			if pluginName := rawTablePlugin(row.RawDataTable); pluginName != "" && row.RawDataParams != "" {
+				addPurgeOrigin(origins, pluginName, row.RawDataParams)
+			}
+		}
+		if mapping.Table == "repos" {
+			addPurgeOrigin(origins, gitextractorPlugin, mapping.RowId)
+		}
+	}
+	return origins, shared, nil
+}
+
+// resolvePluginPurgeOrigins finds the origins of the scope, or all scopes of the connection if scopeId is empty
+func resolvePluginPurgeOrigins(pluginName string, connectionId uint64, scopeId string) (map[string]map[string]bool, errors.Error) {
+	if pluginName == "" || connectionId == 0 {
+		return nil, errors.BadInput.New("either projectName or plugin with connectionId is required")
+	}
+	if err := ensureNoUnfinishedPipelinesByConnection(pluginName, connectionId); err != nil {
+		return nil, err
+	}
+	scopes, err := loadPurgeScopes(pluginName, connectionId)
+	if err != nil {
+		return nil, err
+	}
+	origins := make(map[string]map[string]bool)
+	for _, scope := range scopes {
+		if scopeId == "" || scope.ScopeId() == scopeId {
+			addPurgeOrigin(origins, pluginName, plugin.MarshalScopeParams(scope.ScopeParams()))
+		}
+	}
+	if scopeId != "" && len(origins) == 0 {
+		return nil, errors.NotFound.New(fmt.Sprintf("scope %s of %s connection %d not found", scopeId, pluginName, connectionId))
+	}
+	if scopeId == "" {
+		// rows of the scopes deleted before are left with the connection id only
+		pluginMeta, _ := plugin.GetPlugin(pluginName)
+		if pluginModel, ok := pluginMeta.(plugin.PluginModel); ok {
+			for _, tabler := range pluginModel.GetTablesInfo() {
+				if isPurgeScopeModel(tabler) || !hasPurgeField(tabler, "RawDataParams") || !hasPurgeField(tabler, "ConnectionId") {
+					continue
+				}
+				var params []string
+				err = db.Pluck("DISTINCT _raw_data_params", &params, dal.From(tabler.TableName()), dal.Where("connection_id = ?", connectionId))
+				if err != nil {
+					return nil, err
+				}
+				for _, p := range params {
+					if p != "" {
+						addPurgeOrigin(origins, pluginName, p)
+					}
+				}
+			}
+		}
+	}
+	// repos of the scopes are cloned by gitextractor
+	for params := range origins[pluginName] {
+		var repoIds []string
+		err = db.Pluck("id", &repoIds, dal.From("repos"), dal.Where("_raw_data_table LIKE ? AND _raw_data_params = ?", fmt.Sprintf("_raw_%s%%", pluginName), params))
+		if err != nil {
+			return nil, err
+		}
+		for _, repoId := range repoIds {
+			addPurgeOrigin(origins, gitextractorPlugin, repoId)
+		}
+	}
+	return origins, nil
+}
+
+// loadPurgeScopes loads the scopes of the connection through the scope model of the plugin
+func loadPurgeScopes(pluginName string, connectionId uint64) ([]plugin.ToolLayerScope, errors.Error) {
+	pluginMeta, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("plugin %s not found", pluginName))
+	}
+	pluginSrc, ok := pluginMeta.(plugin.PluginSource)
+	if !ok || pluginSrc.Scope() == nil {
+		return nil, errors.BadInput.New(fmt.Sprintf("plugin %s has no scopes", pluginName))
+	}
+	scopeType := reflect.TypeOf(pluginSrc.Scope())
+	if scopeType.Kind() != reflect.Ptr || scopeType.Elem().Kind() != reflect.Struct {
+		return nil, errors.BadInput.New(fmt.Sprintf("purging data of plugin %s is not supported", pluginName))
+	}
+	slice := reflect.New(reflect.SliceOf(scopeType.Elem()))
+	err = db.All(slice.Interface(), dal.From(pluginSrc.Scope().TableName()), dal.Where("connection_id = ?", connectionId))
+	if err != nil {
+		return nil, err
+	}
+	scopes := make([]plugin.ToolLayerScope, slice.Elem().Len())
+	for i := range scopes {
+		scopes[i] = slice.Elem().Index(i).Addr().Interface().(plugin.ToolLayerScope)
+	}
+	return scopes, nil
+}
+
+// makePurgeSteps generates the conditions of the tables by the same rules as deleting the data of a scope
+func makePurgeSteps(origins []*PurgeOrigin, projectName string) ([]*purgeStep, errors.Error) {
+	allTables, err := db.AllTables()
+	if err != nil {
+		return nil, err
+	}
+	var steps []*purgeStep
+	addStep := func(layer string, tabler dal.Tabler, where string, params ...interface{}) errors.Error {
+		primaryKeys := []string{"id"}
+		if tabler != nil {
+			s, e := schema.Parse(models.UnwrapObject(tabler), &sync.Map{}, schema.NamingStrategy{})
+			if e != nil {
+				return errors.Default.Wrap(e, fmt.Sprintf("failed to parse the model of %s", tabler.TableName()))
+			}
+			primaryKeys = nil
+			for _, field := range s.PrimaryFields {
+				primaryKeys = append(primaryKeys, field.DBName)
+			}
+		}
+		steps = append(steps, &purgeStep{
+			table:       &models.PurgeTable{Table: tabler.TableName(), Layer: layer},
+			primaryKeys: primaryKeys,
+			where:       where,
+			params:      params,
+		})
+		return nil
+	}
+	for _, origin := range origins {
+		rawTablePrefix := fmt.Sprintf("_raw_%s%%", origin.Plugin)
+		// raw tables
+		for _, table := range allTables {
+			if strings.HasPrefix(table, "_raw_") && rawTablePlugin(table) == origin.Plugin {
+				steps = append(steps, &purgeStep{
+					table:       &models.PurgeTable{Table: table, Layer: models.PURGE_LAYER_RAW},
+					primaryKeys: []string{"id"},
+					where:       "params IN ?",
+					params:      []interface{}{origin.Params},
+				})
+			}
+		}
+		// tool tables, the scopes are configuration thus kept
+		if pluginMeta, e := plugin.GetPlugin(origin.Plugin); e == nil {
+			if pluginModel, ok := pluginMeta.(plugin.PluginModel); ok {
+				for _, tabler := range pluginModel.GetTablesInfo() {
+					if isPurgeScopeModel(tabler) || !hasPurgeField(tabler, "RawDataParams") || !slices.Contains(allTables, tabler.TableName()) {
+						continue
+					}
+					if err = addStep(models.PURGE_LAYER_TOOL, tabler, "_raw_data_params IN ?", origin.Params); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		// domain tables
+		for _, tabler := range domaininfo.GetDomainTablesInfo() {
+			if !hasPurgeField(tabler, "RawDataParams") {
+				continue
+			}
+			err = addStep(
+				models.PURGE_LAYER_DOMAIN, tabler,
+				"(_raw_data_table LIKE ? OR _raw_data_table = ?) AND _raw_data_params IN ?",
+				rawTablePrefix, origin.Plugin, origin.Params,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// framework tables
+		for _, tabler := range []dal.Tabler{&models.CollectorLatestState{}, &models.RawDataSync{}} {
+			if err = addStep(models.PURGE_LAYER_FRAMEWORK, tabler, "raw_data_table LIKE ? AND raw_data_params IN ?", rawTablePrefix, origin.Params); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if projectName != "" {
+		// metrics of the project, the mapping is kept so the project still knows its scopes
+		for _, tabler := range domaininfo.GetDomainTablesInfo() {
+			if tabler.TableName() == (crossdomain.ProjectMapping{}).TableName() || !hasPurgeField(tabler, "ProjectName") {
+				continue
+			}
+			if err = addStep(models.PURGE_LAYER_DOMAIN, tabler, "project_name = ?", projectName); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return steps, nil
+}
+
+func addPurgeOrigin(origins map[string]map[string]bool, pluginName string, params string) {
+	if origins[pluginName] == nil {
+		origins[pluginName] = make(map[string]bool)
+	}
+	origins[pluginName][params] = true
+}
+
+func isPurgeScopeModel(tabler dal.Tabler) bool {
+	_, ok := tabler.(plugin.ToolLayerScope)
+	return ok
+}
+
+func hasPurgeField(tabler dal.Tabler, fieldName string) bool {
+	typ := reflect.TypeOf(models.UnwrapObject(tabler))
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	_, ok := typ.FieldByName(fieldName)
+	return ok
+}
+
+func ensureNoUnfinishedPipelines(projectName string) errors.Error {
+	blueprint, err := GetBlueprintByProjectName(projectName)
+	if err != nil || blueprint == nil {
+		return err
+	}
+	return ensureNoUnfinishedPipelinesByBlueprint(blueprint)
+}
+
+func ensureNoUnfinishedPipelinesByConnection(pluginName string, connectionId uint64) errors.Error {
+	for _, blueprint := range bpManager.GetBlueprintsByConnection(pluginName, connectionId) {
+		if err := ensureNoUnfinishedPipelinesByBlueprint(blueprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ensureNoUnfinishedPipelinesByBlueprint(blueprint *models.Blueprint) errors.Error {
+	unfinished, err := thereAreUnfinishedPipelinesUnderBlueprint(blueprint.ID)
+	if err != nil {
+		return err
+	}
+	if unfinished {
+		return errors.Conflict.New(fmt.Sprintf("there are unfinished pipelines of blueprint %s, please purge after they are finished", blueprint.Name))
+	}
+	return nil
+}
+
+func runPurge(purge *models.Purge, steps []*purgeStep) {
+	purgeLogger := logger.Nested("purge")
+	now := time.Now()
+	purge.Status = models.TASK_RUNNING
+	purge.BeganAt = &now
+	if err := db.Update(purge); err != nil {
+		purgeLogger.Error(err, "failed to update purge %d", purge.ID)
+	}
+	var err errors.Error
+	for _, step := range steps {
+		for {
+			var deleted int64
+			deleted, err = deletePurgeBatch(step, purgeBatchSize)
+			if err != nil || deleted == 0 {
+				break
+			}
+			step.table.Deleted += deleted
+			purge.DeletedRows += deleted
+			if purge.TotalRows > 0 {
+				purge.Progress = float32(purge.DeletedRows) / float32(purge.TotalRows)
+			}
+			if purge.Progress > 1 {
+				purge.Progress = 1
+			}
+			if e := db.Update(purge); e != nil {
+				purgeLogger.Error(e, "failed to update purge %d", purge.ID)
+			}
+		}
+		if err != nil {
+			break
+		}
+		purgeLogger.Info("purged %d rows of %s", step.table.Deleted, step.table.Table)
+	}
+	finishedAt := time.Now()
+	purge.FinishedAt = &finishedAt
+	purge.SpentSeconds = int(finishedAt.Unix() - now.Unix())
+	if err != nil {
+		purgeLogger.Error(err, "purge %d failed", purge.ID)
+		purge.Status = models.TASK_FAILED
+		purge.Message = err.Error()
+	} else {
+		purge.Status = models.TASK_COMPLETED
+		purge.Progress = 1
+	}
+	if e := db.Update(purge); e != nil {
+		purgeLogger.Error(e, "failed to update purge %d", purge.ID)
+	}
+}
+
+// deletePurgeBatch deletes at most `size` rows matching the step by their primary keys, which works
+// with all databases as `DELETE ... LIMIT` is not supported by postgres
+func deletePurgeBatch(step *purgeStep, size int) (int64, errors.Error) {
+	cursor, err := db.Cursor(
+		dal.Select(strings.Join(step.primaryKeys, ", ")),
+		dal.From(step.table.Table),
+		dal.Where(step.where, step.params...),
+		dal.Limit(size),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var keys [][]interface{}
+	for cursor.Next() {
+		key := make([]interface{}, len(step.primaryKeys))
+		dest := make([]interface{}, len(key))
+		for i := range key {
+			dest[i] = &key[i]
+		}
+		if e := cursor.Scan(dest...); e != nil {
+			cursor.Close()
+			return 0, errors.Convert(e)
+		}
+		keys = append(keys, key)
+	}
+	cursor.Close()
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	where, params := purgeKeysCondition(step.primaryKeys, keys)
+	err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", step.table.Table, where), params...)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(keys)), nil
+}
+
+func purgeKeysCondition(primaryKeys []string, keys [][]interface{}) (string, []interface{}) {
+	if len(primaryKeys) == 1 {
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = key[0]
+		}
+		return fmt.Sprintf("%s IN ?", primaryKeys[0]), []interface{}{values}
+	}
+	conditions := make([]string, len(primaryKeys))
+	for i, pk := range primaryKeys {
+		conditions[i] = fmt.Sprintf("%s = ?", pk)
+	}
+	condition := "(" + strings.Join(conditions, " AND ") + ")"
+	ors := make([]string, len(keys))
+	params := make([]interface{}, 0, len(keys)*len(primaryKeys))
+	for i, key := range keys {
+		ors[i] = condition
+		params = append(params, key...)
+	}
+	return strings.Join(ors, " OR "), params
+}
@@ -0,0 +1,248 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeKeysCondition(t *testing.T) {
+	where, params := purgeKeysCondition([]string{"id"}, [][]interface{}{{1}, {2}})
+	assert.Equal(t, "id IN ?", where)
+	assert.Equal(t, []interface{}{[]interface{}{1, 2}}, params)
+
+	where, params = purgeKeysCondition([]string{"repo_id", "commit_sha"}, [][]interface{}{{"r1", "a"}, {"r2", "b"}})
+	assert.Equal(t, "(repo_id = ? AND commit_sha = ?) OR (repo_id = ? AND commit_sha = ?)", where)
+	assert.Equal(t, []interface{}{"r1", "a", "r2", "b"}, params)
+}
+
+func TestHasPurgeField(t *testing.T) {
+	assert.True(t, hasPurgeField(&code.PullRequest{}, "RawDataParams"))
+	assert.False(t, hasPurgeField(&code.PullRequest{}, "ProjectName"))
+	assert.True(t, hasPurgeField(&crossdomain.ProjectPrMetric{}, "ProjectName"))
+}
+
+type purgeTestParams struct {
+	ConnectionId uint64
+	Name         string
+}
+
+type purgeTestScope struct {
+	ConnectionId uint64 `gorm:"primaryKey"`
+	Id           string `gorm:"primaryKey;type:varchar(255)"`
+	Name         string `gorm:"type:varchar(255)"`
+}
+
+func (s purgeTestScope) ScopeId() string           { return s.Id }
+func (s purgeTestScope) ScopeName() string         { return s.Name }
+func (s purgeTestScope) ScopeFullName() string     { return s.Name }
+func (s purgeTestScope) ScopeParams() interface{}  { return &purgeTestParams{s.ConnectionId, s.Name} }
+func (s purgeTestScope) ScopeConnectionId() uint64 { return s.ConnectionId }
+func (purgeTestScope) ScopeScopeConfigId() uint64  { return 0 }
+func (purgeTestScope) TableName() string           { return "_tool_purgetest_scopes" }
+
+type purgeTestIssue struct {
+	ConnectionId uint64 `gorm:"primaryKey"`
+	Id           string `gorm:"primaryKey;type:varchar(255)"`
+	common.NoPKModel
+}
+
+func (purgeTestIssue) TableName() string { return "_tool_purgetest_issues" }
+
+// purgeTestPlugin is a data source plugin with scopes and tool tables
+type purgeTestPlugin struct {
+	testPlugin
+}
+
+func (p *purgeTestPlugin) Connection() dal.Tabler       { return nil }
+func (p *purgeTestPlugin) Scope() plugin.ToolLayerScope { return &purgeTestScope{} }
+func (p *purgeTestPlugin) ScopeConfig() dal.Tabler      { return nil }
+func (p *purgeTestPlugin) GetTablesInfo() []dal.Tabler {
+	return []dal.Tabler{&purgeTestScope{}, &purgeTestIssue{}}
+}
+
+var (
+	purgeTestParams1 = plugin.MarshalScopeParams(&purgeTestParams{1, "org/a"})
+	purgeTestParams2 = plugin.MarshalScopeParams(&purgeTestParams{1, "org/b"})
+	// params of a scope deleted before
+	purgeTestParams3 = plugin.MarshalScopeParams(&purgeTestParams{1, "org/c"})
+)
+
+func purgeTestOrigin(table string, params string) common.NoPKModel {
+	return common.NoPKModel{RawDataOrigin: common.RawDataOrigin{RawDataTable: table, RawDataParams: params}}
+}
+
+// setupPurgeTest creates rows of two scopes of the purgetest plugin in all layers, along with raw rows of
+// purgetest_graphql sharing the same params
+func setupPurgeTest(t *testing.T) {
+	setupTestDb(t)
+	require.Nil(t, plugin.RegisterPlugin("purgetest", &purgeTestPlugin{testPlugin{name: "purgetest"}}))
+	registerTestPlugin(t, "purgetest_graphql")
+	require.Nil(t, db.AutoMigrate(&purgeTestScope{}))
+	require.Nil(t, db.AutoMigrate(&purgeTestIssue{}))
+	require.Nil(t, db.Create([]*purgeTestScope{{1, "a", "org/a"}, {1, "b", "org/b"}}))
+	for table, params := range map[string][]string{
+		"_raw_purgetest_api_issues":         {purgeTestParams1, purgeTestParams1, purgeTestParams2},
+		"_raw_purgetest_graphql_api_issues": {purgeTestParams1},
+	} {
+		require.Nil(t, db.AutoMigrate(&api.RawData{}, dal.From(table)))
+		for _, p := range params {
+			require.Nil(t, db.Create(&api.RawData{Params: p, Data: []byte(`{}`)}, dal.From(table)))
+		}
+	}
+	require.Nil(t, db.Create([]*purgeTestIssue{
+		{ConnectionId: 1, Id: "1", NoPKModel: purgeTestOrigin("_raw_purgetest_api_issues", purgeTestParams1)},
+		{ConnectionId: 1, Id: "2", NoPKModel: purgeTestOrigin("_raw_purgetest_api_issues", purgeTestParams2)},
+		{ConnectionId: 1, Id: "3", NoPKModel: purgeTestOrigin("_raw_purgetest_api_issues", purgeTestParams3)},
+	}))
+	require.Nil(t, db.Create([]*ticket.Issue{
+		{DomainEntity: domainlayer.DomainEntity{Id: "purgetest:1", NoPKModel: purgeTestOrigin("_raw_purgetest_api_issues", purgeTestParams1)}},
+		{DomainEntity: domainlayer.DomainEntity{Id: "purgetest:2", NoPKModel: purgeTestOrigin("_raw_purgetest_api_issues", purgeTestParams2)}},
+	}))
+	require.Nil(t, db.Create([]*code.Repo{
+		{DomainEntity: domainlayer.DomainEntity{Id: "repo:a", NoPKModel: purgeTestOrigin("_raw_purgetest_api_repos", purgeTestParams1)}},
+		{DomainEntity: domainlayer.DomainEntity{Id: "repo:b", NoPKModel: purgeTestOrigin("_raw_purgetest_api_repos", purgeTestParams2)}},
+	}))
+	require.Nil(t, db.Create(&code.Commit{Sha: "sha1", NoPKModel: purgeTestOrigin(gitextractorPlugin, "repo:a")}))
+	require.Nil(t, db.Create(&models.RawDataSync{RawDataTable: "_raw_purgetest_api_issues", RawDataParams: purgeTestParams1}))
+}
+
+func purgeTableRows(report *PurgeReport) map[string]int64 {
+	rows := make(map[string]int64)
+	for _, table := range report.Tables {
+		rows[table.Table] = table.Rows
+	}
+	return rows
+}
+
+func TestPlanPurgeOfScope(t *testing.T) {
+	setupPurgeTest(t)
+	report, steps, err := planPurge(&PurgeInput{Plugin: "purgetest", ConnectionId: 1, ScopeId: "a"})
+	require.Nil(t, err)
+	assert.Equal(t, []*PurgeOrigin{
+		{Plugin: gitextractorPlugin, Params: []string{"repo:a"}},
+		{Plugin: "purgetest", Params: []string{purgeTestParams1}},
+	}, report.Origins)
+	// raw tables of purgetest_graphql are not owned by purgetest
+	assert.Equal(t, map[string]int64{
+		"_raw_purgetest_api_issues": 2,
+		"_tool_purgetest_issues":    1,
+		"issues":                    1,
+		"repos":                     1,
+		"commits":                   1,
+		"_devlake_raw_data_syncs":   1,
+	}, purgeTableRows(report))
+	assert.Equal(t, int64(7), report.TotalRows)
+	assert.Len(t, steps, 6)
+
+	_, _, err = planPurge(&PurgeInput{Plugin: "purgetest", ConnectionId: 1, ScopeId: "z"})
+	assert.Equal(t, errors.NotFound, err.GetType())
+	_, _, err = planPurge(&PurgeInput{Plugin: "purgetest"})
+	assert.Equal(t, errors.BadInput, err.GetType())
+}
+
+func TestPlanPurgeOfConnection(t *testing.T) {
+	setupPurgeTest(t)
+	report, _, err := planPurge(&PurgeInput{Plugin: "purgetest", ConnectionId: 1})
+	require.Nil(t, err)
+	// rows of the deleted scope are found by the connection id
+	assert.Equal(t, []*PurgeOrigin{
+		{Plugin: gitextractorPlugin, Params: []string{"repo:a", "repo:b"}},
+		{Plugin: "purgetest", Params: []string{purgeTestParams1, purgeTestParams2, purgeTestParams3}},
+	}, report.Origins)
+	assert.Equal(t, int64(3), purgeTableRows(report)["_tool_purgetest_issues"])
+}
+
+func TestPlanPurgeOfProject(t *testing.T) {
+	setupPurgeTest(t)
+	for _, name := range []string{"p1", "p2"} {
+		require.Nil(t, db.Create(&models.Project{BaseProject: models.BaseProject{Name: name}}))
+	}
+	require.Nil(t, db.Create([]*crossdomain.ProjectMapping{
+		{ProjectName: "p1", Table: "repos", RowId: "repo:a"},
+		{ProjectName: "p1", Table: "repos", RowId: "repo:b"},
+		{ProjectName: "p2", Table: "repos", RowId: "repo:b"},
+	}))
+	require.Nil(t, db.Create(&crossdomain.ProjectPrMetric{ProjectName: "p1", DomainEntity: domainlayer.DomainEntity{Id: "pr1"}}))
+	report, _, err := planPurge(&PurgeInput{ProjectName: "p1"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"repos:repo:b"}, report.SharedScopes)
+	assert.Equal(t, []*PurgeOrigin{
+		{Plugin: gitextractorPlugin, Params: []string{"repo:a"}},
+		{Plugin: "purgetest", Params: []string{purgeTestParams1}},
+	}, report.Origins)
+	assert.Equal(t, int64(1), purgeTableRows(report)["project_pr_metrics"])
+
+	_, _, err = planPurge(&PurgeInput{ProjectName: "p3"})
+	assert.Equal(t, errors.NotFound, err.GetType())
+}
+
+func TestCreatePurge(t *testing.T) {
+	setupPurgeTest(t)
+	// left by a server stopped in the middle of the purge
+	require.Nil(t, db.Create(&models.Purge{Status: models.TASK_RUNNING}))
+
+	purge, err := CreatePurge(&PurgeInput{Plugin: "purgetest", ConnectionId: 1, ScopeId: "a"})
+	require.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		purge, err = GetPurge(purge.ID)
+		require.Nil(t, err)
+		return purge.Status == models.TASK_COMPLETED
+	}, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(7), purge.DeletedRows)
+	assert.Equal(t, float32(1), purge.Progress)
+	interrupted, err := GetPurge(1)
+	require.Nil(t, err)
+	assert.Equal(t, models.TASK_FAILED, interrupted.Status)
+
+	count := func(table string) int64 {
+		n, err := db.Count(dal.From(table))
+		require.Nil(t, err)
+		return n
+	}
+	assert.Equal(t, int64(1), count("_raw_purgetest_api_issues"))
+	assert.Equal(t, int64(1), count("_raw_purgetest_graphql_api_issues"))
+	assert.Equal(t, int64(2), count("_tool_purgetest_issues"))
+	assert.Equal(t, int64(2), count("_tool_purgetest_scopes"))
+	assert.Equal(t, int64(1), count("issues"))
+	assert.Equal(t, int64(1), count("repos"))
+	assert.Equal(t, int64(0), count("commits"))
+
+	// only one purge runs at a time
+	locked, err := runWithLock(purgeLockName, func() errors.Error {
+		_, err := CreatePurge(&PurgeInput{Plugin: "purgetest", ConnectionId: 1, ScopeId: "b"})
+		assert.Equal(t, errors.Conflict, err.GetType())
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, locked)
+}
@@ -18,15 +18,311 @@ limitations under the License.
 package services
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"gorm.io/gorm/schema"
 )
 
-// InsertRow FIXME ...
-func InsertRow(table string, rows []map[string]interface{}) (int64, errors.Error) {
-	err := db.Create(rows, dal.From(table))
+const pushBatchSize = 500
+
+const defaultPushMaxBodySize = 100
+
+// PushMaxBodySize returns the max size in bytes of the request body of the push api, by PUSH_MAX_BODY_SIZE in MB
+func PushMaxBodySize() int64 {
+	size := cfg.GetInt64("PUSH_MAX_BODY_SIZE")
+	if size <= 0 {
+		size = defaultPushMaxBodySize
+	}
+	return size << 20
+}
+
+// PushRowError is the error of a row, Index is the position of the row in the request starting from 0
+type PushRowError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// PushResult reports the number of rows written and the errors of the rows rejected
+type PushResult struct {
+	RowsAffected int64          `json:"rowsAffected"`
+	Failed       int            `json:"failed"`
+	Errors       []PushRowError `json:"errors"`
+}
+
+func (r *PushResult) fail(index int, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, PushRowError{Index: index, Error: err.Error()})
+}
+
+type pushRow struct {
+	index     int
+	values    map[string]interface{}
+	signature string
+}
+
+// PushRows validates rows against the model of the domain layer table and upserts them by primary key.
+// The body is either a json array of objects, or objects separated by newlines when `ndjson` is true.
+// Invalid rows are reported in the result without failing the others.
+func PushRows(table string, body io.Reader, ndjson bool) (*PushResult, errors.Error) {
+	tabler := findDomainTable(table)
+	if tabler == nil {
+		return nil, errors.BadInput.New(fmt.Sprintf("table %s is not a domain layer table", table))
+	}
+	s, e := schema.Parse(tabler, &sync.Map{}, schema.NamingStrategy{})
+	if e != nil {
+		return nil, errors.Default.Wrap(e, fmt.Sprintf("failed to parse the model of %s", table))
+	}
+	result := &PushResult{Errors: []PushRowError{}}
+	var pending []*pushRow
+	err := readPushRows(body, ndjson, func(index int, row map[string]interface{}, err error) errors.Error {
+		if err != nil {
+			result.fail(index, err)
+			return nil
+		}
+		values, err := validatePushRow(s, row)
+		if err != nil {
+			result.fail(index, err)
+			return nil
+		}
+		pending = append(pending, &pushRow{index: index, values: values, signature: pushRowSignature(values)})
+		if len(pending) >= pushBatchSize {
+			upsertPushRows(tabler, pending, result)
+			pending = pending[:0]
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	upsertPushRows(tabler, pending, result)
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Index < result.Errors[j].Index
+	})
+	return result, nil
+}
+
+// readPushRows calls the callback for every row of the body, a malformed row is passed as an error unless
+// it breaks the json array in which case reading stops
+func readPushRows(body io.Reader, ndjson bool, callback func(index int, row map[string]interface{}, err error) errors.Error) errors.Error {
+	parseRow := func(raw []byte) (map[string]interface{}, error) {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var row map[string]interface{}
+		if err := decoder.Decode(&row); err != nil {
+			if _, isTypeErr := err.(*json.UnmarshalTypeError); isTypeErr {
+				return nil, fmt.Errorf("row must be an object")
+			}
+			return nil, err
+		}
+		if row == nil {
+			return nil, fmt.Errorf("row must be an object")
+		}
+		return row, nil
+	}
+	if ndjson {
+		reader := bufio.NewReader(body)
+		index := 0
+		for {
+			line, e := reader.ReadBytes('\n')
+			if e != nil && e != io.EOF {
+				return pushReadError(e, "failed to read the request body")
+			}
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				row, err := parseRow(line)
+				if err := callback(index, row, err); err != nil {
+					return err
+				}
+				index++
+			}
+			if e == io.EOF {
+				return nil
+			}
+		}
+	}
+	decoder := json.NewDecoder(body)
+	token, e := decoder.Token()
+	if e != nil {
+		return pushReadError(e, "failed to read the request body")
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return errors.BadInput.New("the request body must be a json array of rows")
+	}
+	for index := 0; decoder.More(); index++ {
+		var raw json.RawMessage
+		if e = decoder.Decode(&raw); e != nil {
+			return pushReadError(e, fmt.Sprintf("malformed json at row %d", index))
+		}
+		row, err := parseRow(raw)
+		if err := callback(index, row, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushReadError tells the body exceeding the limit set by http.MaxBytesReader apart from the malformed one
+func pushReadError(e error, message string) errors.Error {
+	if maxBytesErr, ok := e.(*http.MaxBytesError); ok {
+		return errors.HttpStatus(http.StatusRequestEntityTooLarge).New(
+			fmt.Sprintf("the request body exceeds the max size of %d bytes", maxBytesErr.Limit),
+		)
+	}
+	return errors.BadInput.Wrap(e, message)
+}
+
+// validatePushRow converts the row into column values with the types of the model fields, the primary key is required
+func validatePushRow(s *schema.Schema, row map[string]interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(row))
+	for key, value := range row {
+		field := s.LookUpField(key)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("unknown column %s", key)
+		}
+		converted, err := convertPushValue(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %s", field.DBName, err.Error())
+		}
+		values[field.DBName] = converted
+	}
+	for _, field := range s.PrimaryFields {
+		if value, ok := values[field.DBName]; !ok || value == nil || value == "" {
+			return nil, fmt.Errorf("primary key %s is required", field.DBName)
+		}
+	}
+	return values, nil
+}
+
+func convertPushValue(field *schema.Field, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	number, isNumber := value.(json.Number)
+	switch field.GORMDataType {
+	case schema.String:
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		default:
+			return nil, fmt.Errorf("expecting a string, got %v", value)
+		}
+		if size := pushFieldSize(field); size > 0 && utf8.RuneCountInString(s) > size {
+			return nil, fmt.Errorf("exceeds the max length %d", size)
+		}
+		return s, nil
+	case schema.Int, schema.Uint:
+		if !isNumber {
+			return nil, fmt.Errorf("expecting an integer, got %v", value)
+		}
+		i, err := number.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("expecting an integer, got %v", value)
+		}
+		if field.GORMDataType == schema.Uint && i < 0 {
+			return nil, fmt.Errorf("expecting a non-negative integer, got %v", value)
+		}
+		return i, nil
+	case schema.Float:
+		if !isNumber {
+			return nil, fmt.Errorf("expecting a number, got %v", value)
+		}
+		f, err := number.Float64()
+		if err != nil || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("expecting a number, got %v", value)
+		}
+		return f, nil
+	case schema.Bool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expecting a boolean, got %v", value)
+	case schema.Time:
+		if s, ok := value.(string); ok {
+			t, err := common.ConvertStringToTime(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a valid time", s)
+			}
+			return t, nil
+		}
+		return nil, fmt.Errorf("expecting a time string, got %v", value)
+	}
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+	return value, nil
+}
+
+var charTypePattern = regexp.MustCompile(`(?i)char\((\d+)\)`)
+
+// pushFieldSize returns the max length of the string field, by the `size` tag or the `type:varchar(n)` tag
+func pushFieldSize(field *schema.Field) int {
+	if field.Size > 0 {
+		return field.Size
+	}
+	if matches := charTypePattern.FindStringSubmatch(string(field.DataType)); matches != nil {
+		size, _ := strconv.Atoi(matches[1])
+		return size
+	}
+	return 0
+}
+
+// pushRowSignature identifies the columns of the row, consecutive rows of the same columns are upserted in
+// batches so the columns absent from a row are left untouched
+func pushRowSignature(values map[string]interface{}) string {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return strings.Join(columns, ",")
+}
+
+// upsertPushRows writes the rows in the order of the request, so the last one wins when rows share the primary key
+func upsertPushRows(tabler dal.Tabler, rows []*pushRow, result *PushResult) {
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].signature == rows[start].signature {
+			end++
+		}
+		upsertPushBatch(tabler, rows[start:end], result)
+		start = end
+	}
+}
+
+// upsertPushBatch writes the rows of the same columns at once, rows of a failed batch are retried one by one to
+// locate the bad ones
+func upsertPushBatch(tabler dal.Tabler, batch []*pushRow, result *PushResult) {
+	values := make([]map[string]interface{}, len(batch))
+	for i, row := range batch {
+		values[i] = row.values
+	}
+	if err := db.CreateOrUpdate(&values, dal.From(tabler)); err == nil {
+		result.RowsAffected += int64(len(batch))
+		return
+	}
+	for _, row := range batch {
+		if err := db.CreateOrUpdate(&row.values, dal.From(tabler)); err != nil {
+			result.fail(row.index, err)
+			continue
+		}
+		result.RowsAffected++
 	}
-	return 1, nil
 }
@@ -0,0 +1,169 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/schema"
+)
+
+func TestValidatePushRow(t *testing.T) {
+	s, err := schema.Parse(&code.PullRequest{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.Nil(t, err)
+	parse := func(body string) map[string]interface{} {
+		var row map[string]interface{}
+		assert.Nil(t, readPushRows(strings.NewReader(body), true, func(index int, r map[string]interface{}, err error) errors.Error {
+			assert.Nil(t, err)
+			row = r
+			return nil
+		}))
+		return row
+	}
+
+	values, e := validatePushRow(s, parse(`{"id": "pr1", "title": "fix", "additions": 3, "is_draft": true, "created_date": "2024-01-02T03:04:05Z", "merged_date": null}`))
+	assert.Nil(t, e)
+	assert.Equal(t, map[string]interface{}{
+		"id":           "pr1",
+		"title":        "fix",
+		"additions":    int64(3),
+		"is_draft":     true,
+		"created_date": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"merged_date":  nil,
+	}, values)
+
+	for body, message := range map[string]string{
+		`{"title": "fix"}`:                                            "primary key id is required",
+		`{"id": "pr1", "foo": 1}`:                                     "unknown column foo",
+		`{"id": "pr1", "additions": "3"}`:                             "column additions: expecting an integer, got 3",
+		`{"id": "pr1", "additions": 1.5}`:                             "column additions: expecting an integer, got 1.5",
+		`{"id": "pr1", "is_draft": 1}`:                                "column is_draft: expecting a boolean, got 1",
+		`{"id": "pr1", "created_date": "someday"}`:                    "column created_date: someday is not a valid time",
+		`{"id": "pr1", "title": {"text": "fix"}}`:                     "column title: expecting a string, got map[text:fix]",
+		`{"id": "pr1", "status": "` + strings.Repeat("x", 101) + `"}`: "column status: exceeds the max length 100",
+	} {
+		_, e = validatePushRow(s, parse(body))
+		if assert.NotNil(t, e, body) {
+			assert.Equal(t, message, e.Error(), body)
+		}
+	}
+}
+
+func TestReadPushRows(t *testing.T) {
+	type read struct {
+		index int
+		id    interface{}
+		err   string
+	}
+	collect := func(body string, ndjson bool) ([]read, errors.Error) {
+		var reads []read
+		err := readPushRows(strings.NewReader(body), ndjson, func(index int, row map[string]interface{}, err error) errors.Error {
+			r := read{index: index}
+			if err != nil {
+				r.err = err.Error()
+			} else {
+				r.id = row["id"]
+			}
+			reads = append(reads, r)
+			return nil
+		})
+		return reads, err
+	}
+
+	reads, err := collect(`[{"id": "a"}, 1, null, {"id": "b"}]`, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []read{
+		{index: 0, id: "a"},
+		{index: 1, err: "row must be an object"},
+		{index: 2, err: "row must be an object"},
+		{index: 3, id: "b"},
+	}, reads)
+
+	reads, err = collect("{\"id\": \"a\"}\n\n{bad\n{\"id\": \"b\"}", true)
+	assert.Nil(t, err)
+	assert.Len(t, reads, 3)
+	assert.Equal(t, "a", reads[0].id)
+	assert.NotEmpty(t, reads[1].err)
+	assert.Equal(t, read{index: 2, id: "b"}, reads[2])
+
+	_, err = collect(`{"id": "a"}`, false)
+	assert.NotNil(t, err)
+	_, err = collect(`[{"id": "a"}, {"id"`, false)
+	assert.NotNil(t, err)
+}
+
+func TestPushRows(t *testing.T) {
+	setupTestDb(t)
+	getPr := func(id string) *code.PullRequest {
+		pr := &code.PullRequest{}
+		require.Nil(t, db.First(pr, dal.Where("id = ?", id)))
+		return pr
+	}
+
+	// insert
+	result, err := PushRows("pull_requests", strings.NewReader(`[
+		{"id": "pr1", "title": "fix", "status": "OPEN"},
+		{"id": "pr2", "title": "feat", "additions": "many"},
+		{"id": "pr3", "title": "docs"}
+	]`), false)
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), result.RowsAffected)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 1, result.Errors[0].Index)
+	assert.Equal(t, "OPEN", getPr("pr1").Status)
+	count, err := db.Count(dal.From(&code.PullRequest{}))
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// update, columns absent from the row are left untouched
+	result, err = PushRows("pull_requests", strings.NewReader("{\"id\": \"pr1\", \"title\": \"fix a bug\"}\n"), true)
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected)
+	pr := getPr("pr1")
+	assert.Equal(t, "fix a bug", pr.Title)
+	assert.Equal(t, "OPEN", pr.Status)
+
+	// rows sharing the primary key are written in the order of the request
+	result, err = PushRows("pull_requests", strings.NewReader(`[
+		{"id": "pr3", "title": "docs v2"},
+		{"id": "pr3", "title": "docs v3", "status": "MERGED"},
+		{"id": "pr3", "title": "docs v4"}
+	]`), false)
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), result.RowsAffected)
+	pr = getPr("pr3")
+	assert.Equal(t, "docs v4", pr.Title)
+	assert.Equal(t, "MERGED", pr.Status)
+
+	// the body is limited by http.MaxBytesReader in the api
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`[{"id": "pr4", "title": "too long"}]`)), 10)
+	_, err = PushRows("pull_requests", body, false)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.GetType().GetHttpCode())
+}
@@ -0,0 +1,414 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/version"
+	"github.com/apache/incubator-devlake/helpers/dbhelper"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/google/uuid"
+)
+
+// RAW_BUNDLE_VERSION is the version of the raw bundle format
+const RAW_BUNDLE_VERSION = 1
+
+const rawBundleManifest = "manifest.json"
+
+var rawTableNamePattern = regexp.MustCompile(`^_raw_[a-z0-9_]+$`)
+
+// RawBundleQuery selects the raw rows to be exported
+type RawBundleQuery struct {
+	Plugin       string `form:"plugin"`
+	ConnectionId uint64 `form:"connectionId"`
+	// Scope matches any value of the raw params other than the ConnectionId, i.e. the repo name for github
+	// or the board id for jira, all scopes of the connection are exported if it is empty
+	Scope string `form:"scope"`
+	// Params matches the raw params exactly, it takes precedence over ConnectionId and Scope
+	Params string `form:"params"`
+}
+
+// RawBundleTable describes a raw table in the bundle
+type RawBundleTable struct {
+	Name   string   `json:"name"`
+	Params []string `json:"params"`
+	Rows   int64    `json:"rows"`
+}
+
+// RawBundleManifest describes the content of a raw bundle
+type RawBundleManifest struct {
+	Version        int              `json:"version"`
+	DevlakeVersion string           `json:"devlakeVersion"`
+	Plugin         string           `json:"plugin"`
+	ConnectionId   uint64           `json:"connectionId"`
+	Scope          string           `json:"scope"`
+	Params         string           `json:"params"`
+	ExportedAt     time.Time        `json:"exportedAt"`
+	Tables         []RawBundleTable `json:"tables"`
+}
+
+// rawBundleRow is a raw row in the bundle, Data is kept as it is if it's a json document to make the bundle readable
+type rawBundleRow struct {
+	Params     string          `json:"params"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	DataBase64 []byte          `json:"dataBase64,omitempty"`
+	Url        string          `json:"url"`
+	Input      json.RawMessage `json:"input,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// ExportRawBundle exports the raw rows selected by the query to a gzipped tar archive, which consists of the
+// manifest.json and a newline delimited json file for each of the raw tables, and returns the archive path.
+func ExportRawBundle(query *RawBundleQuery) (string, errors.Error) {
+	if query.Plugin == "" {
+		return "", errors.BadInput.New("plugin is required")
+	}
+	if _, err := plugin.GetPlugin(query.Plugin); err != nil {
+		return "", errors.BadInput.Wrap(err, fmt.Sprintf("plugin %s not found", query.Plugin))
+	}
+	if query.Params == "" && query.ConnectionId == 0 {
+		return "", errors.BadInput.New("either connectionId or params is required")
+	}
+	tables, err := findPluginRawTables(query.Plugin)
+	if err != nil {
+		return "", err
+	}
+	dir, e := os.MkdirTemp("", "raw-bundle-")
+	if e != nil {
+		return "", errors.Convert(e)
+	}
+	defer os.RemoveAll(dir)
+	manifest := &RawBundleManifest{
+		Version:        RAW_BUNDLE_VERSION,
+		DevlakeVersion: version.Version,
+		Plugin:         query.Plugin,
+		ConnectionId:   query.ConnectionId,
+		Scope:          query.Scope,
+		Params:         query.Params,
+		ExportedAt:     time.Now(),
+	}
+	for _, table := range tables {
+		bundleTable, err := exportRawTable(query, table, filepath.Join(dir, table+".ndjson"))
+		if err != nil {
+			return "", err
+		}
+		if bundleTable != nil {
+			manifest.Tables = append(manifest.Tables, *bundleTable)
+		}
+	}
+	if len(manifest.Tables) == 0 {
+		return "", errors.NotFound.New("no raw data found for the given plugin and scope")
+	}
+	archive := filepath.Join(os.TempDir(), uuid.New().String(), fmt.Sprintf("%s-raw-bundle.tar.gz", query.Plugin))
+	if err = writeRawBundleArchive(archive, dir, manifest); err != nil {
+		return "", err
+	}
+	return archive, nil
+}
+
+// findPluginRawTables returns the raw tables of the plugin
+func findPluginRawTables(pluginName string) ([]string, errors.Error) {
+	allTables, err := db.AllTables()
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for _, table := range allTables {
+		if strings.HasPrefix(table, "_raw_") && rawTablePlugin(table) == pluginName {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+// rawTablePlugin returns the plugin owning the raw table by the longest matched plugin name, i.e.
+// `_raw_github_graphql_*` tables belong to `github_graphql` rather than `github`
+func rawTablePlugin(table string) string {
+	owner := ""
+	for name := range plugin.AllPlugins() {
+		if strings.HasPrefix(table, fmt.Sprintf("_raw_%s_", name)) && len(name) > len(owner) {
+			owner = name
+		}
+	}
+	return owner
+}
+
+// matchRawParams tells whether the raw params belong to the connection and scope of the query
+func matchRawParams(query *RawBundleQuery, params string) bool {
+	if query.Params != "" {
+		return params == query.Params
+	}
+	var values map[string]interface{}
+	if json.Unmarshal([]byte(params), &values) != nil {
+		return false
+	}
+	if fmt.Sprint(values["ConnectionId"]) != strconv.FormatUint(query.ConnectionId, 10) {
+		return false
+	}
+	if query.Scope == "" {
+		return true
+	}
+	for key, value := range values {
+		if key != "ConnectionId" && fmt.Sprint(value) == query.Scope {
+			return true
+		}
+	}
+	return false
+}
+
+func exportRawTable(query *RawBundleQuery, table string, path string) (*RawBundleTable, errors.Error) {
+	var allParams []string
+	err := db.Pluck("DISTINCT params", &allParams, dal.From(table))
+	if err != nil {
+		return nil, err
+	}
+	bundleTable := &RawBundleTable{Name: table}
+	for _, params := range allParams {
+		if matchRawParams(query, params) {
+			bundleTable.Params = append(bundleTable.Params, params)
+		}
+	}
+	if len(bundleTable.Params) == 0 {
+		return nil, nil
+	}
+	file, e := os.Create(path)
+	if e != nil {
+		return nil, errors.Convert(e)
+	}
+	defer file.Close()
+	cursor, err := db.Cursor(dal.From(table), dal.Where("params IN ?", bundleTable.Params), dal.Orderby("id"))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+	encoder := json.NewEncoder(file)
+	for cursor.Next() {
+		raw := &api.RawData{}
+		if err = db.Fetch(cursor, raw); err != nil {
+			return nil, err
+		}
+		data, err := api.DecompressRawData(raw.Data)
+		if err != nil {
+			return nil, err
+		}
+		row := &rawBundleRow{Params: raw.Params, Url: raw.Url, Input: raw.Input, CreatedAt: raw.CreatedAt}
+		if json.Valid(data) {
+			row.Data = data
+		} else {
+			row.DataBase64 = data
+		}
+		if e = encoder.Encode(row); e != nil {
+			return nil, errors.Convert(e)
+		}
+		bundleTable.Rows++
+	}
+	return bundleTable, nil
+}
+
+func writeRawBundleArchive(archive string, dir string, manifest *RawBundleManifest) errors.Error {
+	files := make([]string, len(manifest.Tables))
+	for i, table := range manifest.Tables {
+		files[i] = filepath.Join(dir, table.Name+".ndjson")
+	}
+	return writeManifestArchive(archive, rawBundleManifest, manifest, manifest.ExportedAt, files)
+}
+
+// writeManifestArchive writes the manifest followed by the files into a gzipped tar archive, so the
+// manifest could be read before the files when the archive is streamed
+func writeManifestArchive(archive string, manifestName string, manifest interface{}, modTime time.Time, files []string) errors.Error {
+	if err := os.MkdirAll(filepath.Dir(archive), 0o755); err != nil {
+		return errors.Convert(err)
+	}
+	file, err := os.Create(archive)
+	if err != nil {
+		return errors.Convert(err)
+	}
+	defer file.Close()
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Convert(err)
+	}
+	header := &tar.Header{Name: manifestName, Mode: 0o644, Size: int64(len(content)), ModTime: modTime}
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return errors.Convert(err)
+	}
+	if _, err = tarWriter.Write(content); err != nil {
+		return errors.Convert(err)
+	}
+	for _, path := range files {
+		if err := addFileToTar(tarWriter, path, modTime); err != nil {
+			return err
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		return errors.Convert(err)
+	}
+	return errors.Convert(gzipWriter.Close())
+}
+
+func addFileToTar(tarWriter *tar.Writer, path string, modTime time.Time) errors.Error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Convert(err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Convert(err)
+	}
+	header := &tar.Header{Name: filepath.Base(path), Mode: 0o644, Size: info.Size(), ModTime: modTime}
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return errors.Convert(err)
+	}
+	_, err = io.Copy(tarWriter, file)
+	return errors.Convert(err)
+}
+
+// ImportRawBundle imports a raw bundle created by ExportRawBundle, existing raw rows with the same params are
+// replaced. Tool and domain layers could then be regenerated by a pipeline with `skipCollectors`.
+func ImportRawBundle(reader io.Reader) (manifest *RawBundleManifest, err errors.Error) {
+	gzipReader, e := gzip.NewReader(reader)
+	if e != nil {
+		return nil, errors.BadInput.Wrap(e, "the bundle is not a gzipped tar archive")
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	header, e := tarReader.Next()
+	if e != nil || header.Name != rawBundleManifest {
+		return nil, errors.BadInput.New(fmt.Sprintf("the bundle must start with %s", rawBundleManifest))
+	}
+	manifest = &RawBundleManifest{}
+	if e = json.NewDecoder(tarReader).Decode(manifest); e != nil {
+		return nil, errors.BadInput.Wrap(e, fmt.Sprintf("failed to parse %s", rawBundleManifest))
+	}
+	if manifest.Version != RAW_BUNDLE_VERSION {
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported raw bundle version %d", manifest.Version))
+	}
+	tables := make(map[string]*RawBundleTable)
+	for i := range manifest.Tables {
+		table := &manifest.Tables[i]
+		if !rawTableNamePattern.MatchString(table.Name) {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid raw table name %s", table.Name))
+		}
+		if manifest.Plugin == "" || rawTablePlugin(table.Name) != manifest.Plugin {
+			return nil, errors.BadInput.New(fmt.Sprintf("raw table %s does not belong to plugin %s", table.Name, manifest.Plugin))
+		}
+		tables[table.Name+".ndjson"] = table
+	}
+
+	txHelper := dbhelper.NewTxHelper(basicRes, &err)
+	defer txHelper.End()
+	tx := txHelper.Begin()
+	for {
+		header, e = tarReader.Next()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return nil, errors.BadInput.Wrap(e, "failed to read the bundle")
+		}
+		table, ok := tables[header.Name]
+		if !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unexpected file %s in the bundle", header.Name))
+		}
+		rows, err := importRawTable(tx, table, tarReader)
+		if err != nil {
+			return nil, err
+		}
+		if rows != table.Rows {
+			return nil, errors.BadInput.New(fmt.Sprintf("%s has %d rows while %d are declared", table.Name, rows, table.Rows))
+		}
+		delete(tables, header.Name)
+	}
+	for name := range tables {
+		return nil, errors.BadInput.New(fmt.Sprintf("%s is missing in the bundle", name))
+	}
+	return manifest, nil
+}
+
+func importRawTable(tx dal.Transaction, table *RawBundleTable, reader io.Reader) (int64, errors.Error) {
+	err := tx.AutoMigrate(&api.RawData{}, dal.From(table.Name))
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Delete(&api.RawData{}, dal.From(table.Name), dal.Where("params IN ?", table.Params))
+	if err != nil {
+		return 0, err
+	}
+	params := make(map[string]bool, len(table.Params))
+	for _, p := range table.Params {
+		params[p] = true
+	}
+	var count int64
+	batch := make([]*api.RawData, 0, 100)
+	flush := func() errors.Error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := tx.Create(&batch, dal.From(table.Name))
+		batch = batch[:0]
+		return err
+	}
+	decoder := json.NewDecoder(reader)
+	for {
+		row := &rawBundleRow{}
+		e := decoder.Decode(row)
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return 0, errors.BadInput.Wrap(e, fmt.Sprintf("failed to parse rows of %s", table.Name))
+		}
+		if !params[row.Params] {
+			return 0, errors.BadInput.New(fmt.Sprintf("params %s of %s are not declared in the manifest", row.Params, table.Name))
+		}
+		raw := &api.RawData{Params: row.Params, Data: row.DataBase64, Url: row.Url, CreatedAt: row.CreatedAt}
+		if row.Data != nil {
+			raw.Data = row.Data
+		}
+		if len(row.Input) > 0 && string(row.Input) != "null" {
+			raw.Input = row.Input
+		}
+		batch = append(batch, raw)
+		count++
+		if len(batch) == cap(batch) {
+			if err = flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return count, flush()
+}
@@ -0,0 +1,88 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchRawParams(t *testing.T) {
+	params := `{"ConnectionId":1,"Name":"apache/incubator-devlake"}`
+	assert.True(t, matchRawParams(&RawBundleQuery{ConnectionId: 1}, params))
+	assert.True(t, matchRawParams(&RawBundleQuery{ConnectionId: 1, Scope: "apache/incubator-devlake"}, params))
+	assert.False(t, matchRawParams(&RawBundleQuery{ConnectionId: 1, Scope: "apache/devlake-website"}, params))
+	assert.False(t, matchRawParams(&RawBundleQuery{ConnectionId: 2}, params))
+	assert.False(t, matchRawParams(&RawBundleQuery{ConnectionId: 1, Scope: "1"}, `{"ConnectionId":1}`))
+	assert.True(t, matchRawParams(&RawBundleQuery{ConnectionId: 1, Scope: "8"}, `{"ConnectionId":1,"BoardId":8}`))
+	assert.True(t, matchRawParams(&RawBundleQuery{ConnectionId: 2, Params: params}, params))
+	assert.False(t, matchRawParams(&RawBundleQuery{ConnectionId: 1}, "not json"))
+}
+
+func TestImportRawBundleManifest(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	assert.Nil(t, writeRawBundleArchive(archive, "", &RawBundleManifest{Version: RAW_BUNDLE_VERSION + 1}))
+	file, err := os.Open(archive)
+	assert.Nil(t, err)
+	defer file.Close()
+	_, e := ImportRawBundle(file)
+	assert.ErrorContains(t, e, "unsupported raw bundle version")
+
+	_, e = ImportRawBundle(bytes.NewReader(rawBundleOf(t, "tables.ndjson", nil)))
+	assert.ErrorContains(t, e, "must start with manifest.json")
+
+	manifest := &RawBundleManifest{
+		Version: RAW_BUNDLE_VERSION,
+		Tables:  []RawBundleTable{{Name: "_raw_github_api_repos; DROP TABLE users"}},
+	}
+	_, e = ImportRawBundle(bytes.NewReader(rawBundleOf(t, rawBundleManifest, manifest)))
+	assert.ErrorContains(t, e, "invalid raw table name")
+
+	registerTestPlugin(t, "bundletest")
+	registerTestPlugin(t, "bundletest_graphql")
+	for _, name := range []string{"_raw_devlake_migration_history", "_raw_bundletest_graphql_issues"} {
+		manifest = &RawBundleManifest{
+			Version: RAW_BUNDLE_VERSION,
+			Plugin:  "bundletest",
+			Tables:  []RawBundleTable{{Name: name}},
+		}
+		_, e = ImportRawBundle(bytes.NewReader(rawBundleOf(t, rawBundleManifest, manifest)))
+		assert.ErrorContains(t, e, "does not belong to plugin bundletest")
+	}
+}
+
+func rawBundleOf(t *testing.T, name string, content interface{}) []byte {
+	data, err := json.Marshal(content)
+	assert.Nil(t, err)
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	assert.Nil(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}))
+	_, err = tarWriter.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, tarWriter.Close())
+	assert.Nil(t, gzipWriter.Close())
+	return buf.Bytes()
+}
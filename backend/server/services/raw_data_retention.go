@@ -0,0 +1,272 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/robfig/cron/v3"
+)
+
+const defaultRawDataRetentionCron = "0 3 * * *"
+
+var rawDataRetentionLog = logruslog.Global.Nested("raw data retention")
+
+// RawDataRetentionRule keeps raw rows collected in the last `Days` days or by the last `Syncs` syncs,
+// rows collected by the latest full sync are always kept so the tool and domain layers could be regenerated
+type RawDataRetentionRule struct {
+	Days  int
+	Syncs int
+}
+
+// RawDataRetentionPolicy holds retention rules by raw table name, plugin name or `*` for all raw tables,
+// the most specific rule applies
+type RawDataRetentionPolicy map[string]*RawDataRetentionRule
+
+// ParseRawDataRetentionPolicy parses the policy from comma separated rules like
+// `*=90d,github=3syncs,_raw_jira_api_issues=30d`, nil is returned if the text is empty
+func ParseRawDataRetentionPolicy(text string) (RawDataRetentionPolicy, errors.Error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	policy := make(RawDataRetentionPolicy)
+	for _, item := range strings.Split(text, ",") {
+		target, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		target = strings.TrimSpace(target)
+		value = strings.TrimSpace(value)
+		if !ok || target == "" {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid raw data retention rule %s, expecting <target>=<N>d or <target>=<N>syncs", item))
+		}
+		rule := &RawDataRetentionRule{}
+		var number string
+		var n *int
+		if strings.HasSuffix(value, "syncs") {
+			number, n = strings.TrimSuffix(value, "syncs"), &rule.Syncs
+		} else if strings.HasSuffix(value, "d") {
+			number, n = strings.TrimSuffix(value, "d"), &rule.Days
+		} else {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid raw data retention %s of %s, expecting <N>d or <N>syncs", value, target))
+		}
+		var err error
+		*n, err = strconv.Atoi(number)
+		if err != nil || *n <= 0 {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid raw data retention %s of %s, expecting a positive number", value, target))
+		}
+		policy[target] = rule
+	}
+	return policy, nil
+}
+
+// RuleOf returns the rule applies to the raw table, or nil if the table should be kept forever
+func (policy RawDataRetentionPolicy) RuleOf(table string, pluginName string) *RawDataRetentionRule {
+	for _, target := range []string{table, pluginName, "*"} {
+		if rule, ok := policy[target]; ok && target != "" {
+			return rule
+		}
+	}
+	return nil
+}
+
+// RawDataRetentionReport tells how many raw rows were deleted from each raw table
+type RawDataRetentionReport map[string]int64
+
+// EnforceRawDataRetention deletes the raw rows out of the retention of the policy
+func EnforceRawDataRetention(policy RawDataRetentionPolicy) (RawDataRetentionReport, errors.Error) {
+	allTables, err := db.AllTables()
+	if err != nil {
+		return nil, err
+	}
+	report := make(RawDataRetentionReport)
+	now := time.Now()
+	for _, table := range allTables {
+		if !strings.HasPrefix(table, "_raw_") {
+			continue
+		}
+		rule := policy.RuleOf(table, rawTablePlugin(table))
+		if rule == nil {
+			continue
+		}
+		deleted, err := enforceRawTableRetention(table, rule, now)
+		if err != nil {
+			return report, errors.Default.Wrap(err, fmt.Sprintf("failed to enforce retention of %s", table))
+		}
+		if deleted > 0 {
+			report[table] = deleted
+		}
+	}
+	return report, nil
+}
+
+func enforceRawTableRetention(table string, rule *RawDataRetentionRule, now time.Time) (int64, errors.Error) {
+	var allParams []string
+	err := db.Pluck("DISTINCT params", &allParams, dal.From(table))
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, params := range allParams {
+		cutoff, fullSync, err := rawDataRetentionCutoff(table, params, rule, now)
+		if err != nil {
+			return deleted, err
+		}
+		if cutoff == nil {
+			continue
+		}
+		// rows of the latest full sync are the base of the full extraction, they are kept until the next full sync
+		rowsClause := dal.Where("params = ? AND created_at < ?", params, *cutoff)
+		syncsClause := dal.Where("raw_data_table = ? AND raw_data_params = ? AND started_at < ?", table, params, *cutoff)
+		if fullSync != nil {
+			rowsClause = dal.Where(
+				"params = ? AND created_at < ? AND (created_at < ? OR created_at >= ?)",
+				params, *cutoff, fullSync.From, fullSync.To,
+			)
+			syncsClause = dal.Where(
+				"raw_data_table = ? AND raw_data_params = ? AND started_at < ? AND id <> ?",
+				table, params, *cutoff, fullSync.Id,
+			)
+		}
+		count, err := db.Count(dal.From(table), rowsClause)
+		if err != nil {
+			return deleted, err
+		}
+		if count == 0 {
+			continue
+		}
+		err = db.Delete(&api.RawData{}, dal.From(table), rowsClause)
+		if err != nil {
+			return deleted, err
+		}
+		err = db.Delete(&models.RawDataSync{}, syncsClause)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// rawDataFullSync is the latest full sync of a raw table and params, whose rows were collected between From and To
+type rawDataFullSync struct {
+	Id   uint64
+	From time.Time
+	To   time.Time
+}
+
+// rawDataRetentionCutoff returns the time before which the raw rows of the params should be deleted,
+// nil is returned when nothing should be deleted, i.e. no full sync was recorded since the raw rows were collected
+// by an earlier version of devlake. The collectors delete all rows of the params on full syncs, so the rows of the
+// latest full sync are returned as well if the cutoff goes past them, they must be kept for the full extraction
+// while the rows of the incremental syncs out of the rule could go.
+func rawDataRetentionCutoff(table string, params string, rule *RawDataRetentionRule, now time.Time) (*time.Time, *rawDataFullSync, errors.Error) {
+	limit := 1
+	if rule.Syncs > 0 {
+		limit = rule.Syncs
+	}
+	var syncs []models.RawDataSync
+	err := db.All(
+		&syncs,
+		dal.Where("raw_data_table = ? AND raw_data_params = ?", table, params),
+		dal.Orderby("started_at DESC"),
+		dal.Limit(limit),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(syncs) < limit {
+		return nil, nil, nil
+	}
+	fullSync := &models.RawDataSync{}
+	err = db.First(
+		fullSync,
+		dal.Where("raw_data_table = ? AND raw_data_params = ? AND incremental = ?", table, params, false),
+		dal.Orderby("started_at DESC"),
+	)
+	if db.IsErrorNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var cutoff time.Time
+	if rule.Syncs > 0 {
+		cutoff = syncs[limit-1].StartedAt
+	} else {
+		cutoff = now.AddDate(0, 0, -rule.Days)
+		if syncs[0].StartedAt.Before(cutoff) {
+			cutoff = syncs[0].StartedAt
+		}
+	}
+	if !fullSync.StartedAt.Before(cutoff) {
+		return &cutoff, nil, nil
+	}
+	// the full sync is followed by the sync the cutoff keeps at least
+	nextSync := &models.RawDataSync{}
+	err = db.First(
+		nextSync,
+		dal.Where("raw_data_table = ? AND raw_data_params = ? AND started_at > ?", table, params, fullSync.StartedAt),
+		dal.Orderby("started_at ASC"),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &cutoff, &rawDataFullSync{Id: fullSync.ID, From: fullSync.StartedAt, To: nextSync.StartedAt}, nil
+}
+
+// rawDataRetentionInit schedules the raw data retention job if RAW_DATA_RETENTION is set
+func rawDataRetentionInit() {
+	policy, err := ParseRawDataRetentionPolicy(cfg.GetString("RAW_DATA_RETENTION"))
+	if err != nil {
+		panic(err)
+	}
+	if policy == nil {
+		return
+	}
+	spec := cfg.GetString("RAW_DATA_RETENTION_CRON")
+	if spec == "" {
+		spec = defaultRawDataRetentionCron
+	}
+	// the blueprint cronManager gets reset on reloading, so the job runs with its own scheduler
+	scheduler := cron.New(cron.WithLocation(time.UTC))
+	_, e := scheduler.AddFunc(spec, func() {
+		// every instance schedules the job while only the leader enforces the policy
+		if !isLeader() {
+			return
+		}
+		report, err := EnforceRawDataRetention(policy)
+		if err != nil {
+			rawDataRetentionLog.Error(err, "failed to enforce raw data retention")
+		}
+		for table, deleted := range report {
+			rawDataRetentionLog.Info("deleted %d rows from %s", deleted, table)
+		}
+	})
+	if e != nil {
+		panic(errors.BadInput.Wrap(e, "invalid RAW_DATA_RETENTION_CRON"))
+	}
+	scheduler.Start()
+	rawDataRetentionLog.Info("raw data retention scheduled at %s", spec)
+}
@@ -0,0 +1,133 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRawDataRetentionPolicy(t *testing.T) {
+	policy, err := ParseRawDataRetentionPolicy("")
+	assert.Nil(t, err)
+	assert.Nil(t, policy)
+
+	policy, err = ParseRawDataRetentionPolicy(" *=90d, github=3syncs,_raw_github_api_issues=30d ")
+	assert.Nil(t, err)
+	assert.Equal(t, &RawDataRetentionRule{Days: 30}, policy.RuleOf("_raw_github_api_issues", "github"))
+	assert.Equal(t, &RawDataRetentionRule{Syncs: 3}, policy.RuleOf("_raw_github_api_pulls", "github"))
+	assert.Equal(t, &RawDataRetentionRule{Days: 90}, policy.RuleOf("_raw_jira_api_issues", "jira"))
+	assert.Equal(t, &RawDataRetentionRule{Days: 90}, policy.RuleOf("_raw_unknown", ""))
+
+	policy, err = ParseRawDataRetentionPolicy("jira=7d")
+	assert.Nil(t, err)
+	assert.Nil(t, policy.RuleOf("_raw_github_api_issues", "github"))
+
+	for _, text := range []string{"github", "github=3", "github=0d", "github=-1syncs", "=3d", "github=xd"} {
+		_, err = ParseRawDataRetentionPolicy(text)
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestEnforceRawDataRetentionThenExtract(t *testing.T) {
+	setupTestDb(t)
+	table := "_raw_retentiontest_api_items"
+	require.Nil(t, db.AutoMigrate(&api.RawData{}, dal.From(table)))
+	rawParams := struct{ ConnectionId uint64 }{ConnectionId: 1}
+	params := plugin.MarshalScopeParams(rawParams)
+	base := time.Now().Add(-10 * 24 * time.Hour)
+	// a sync collects the rows the way the collectors do, a full sync deletes the rows collected earlier
+	sync := func(day int, incremental bool, urls ...string) {
+		startedAt := base.Add(time.Duration(day) * 24 * time.Hour)
+		if !incremental {
+			require.Nil(t, db.Delete(&api.RawData{}, dal.From(table), dal.Where("params = ?", params)))
+		}
+		require.Nil(t, db.Create(&models.RawDataSync{
+			RawDataTable:  table,
+			RawDataParams: params,
+			StartedAt:     startedAt,
+			Incremental:   incremental,
+		}))
+		for _, url := range urls {
+			require.Nil(t, db.Create(&api.RawData{
+				Params:    params,
+				Data:      []byte(`{}`),
+				Url:       url,
+				CreatedAt: startedAt.Add(time.Minute),
+			}, dal.From(table)))
+		}
+	}
+	extract := func() []string {
+		ctx := contextimpl.NewStandaloneSubTaskContext(context.Background(), basicRes, "extractItems", nil, "retentiontest", nil)
+		var urls []string
+		extractor, err := api.NewApiExtractor(api.ApiExtractorArgs{
+			RawDataSubTaskArgs: api.RawDataSubTaskArgs{Ctx: ctx, Table: "retentiontest_api_items", Params: rawParams},
+			Extract: func(row *api.RawData) ([]interface{}, errors.Error) {
+				urls = append(urls, row.Url)
+				return nil, nil
+			},
+		})
+		require.Nil(t, err)
+		require.Nil(t, extractor.Execute())
+		return urls
+	}
+	policy, err := ParseRawDataRetentionPolicy("*=1syncs")
+	require.Nil(t, err)
+
+	countSyncs := func() int64 {
+		count, err := db.Count(dal.From(&models.RawDataSync{}))
+		require.Nil(t, err)
+		return count
+	}
+
+	// rows of the incremental syncs out of the rule go, while the rows of the full sync are kept for the extraction
+	sync(0, false, "item1", "item2")
+	sync(1, true, "item3")
+	sync(2, true, "item4")
+	report, err := EnforceRawDataRetention(policy)
+	require.Nil(t, err)
+	assert.Equal(t, RawDataRetentionReport{table: 1}, report)
+	assert.Equal(t, []string{"item1", "item2", "item4"}, extract())
+	assert.Equal(t, int64(2), countSyncs())
+
+	// nothing to delete right after a full sync since the collector deleted the earlier rows
+	sync(3, false, "item5")
+	report, err = EnforceRawDataRetention(policy)
+	require.Nil(t, err)
+	assert.Empty(t, report)
+	assert.Equal(t, []string{"item5"}, extract())
+
+	// the syncs recorded before the latest full sync go along with the next incremental rows
+	sync(4, true, "item6")
+	sync(5, true, "item7")
+	report, err = EnforceRawDataRetention(policy)
+	require.Nil(t, err)
+	assert.Equal(t, RawDataRetentionReport{table: 1}, report)
+	assert.Equal(t, []string{"item5", "item7"}, extract())
+	assert.Equal(t, int64(2), countSyncs())
+}
@@ -0,0 +1,104 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/migration"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/services"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDb points the services module to a fresh sqlite database migrated by the framework scripts
+func setupTestDb(t *testing.T) {
+	c := config.GetConfig()
+	c.Set("DB_URL", fmt.Sprintf("sqlite://%s", filepath.Join(t.TempDir(), "lake.db")))
+	if c.GetString(plugin.EncodeKeyEnvStr) == "" {
+		c.Set(plugin.EncodeKeyEnvStr, "testsecret")
+	}
+	dalgorm.Init(c.GetString(plugin.EncodeKeyEnvStr))
+	gormDb, err := runner.NewGormDb(c, logruslog.Global)
+	require.Nil(t, err)
+	sqlDb, e := gormDb.DB()
+	require.Nil(t, e)
+	t.Cleanup(func() {
+		_ = sqlDb.Close()
+	})
+	basicRes = runner.CreateBasicRes(c, logruslog.Global, gormDb)
+	cfg = basicRes.GetConfigReader()
+	logger = basicRes.GetLogger()
+	db = basicRes.GetDal()
+	bpManager = services.NewBlueprintManager(db)
+	vld = validator.New()
+	m, err := migration.NewMigrator(basicRes)
+	require.Nil(t, err)
+	m.Register(migrationscripts.All(), "Framework")
+	if err := m.Execute(); err != nil {
+		t.Fatal(err.Messages().Format())
+	}
+}
+
+// testPlugin runs the given entry points as its subtasks
+type testPlugin struct {
+	name        string
+	entryPoints []plugin.SubTaskEntryPoint
+}
+
+func (p *testPlugin) Description() string {
+	return "plugin for testing"
+}
+
+func (p *testPlugin) RootPkgPath() string {
+	return "github.com/apache/incubator-devlake/server/services"
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) SubTaskMetas() []plugin.SubTaskMeta {
+	metas := make([]plugin.SubTaskMeta, len(p.entryPoints))
+	for i, entryPoint := range p.entryPoints {
+		metas[i] = plugin.SubTaskMeta{
+			Name:             fmt.Sprintf("subtask%d", i+1),
+			EntryPoint:       entryPoint,
+			EnabledByDefault: true,
+		}
+	}
+	return metas
+}
+
+func (p *testPlugin) PrepareTaskData(_ plugin.TaskContext, _ map[string]interface{}) (interface{}, errors.Error) {
+	return nil, nil
+}
+
+// registerTestPlugin registers a plugin running the given entry points for the tests
+func registerTestPlugin(t *testing.T, name string, entryPoints ...plugin.SubTaskEntryPoint) {
+	require.Nil(t, plugin.RegisterPlugin(name, &testPlugin{name: name, entryPoints: entryPoints}))
+}
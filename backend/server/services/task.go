@@ -46,6 +46,7 @@ func createTask(newTask *models.NewTask, tx dal.Transaction) (*models.Task, erro
 		Plugin:      newTask.Plugin,
 		Subtasks:    newTask.Subtasks,
 		Options:     newTask.Options,
+		Timeout:     newTask.Timeout,
 		Status:      models.TASK_CREATED,
 		Message:     "",
 		PipelineId:  newTask.PipelineId,
@@ -166,16 +167,25 @@ func GetTask(taskId uint64) (*models.Task, errors.Error) {
 
 // CancelTask FIXME ...
 func CancelTask(taskId uint64) errors.Error {
+	return cancelTask(taskId, context.Canceled)
+}
+
+// cancelTask cancels the context of the running task with the cause
+func cancelTask(taskId uint64, cause error) errors.Error {
 	cancel, err := runningTasks.Remove(taskId)
 	if err != nil {
+		if clusterMode {
+			// the task might be queued or running on another worker
+			return cancelTaskInCluster(taskId, cause)
+		}
 		return err
 	}
-	cancel()
+	cancel(cause)
 	return nil
 }
 
 // RunTasksStandalone run tasks in parallel
-func RunTasksStandalone(parentLogger log.Logger, taskIds []uint64) errors.Error {
+func RunTasksStandalone(ctx context.Context, parentLogger log.Logger, taskIds []uint64) errors.Error {
 	if len(taskIds) == 0 {
 		return nil
 	}
@@ -184,7 +194,7 @@ func RunTasksStandalone(parentLogger log.Logger, taskIds []uint64) errors.Error
 		go func(id uint64) {
 			taskLog.Info("run task #%d in background ", id)
 			var err errors.Error
-			taskErr := runTaskStandalone(parentLogger, id)
+			taskErr := runTaskStandalone(ctx, parentLogger, id)
 			if taskErr != nil {
 				err = errors.Default.Wrap(taskErr, fmt.Sprintf("Error running task %d.", id))
 			}
@@ -301,6 +311,7 @@ func GetSubTasksInfo(pipelineId uint64, shouldSanitize bool, tx dal.Dal) (*model
 				IsCollector:     subtask.IsCollector,
 				IsFailed:        subtask.IsFailed,
 				Message:         subtask.Message,
+				Attempts:        subtask.Attempts,
 			}
 			subTaskResult.SubtaskDetails = append(subTaskResult.SubtaskDetails, t)
 		}
@@ -366,7 +377,7 @@ func getTaskStatus(statuses []string) string {
 	failedCount := 0
 	completedCount := 0
 	for _, s := range statuses {
-		if s == models.TASK_FAILED {
+		if s == models.TASK_FAILED || s == models.TASK_TIMEOUT {
 			failedCount++
 		} else if s == models.TASK_COMPLETED {
 			completedCount++
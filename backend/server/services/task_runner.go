@@ -20,6 +20,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/log"
 	"github.com/apache/incubator-devlake/core/models"
@@ -30,7 +31,7 @@ import (
 
 // RunningTaskData FIXME ...
 type RunningTaskData struct {
-	Cancel         context.CancelFunc
+	Cancel         context.CancelCauseFunc
 	ProgressDetail *models.TaskProgressDetail
 }
 
@@ -41,7 +42,7 @@ type RunningTask struct {
 }
 
 // Add FIXME ...
-func (rt *RunningTask) Add(taskId uint64, cancel context.CancelFunc) errors.Error {
+func (rt *RunningTask) Add(taskId uint64, cancel context.CancelCauseFunc) errors.Error {
 	rt.mu.Lock()
 	defer rt.mu.Unlock()
 	if _, ok := rt.tasks[taskId]; ok {
@@ -79,7 +80,7 @@ func (rt *RunningTask) GetProgressDetail(taskId uint64) *models.TaskProgressDeta
 }
 
 // Remove FIXME ...
-func (rt *RunningTask) Remove(taskId uint64) (context.CancelFunc, errors.Error) {
+func (rt *RunningTask) Remove(taskId uint64) (context.CancelCauseFunc, errors.Error) {
 	rt.mu.Lock()
 	defer rt.mu.Unlock()
 	if d, ok := rt.tasks[taskId]; ok {
@@ -96,21 +97,28 @@ func init() {
 	runningTasks.tasks = make(map[uint64]*RunningTaskData)
 }
 
-func runTaskStandalone(parentLog log.Logger, taskId uint64) errors.Error {
+func runTaskStandalone(parentCtx context.Context, parentLog log.Logger, taskId uint64) errors.Error {
 	// deferring cleaning up
 	defer func() {
 		_, _ = runningTasks.Remove(taskId)
 	}()
-	// for task cancelling
-	ctx, cancel := context.WithCancel(context.Background())
+	// for task cancelling, the cause tells whether it was cancelled by user or timed out
+	ctx, cancel := context.WithCancelCause(parentCtx)
 	err := runningTasks.Add(taskId, cancel)
 	if err != nil {
 		return err
 	}
+	task := &models.Task{}
+	err = db.First(task, dal.Where("id = ?", taskId))
+	if err != nil {
+		return err
+	}
+	publisher := &taskEventPublisher{pipelineId: task.PipelineId, taskId: taskId}
+	publisher.publishStatus(models.TASK_RUNNING, "")
 	// now , create a progress update channel and kick off
 	progress := make(chan plugin.RunningProgress, 100)
 	doneSignal := make(chan struct{})
-	go updateTaskProgress(doneSignal, taskId, progress)
+	go updateTaskProgress(doneSignal, taskId, progress, publisher)
 	err = runner.RunTask(
 		ctx,
 		basicRes.ReplaceLogger(parentLog),
@@ -120,6 +128,14 @@ func runTaskStandalone(parentLog log.Logger, taskId uint64) errors.Error {
 	close(progress)
 	// wait all progresses are handled
 	<-doneSignal
+	if e := db.First(task, dal.Where("id = ?", taskId)); e != nil {
+		parentLog.Error(e, "failed to load task #%d", taskId)
+	} else {
+		publisher.publishStatus(task.Status, task.Message)
+	}
+	if e := NotifyTaskFailed(taskId); e != nil {
+		parentLog.Error(e, "failed to send task failed notification for task #%d", taskId)
+	}
 	return err
 }
 
@@ -130,7 +146,7 @@ func getRunningTaskById(taskId uint64) *RunningTaskData {
 	return runningTasks.tasks[taskId]
 }
 
-func updateTaskProgress(done chan struct{}, taskId uint64, progress chan plugin.RunningProgress) {
+func updateTaskProgress(done chan struct{}, taskId uint64, progress chan plugin.RunningProgress, publisher *taskEventPublisher) {
 	data := getRunningTaskById(taskId)
 	if data == nil {
 		return
@@ -141,8 +157,14 @@ func updateTaskProgress(done chan struct{}, taskId uint64, progress chan plugin.
 		if hasMore {
 			runningTasks.mu.Lock()
 			runner.UpdateProgressDetail(basicRes, taskId, progressDetail, &p)
+			detail := *progressDetail
 			runningTasks.mu.Unlock()
+			publisher.publishProgress(&p, detail)
 		} else {
+			runningTasks.mu.Lock()
+			detail := *progressDetail
+			runningTasks.mu.Unlock()
+			publisher.flushProgress(detail)
 			done <- struct{}{}
 			break
 		}
@@ -0,0 +1,305 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/version"
+	"github.com/google/uuid"
+	"golang.org/x/sync/semaphore"
+)
+
+// clusterMode allows multiple devlake instances to share the same database, check models.Worker for the detail
+var clusterMode bool
+var workerId string
+var leaseDuration = 60 * time.Second
+
+// claimableTaskStatus are the statuses of tasks that could be claimed by a worker, a TASK_RUNNING task
+// is only claimable when its lease expired which means the worker running it is dead
+var claimableTaskStatus = []string{models.TASK_CREATED, models.TASK_RERUN, models.TASK_RESUME, models.TASK_RUNNING}
+
+func workerServiceInit() {
+	clusterMode = cfg.GetBool("CLUSTER_MODE")
+	if !clusterMode {
+		return
+	}
+	if seconds := cfg.GetInt("WORKER_LEASE_SECONDS"); seconds > 0 {
+		leaseDuration = time.Duration(seconds) * time.Second
+	}
+	hostName := errors.Must1(os.Hostname())
+	workerId = fmt.Sprintf("%s-%d-%s", hostName, os.Getpid(), uuid.New().String()[:8])
+	now := time.Now()
+	errors.Must(db.Create(&models.Worker{
+		ID:          workerId,
+		HostName:    hostName,
+		Version:     version.Version,
+		StartedAt:   now,
+		HeartbeatAt: now,
+	}))
+	globalPipelineLog.Info("running in cluster mode as worker %s", workerId)
+	go heartbeatInLoop()
+	maxTasks := cfg.GetInt64("WORKER_MAX_TASKS")
+	if maxTasks <= 0 {
+		maxTasks = 10
+	}
+	go consumeTasksInLoop(maxTasks, nil)
+}
+
+func heartbeatInLoop() {
+	for {
+		time.Sleep(leaseDuration / 3)
+		err := db.UpdateColumn(&models.Worker{}, "heartbeat_at", time.Now(), dal.Where("id = ?", workerId))
+		if err != nil {
+			globalPipelineLog.Error(err, "worker %s failed to send heartbeat", workerId)
+		}
+	}
+}
+
+// leaseHolder is implemented by the models embedding models.Lease
+type leaseHolder interface {
+	GetLease() *models.Lease
+}
+
+// claimLease takes the lease of the row for the current worker if nobody else is holding it,
+// the conditional update is atomic so only one worker could win
+func claimLease(entity leaseHolder, id uint64, clauses ...dal.Clause) (bool, errors.Error) {
+	now := time.Now()
+	expiresAt := now.Add(leaseDuration)
+	clauses = append(clauses,
+		dal.Where("id = ?", id),
+		dal.Where("(worker_id IS NULL OR worker_id = '' OR worker_id = ? OR lease_expires_at IS NULL OR lease_expires_at < ?)", workerId, now),
+	)
+	err := db.UpdateColumns(entity, []dal.DalSet{
+		{ColumnName: "worker_id", Value: workerId},
+		{ColumnName: "lease_expires_at", Value: expiresAt},
+	}, clauses...)
+	if err != nil {
+		return false, err
+	}
+	err = db.First(entity, dal.Where("id = ?", id))
+	if err != nil {
+		return false, err
+	}
+	return entity.GetLease().WorkerId == workerId, nil
+}
+
+// renewLeaseInLoop keeps the lease alive until `done` is closed, `onLost` is called once the row is not
+// held by the current worker anymore
+func renewLeaseInLoop(entity interface{}, id uint64, done chan struct{}, onRenewed func() bool) {
+	ticker := time.NewTicker(leaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			err := db.UpdateColumn(
+				entity, "lease_expires_at", time.Now().Add(leaseDuration),
+				dal.Where("id = ? AND worker_id = ?", id, workerId),
+			)
+			if err != nil {
+				globalPipelineLog.Error(err, "worker %s failed to renew lease of %T #%d", workerId, entity, id)
+				continue
+			}
+			if onRenewed != nil && !onRenewed() {
+				return
+			}
+		}
+	}
+}
+
+// releaseLease keeps the worker id for the record but lets the lease expire immediately
+func releaseLease(entity interface{}, id uint64) {
+	err := db.UpdateColumn(entity, "lease_expires_at", nil, dal.Where("id = ? AND worker_id = ?", id, workerId))
+	if err != nil {
+		globalPipelineLog.Error(err, "worker %s failed to release lease of %T #%d", workerId, entity, id)
+	}
+}
+
+// consumeTasksInLoop claims tasks queued by pipeline coordinators on any worker and runs them locally until
+// `stop` is closed
+func consumeTasksInLoop(maxTasks int64, stop chan struct{}) {
+	sema := semaphore.NewWeighted(maxTasks)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		errors.Must(sema.Acquire(context.TODO(), 1))
+		task, err := claimQueuedTask()
+		if err != nil {
+			globalPipelineLog.Error(err, "worker %s failed to claim task", workerId)
+		}
+		if task == nil {
+			sema.Release(1)
+			time.Sleep(time.Second)
+			continue
+		}
+		go func(task *models.Task) {
+			defer sema.Release(1)
+			runClaimedTask(task)
+		}(task)
+	}
+}
+
+func claimQueuedTask() (*models.Task, errors.Error) {
+	candidates := make([]*models.Task, 0)
+	now := time.Now()
+	err := db.All(
+		&candidates,
+		dal.Where("queued_at IS NOT NULL AND status IN ?", claimableTaskStatus),
+		dal.Where("(worker_id IS NULL OR worker_id = '' OR lease_expires_at IS NULL OR lease_expires_at < ?)", now),
+		dal.Orderby("queued_at ASC"),
+		dal.Limit(10),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		claimed, err := claimLease(&models.Task{}, candidate.ID, dal.Where("status IN ?", claimableTaskStatus))
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+func runClaimedTask(task *models.Task) {
+	defer releaseLease(&models.Task{}, task.ID)
+	pipeline, err := GetDbPipeline(task.PipelineId)
+	if err != nil {
+		globalPipelineLog.Error(err, "worker %s failed to load pipeline of task #%d", workerId, task.ID)
+		return
+	}
+	logger := GetPipelineLogger(pipeline).Nested(fmt.Sprintf("worker %s", workerId))
+	if task.Status == models.TASK_RUNNING {
+		logger.Info("task #%d was abandoned by worker %s, resuming", task.ID, task.WorkerId)
+	}
+	done := make(chan struct{})
+	var stopped atomic.Pointer[models.Task]
+	go renewLeaseInLoop(&models.Task{}, task.ID, done, func() bool {
+		// tasks cancelled or timed out on other instances are marked in the database
+		current, err := GetTask(task.ID)
+		if err != nil {
+			return true
+		}
+		switch current.Status {
+		case models.TASK_CANCELLED:
+			stopped.Store(current)
+			_ = CancelTask(task.ID)
+			return false
+		case models.TASK_TIMEOUT:
+			stopped.Store(current)
+			_ = cancelTask(task.ID, errors.Timeout.New(fmt.Sprintf("task #%d timed out", task.ID)))
+			return false
+		}
+		return true
+	})
+	// spans of the tasks run by workers are reported as roots since the pipeline span lives on another instance
+	err = runTaskStandalone(context.Background(), logger, task.ID)
+	close(done)
+	if err != nil {
+		logger.Error(err, "task #%d failed on worker %s", task.ID, workerId)
+	}
+	if current := stopped.Load(); current != nil {
+		// the task would be marked as failed by the runner, restore what the coordinator recorded
+		sets := []dal.DalSet{{ColumnName: "status", Value: current.Status}}
+		if current.Message != "" {
+			sets = append(sets, dal.DalSet{ColumnName: "message", Value: current.Message})
+		}
+		if err = db.UpdateColumns(&models.Task{}, sets, dal.Where("id = ?", task.ID)); err != nil {
+			logger.Error(err, "failed to mark task #%d as %s", task.ID, current.Status)
+		}
+	}
+}
+
+// RunTasksDistributed queues the tasks for workers to claim, then waits for all of them to be finished
+func RunTasksDistributed(parentLogger log.Logger, taskIds []uint64) errors.Error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	err := db.UpdateColumn(&models.Task{}, "queued_at", time.Now(), dal.Where("id IN ?", taskIds))
+	if err != nil {
+		return err
+	}
+	var skipOnFail bool
+	for {
+		time.Sleep(time.Second)
+		tasks := make([]*models.Task, 0, len(taskIds))
+		err = db.All(&tasks, dal.Where("id IN ?", taskIds))
+		if err != nil {
+			return err
+		}
+		finished := 0
+		var taskErr errors.Error
+		for _, task := range tasks {
+			switch task.Status {
+			case models.TASK_CANCELLED:
+				parentLogger.Info("task #%d canceled", task.ID)
+				return errors.Convert(context.Canceled)
+			case models.TASK_FAILED, models.TASK_TIMEOUT:
+				finished++
+				if skipOnFail, err = isSkipOnFail(task.PipelineId); err != nil {
+					return err
+				}
+				if !skipOnFail {
+					taskErr = errors.Default.New(fmt.Sprintf("Error running task %d on worker %s: %s", task.ID, task.WorkerId, task.Message))
+				}
+			case models.TASK_COMPLETED:
+				finished++
+			}
+		}
+		if finished == len(tasks) {
+			return taskErr
+		}
+	}
+}
+
+func isSkipOnFail(pipelineId uint64) (bool, errors.Error) {
+	pipeline, err := GetDbPipeline(pipelineId)
+	if err != nil {
+		return false, err
+	}
+	return pipeline.SkipOnFail, nil
+}
+
+// cancelTaskInCluster marks the task cancelled, or timed out if that is the cause, so the worker running it
+// would stop on next lease renewal
+func cancelTaskInCluster(taskId uint64, cause error) errors.Error {
+	sets := []dal.DalSet{{ColumnName: "status", Value: models.TASK_CANCELLED}}
+	if lakeErr := errors.AsLakeErrorType(cause); lakeErr != nil && lakeErr.GetType() == errors.Timeout {
+		sets = []dal.DalSet{
+			{ColumnName: "status", Value: models.TASK_TIMEOUT},
+			{ColumnName: "message", Value: lakeErr.Error()},
+		}
+	}
+	return db.UpdateColumns(&models.Task{}, sets, dal.Where("id = ? AND status IN ?", taskId, claimableTaskStatus))
+}
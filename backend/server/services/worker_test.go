@@ -0,0 +1,185 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWorkerTest(t *testing.T, worker string) {
+	setupTestDb(t)
+	clusterMode = true
+	workerId = worker
+	t.Cleanup(func() {
+		clusterMode = false
+		workerId = ""
+	})
+}
+
+func createTestTask(t *testing.T, task *models.Task) *models.Task {
+	if task.PipelineId == 0 {
+		pipeline := &models.Pipeline{Name: "test", Status: models.TASK_RUNNING}
+		require.Nil(t, db.Create(pipeline))
+		task.PipelineId = pipeline.ID
+	}
+	require.Nil(t, db.Create(task))
+	return task
+}
+
+func TestClaimLease(t *testing.T) {
+	setupWorkerTest(t, "worker1")
+	task := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_CREATED})
+
+	claimed, err := claimLease(&models.Task{}, task.ID)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	// renewing by the holder
+	claimed, err = claimLease(&models.Task{}, task.ID)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+
+	// held by worker1
+	workerId = "worker2"
+	claimed, err = claimLease(&models.Task{}, task.ID)
+	assert.Nil(t, err)
+	assert.False(t, claimed)
+
+	// the lease of worker1 expired
+	require.Nil(t, db.UpdateColumn(&models.Task{}, "lease_expires_at", time.Now().Add(-time.Second), dal.Where("id = ?", task.ID)))
+	claimed, err = claimLease(&models.Task{}, task.ID, dal.Where("status IN ?", claimableTaskStatus))
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	current, err := GetTask(task.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "worker2", current.WorkerId)
+
+	// the extra clauses must hold as well
+	require.Nil(t, db.UpdateColumn(&models.Task{}, "lease_expires_at", time.Now().Add(-time.Second), dal.Where("id = ?", task.ID)))
+	require.Nil(t, db.UpdateColumn(&models.Task{}, "status", models.TASK_COMPLETED, dal.Where("id = ?", task.ID)))
+	workerId = "worker1"
+	claimed, err = claimLease(&models.Task{}, task.ID, dal.Where("status IN ?", claimableTaskStatus))
+	assert.Nil(t, err)
+	assert.False(t, claimed)
+}
+
+func TestClaimQueuedTask(t *testing.T) {
+	setupWorkerTest(t, "worker1")
+	now := time.Now()
+	expired := now.Add(-time.Second)
+	valid := now.Add(time.Minute)
+	notQueued := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_CREATED})
+	completed := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_COMPLETED, QueuedAt: &now})
+	running := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_RUNNING, QueuedAt: &now,
+		Lease: models.Lease{WorkerId: "worker2", LeaseExpiresAt: &valid}})
+	abandoned := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_RUNNING, QueuedAt: &now,
+		Lease: models.Lease{WorkerId: "worker2", LeaseExpiresAt: &expired}})
+	queued := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_CREATED, QueuedAt: &now})
+
+	claimedIds := make([]uint64, 0)
+	for {
+		task, err := claimQueuedTask()
+		assert.Nil(t, err)
+		if task == nil {
+			break
+		}
+		claimedIds = append(claimedIds, task.ID)
+	}
+	assert.ElementsMatch(t, []uint64{abandoned.ID, queued.ID}, claimedIds)
+	for _, task := range []*models.Task{notQueued, completed, running} {
+		assert.NotContains(t, claimedIds, task.ID)
+	}
+}
+
+func TestRunTasksDistributed(t *testing.T) {
+	setupWorkerTest(t, "worker1")
+	registerTestPlugin(t, "workertestok", func(plugin.SubTaskContext) errors.Error {
+		return nil
+	})
+	registerTestPlugin(t, "workertestfail", func(plugin.SubTaskContext) errors.Error {
+		return errors.Default.New("boom")
+	})
+	pipeline := &models.Pipeline{Name: "test", Status: models.TASK_RUNNING}
+	require.Nil(t, db.Create(pipeline))
+	ok1 := createTestTask(t, &models.Task{Plugin: "workertestok", Status: models.TASK_CREATED, PipelineId: pipeline.ID})
+	ok2 := createTestTask(t, &models.Task{Plugin: "workertestok", Status: models.TASK_CREATED, PipelineId: pipeline.ID, PipelineCol: 1})
+	fail := createTestTask(t, &models.Task{Plugin: "workertestfail", Status: models.TASK_CREATED, PipelineId: pipeline.ID, PipelineCol: 2})
+
+	// the tasks are claimed and run by the consume loop
+	stop := make(chan struct{})
+	defer close(stop)
+	go consumeTasksInLoop(2, stop)
+
+	err := RunTasksDistributed(logruslog.Global, []uint64{ok1.ID, ok2.ID})
+	assert.Nil(t, err)
+	for _, id := range []uint64{ok1.ID, ok2.ID} {
+		task, err := GetTask(id)
+		assert.Nil(t, err)
+		assert.Equal(t, models.TASK_COMPLETED, task.Status)
+		assert.Equal(t, "worker1", task.WorkerId)
+		// the lease is released once the task is done
+		assert.Nil(t, task.LeaseExpiresAt)
+	}
+
+	err = RunTasksDistributed(logruslog.Global, []uint64{fail.ID})
+	assert.NotNil(t, err)
+	task, e := GetTask(fail.ID)
+	assert.Nil(t, e)
+	assert.Equal(t, models.TASK_FAILED, task.Status)
+	assert.Contains(t, task.Message, "boom")
+
+	// failures are ignored when the pipeline skips on fail
+	require.Nil(t, db.UpdateColumn(&models.Pipeline{}, "skip_on_fail", true, dal.Where("id = ?", pipeline.ID)))
+	require.Nil(t, db.UpdateColumns(&models.Task{}, []dal.DalSet{
+		{ColumnName: "status", Value: models.TASK_CREATED},
+		{ColumnName: "queued_at", Value: nil},
+	}, dal.Where("id = ?", fail.ID)))
+	err = RunTasksDistributed(logruslog.Global, []uint64{fail.ID})
+	assert.Nil(t, err)
+}
+
+func TestCancelTaskInCluster(t *testing.T) {
+	setupWorkerTest(t, "worker1")
+	cancelled := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_RUNNING})
+	timedOut := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_RUNNING})
+	completed := createTestTask(t, &models.Task{Plugin: "test", Status: models.TASK_COMPLETED})
+
+	// the tasks are not running on the current worker
+	assert.Nil(t, CancelTask(cancelled.ID))
+	assert.Nil(t, cancelTask(timedOut.ID, errors.Timeout.New("pipeline #1 timed out after 60s")))
+	assert.Nil(t, cancelTask(completed.ID, errors.Timeout.New("pipeline #1 timed out after 60s")))
+
+	task, err := GetTask(cancelled.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_CANCELLED, task.Status)
+	task, err = GetTask(timedOut.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_TIMEOUT, task.Status)
+	assert.Contains(t, task.Message, "pipeline #1 timed out after 60s")
+	task, err = GetTask(completed.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TASK_COMPLETED, task.Status)
+}